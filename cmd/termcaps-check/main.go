@@ -10,17 +10,19 @@ import (
 )
 
 type report struct {
-	Detected     string `json:"detected"`
-	TermProgram  string `json:"term_program,omitempty"`
-	Term         string `json:"term,omitempty"`
-	ItermSession string `json:"iterm_session_id,omitempty"`
-	KittyWindow  string `json:"kitty_window_id,omitempty"`
+	Detected     string            `json:"detected"`
+	Passthrough  string            `json:"passthrough"`
+	TermProgram  string            `json:"term_program,omitempty"`
+	Term         string            `json:"term,omitempty"`
+	ItermSession string            `json:"iterm_session_id,omitempty"`
+	KittyWindow  string            `json:"kitty_window_id,omitempty"`
+	Probe        termcaps.RawProbe `json:"probe"`
 }
 
 func main() {
 	var expect string
 	var asJSON bool
-	flag.StringVar(&expect, "expect", "", "Expected protocol: none|kitty|iterm (optional)")
+	flag.StringVar(&expect, "expect", "", "Expected protocol: none|kitty|iterm|sixel (optional)")
 	flag.BoolVar(&asJSON, "json", true, "Emit JSON")
 	flag.Parse()
 
@@ -28,10 +30,12 @@ func main() {
 
 	r := report{
 		Detected:     detected.String(),
+		Passthrough:  termcaps.DetectPassthrough(os.Getenv).String(),
 		TermProgram:  os.Getenv("TERM_PROGRAM"),
 		Term:         os.Getenv("TERM"),
 		ItermSession: os.Getenv("ITERM_SESSION_ID"),
 		KittyWindow:  os.Getenv("KITTY_WINDOW_ID"),
+		Probe:        termcaps.ProbeRaw(),
 	}
 
 	if asJSON {
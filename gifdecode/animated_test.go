@@ -0,0 +1,346 @@
+package gifdecode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+	"time"
+)
+
+type testAnimFrame struct {
+	img     image.Image
+	x, y    int
+	delayMS int
+	dispose byte // APNG dispose_op; WebP: 1 = dispose to background
+	blend   byte // APNG blend_op; WebP: 1 = no blend
+}
+
+func TestDecodeAPNGFrames(t *testing.T) {
+	red := color.NRGBA{R: 255, A: 255}
+	blue := color.NRGBA{B: 255, A: 255}
+	data := makeTestAPNG(t, 4, 4, 3, []testAnimFrame{
+		{img: solidNRGBA(4, 4, red), delayMS: 50, dispose: 1},
+		{img: solidNRGBA(2, 2, blue), x: 2, y: 2, delayMS: 120, blend: 1},
+	}, false)
+
+	frames, err := Decode(data, DefaultOptions())
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if len(frames.Frames) != 2 || frames.Width != 4 || frames.Height != 4 {
+		t.Fatalf("unexpected result: %d frames %dx%d", len(frames.Frames), frames.Width, frames.Height)
+	}
+	if frames.LoopCount != 2 {
+		t.Fatalf("expected 3 plays -> loop count 2, got %d", frames.LoopCount)
+	}
+	if frames.Frames[0].Delay != 50*time.Millisecond || frames.Frames[1].Delay != 120*time.Millisecond {
+		t.Fatalf("unexpected delays: %v %v", frames.Frames[0].Delay, frames.Frames[1].Delay)
+	}
+	assertFramePixel(t, frames.Frames[0].PNG, 0, 0, red)
+	// Frame 0 disposes to transparent, frame 1 only covers the corner.
+	assertFramePixel(t, frames.Frames[1].PNG, 0, 0, color.NRGBA{})
+	assertFramePixel(t, frames.Frames[1].PNG, 3, 3, blue)
+}
+
+func TestDecodeAPNGSkipsHiddenDefaultImage(t *testing.T) {
+	green := color.NRGBA{G: 255, A: 255}
+	data := makeTestAPNG(t, 2, 2, 0, []testAnimFrame{
+		{img: solidNRGBA(2, 2, color.NRGBA{R: 255, A: 255})},
+		{img: solidNRGBA(2, 2, green), delayMS: 30},
+	}, true)
+	frames, err := Decode(data, DefaultOptions())
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if len(frames.Frames) != 1 || frames.LoopCount != 0 {
+		t.Fatalf("expected only the animated frame, got %d (loop %d)", len(frames.Frames), frames.LoopCount)
+	}
+	assertFramePixel(t, frames.Frames[0].PNG, 1, 1, green)
+}
+
+func TestDecodeAnimatedWebP(t *testing.T) {
+	red := color.NRGBA{R: 255, A: 255}
+	blue := color.NRGBA{B: 255, A: 255}
+	data := makeTestWebP(6, 4, 1, []testAnimFrame{
+		{img: solidNRGBA(6, 4, red), delayMS: 40, dispose: 1},
+		{img: solidNRGBA(2, 2, blue), x: 2, y: 2, delayMS: 90},
+		{img: solidNRGBA(2, 2, color.NRGBA{}), x: 4, y: 0, delayMS: 10, blend: 1},
+	})
+
+	frames, err := Decode(data, DefaultOptions())
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if len(frames.Frames) != 3 || frames.Width != 6 || frames.Height != 4 {
+		t.Fatalf("unexpected result: %d frames %dx%d", len(frames.Frames), frames.Width, frames.Height)
+	}
+	if frames.LoopCount != -1 {
+		t.Fatalf("expected single play -> loop count -1, got %d", frames.LoopCount)
+	}
+	if frames.Frames[1].Delay != 90*time.Millisecond {
+		t.Fatalf("unexpected delay %v", frames.Frames[1].Delay)
+	}
+	assertFramePixel(t, frames.Frames[0].PNG, 5, 3, red)
+	assertFramePixel(t, frames.Frames[1].PNG, 0, 0, color.NRGBA{})
+	assertFramePixel(t, frames.Frames[1].PNG, 3, 3, blue)
+	// Frame 2 doesn't blend, so its transparent pixels replace the canvas.
+	assertFramePixel(t, frames.Frames[2].PNG, 3, 3, blue)
+	assertFramePixel(t, frames.Frames[2].PNG, 4, 0, color.NRGBA{})
+}
+
+func TestDecodeAnimatedFormatsRespectLimits(t *testing.T) {
+	frame := testAnimFrame{img: solidNRGBA(8, 8, color.NRGBA{G: 255, A: 255})}
+	data := makeTestWebP(8, 8, 0, []testAnimFrame{frame, frame, frame})
+	opts := DefaultOptions()
+	opts.MaxFrames = 2
+	opts.MaxWidth = 4
+	frames, err := Decode(data, opts)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if len(frames.Frames) != 2 || frames.Width != 4 || frames.Height != 4 {
+		t.Fatalf("unexpected result: %d frames %dx%d", len(frames.Frames), frames.Width, frames.Height)
+	}
+
+	if _, err := Decode(data, Options{StrictGIF: true}); err == nil {
+		t.Fatalf("expected strict gif error")
+	}
+
+	opts = DefaultOptions()
+	opts.MaxPixels = 10
+	if _, err := Decode(data, opts); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+}
+
+func TestDecodeAnimatedRejectsFramesOutsideCanvas(t *testing.T) {
+	frame := testAnimFrame{img: solidNRGBA(4, 4, color.NRGBA{A: 255}), x: 2}
+	if _, err := Decode(makeTestWebP(4, 4, 0, []testAnimFrame{frame}), DefaultOptions()); !errors.Is(err, errBadWebP) {
+		t.Fatalf("expected errBadWebP, got %v", err)
+	}
+	if _, err := Decode(makeTestAPNG(t, 4, 4, 0, []testAnimFrame{frame}, false), DefaultOptions()); !errors.Is(err, errBadAPNG) {
+		t.Fatalf("expected errBadAPNG, got %v", err)
+	}
+}
+
+func TestLoopCountFromPlays(t *testing.T) {
+	for plays, want := range map[int]int{0: 0, 1: -1, 2: 1, 5: 4} {
+		if got := loopCountFromPlays(plays); got != want {
+			t.Fatalf("plays %d: got %d want %d", plays, got, want)
+		}
+	}
+}
+
+// makeTestAPNG encodes frames with image/png and stitches their IDAT data
+// into fcTL/fdAT chunks. With hiddenDefault the first frame is the static
+// default image and not part of the animation.
+func makeTestAPNG(t *testing.T, width, height, plays int, frames []testAnimFrame, hiddenDefault bool) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	buf.WriteString(pngSignature)
+	seq := uint32(0)
+	animated := len(frames)
+	if hiddenDefault {
+		animated--
+	}
+	for i, f := range frames {
+		var enc bytes.Buffer
+		if err := png.Encode(&enc, f.img); err != nil {
+			t.Fatalf("png encode: %v", err)
+		}
+		chunks, err := readPNGChunks(enc.Bytes())
+		if err != nil {
+			t.Fatalf("read chunks: %v", err)
+		}
+		if i == 0 {
+			ihdr := append([]byte(nil), chunks[0].data...)
+			binary.BigEndian.PutUint32(ihdr, uint32(width))
+			binary.BigEndian.PutUint32(ihdr[4:], uint32(height))
+			writePNGChunk(&buf, "IHDR", ihdr)
+			actl := make([]byte, 8)
+			binary.BigEndian.PutUint32(actl, uint32(animated))
+			binary.BigEndian.PutUint32(actl[4:], uint32(plays))
+			writePNGChunk(&buf, "acTL", actl)
+		}
+		if i > 0 || !hiddenDefault {
+			b := f.img.Bounds()
+			fctl := make([]byte, 26)
+			binary.BigEndian.PutUint32(fctl, seq)
+			binary.BigEndian.PutUint32(fctl[4:], uint32(b.Dx()))
+			binary.BigEndian.PutUint32(fctl[8:], uint32(b.Dy()))
+			binary.BigEndian.PutUint32(fctl[12:], uint32(f.x))
+			binary.BigEndian.PutUint32(fctl[16:], uint32(f.y))
+			binary.BigEndian.PutUint16(fctl[20:], uint16(f.delayMS))
+			binary.BigEndian.PutUint16(fctl[22:], 1000)
+			fctl[24] = f.dispose
+			fctl[25] = f.blend
+			writePNGChunk(&buf, "fcTL", fctl)
+			seq++
+		}
+		for _, c := range chunks {
+			if c.typ != "IDAT" {
+				continue
+			}
+			if i == 0 {
+				writePNGChunk(&buf, "IDAT", c.data)
+				continue
+			}
+			fdat := make([]byte, 4, 4+len(c.data))
+			binary.BigEndian.PutUint32(fdat, seq)
+			writePNGChunk(&buf, "fdAT", append(fdat, c.data...))
+			seq++
+		}
+	}
+	writePNGChunk(&buf, "IEND", nil)
+	return buf.Bytes()
+}
+
+// makeTestWebP builds an animated WebP from solid-color VP8L frames.
+func makeTestWebP(width, height, plays int, frames []testAnimFrame) []byte {
+	var body bytes.Buffer
+	body.WriteString("WEBP")
+	vp8x := make([]byte, 10)
+	vp8x[0] = webpFlagAnimation | 0x10
+	putUint24(vp8x[4:], uint32(width-1))
+	putUint24(vp8x[7:], uint32(height-1))
+	writeRIFFChunk(&body, "VP8X", vp8x)
+	anim := make([]byte, 6)
+	binary.LittleEndian.PutUint16(anim[4:], uint16(plays))
+	writeRIFFChunk(&body, "ANIM", anim)
+	for _, f := range frames {
+		b := f.img.Bounds()
+		hdr := make([]byte, 16)
+		putUint24(hdr[0:], uint32(f.x/2))
+		putUint24(hdr[3:], uint32(f.y/2))
+		putUint24(hdr[6:], uint32(b.Dx()-1))
+		putUint24(hdr[9:], uint32(b.Dy()-1))
+		putUint24(hdr[12:], uint32(f.delayMS))
+		hdr[15] = f.blend<<1 | f.dispose
+		var frame bytes.Buffer
+		frame.Write(hdr)
+		c, _ := color.NRGBAModel.Convert(f.img.At(b.Min.X, b.Min.Y)).(color.NRGBA)
+		writeRIFFChunk(&frame, "VP8L", solidVP8L(b.Dx(), b.Dy(), c))
+		writeRIFFChunk(&body, "ANMF", frame.Bytes())
+	}
+	out := make([]byte, 8, 8+body.Len())
+	copy(out, "RIFF")
+	binary.LittleEndian.PutUint32(out[4:], uint32(body.Len()))
+	return append(out, body.Bytes()...)
+}
+
+// solidVP8L writes a lossless bitstream whose five prefix codes each have a
+// single symbol, so every pixel costs zero bits.
+func solidVP8L(width, height int, c color.NRGBA) []byte {
+	var w bitWriter
+	w.write(0x2f, 8)
+	w.write(uint32(width-1), 14)
+	w.write(uint32(height-1), 14)
+	w.write(1, 1) // alpha used
+	w.write(0, 3) // version
+	w.write(0, 1) // no transforms
+	w.write(0, 1) // no color cache
+	w.write(0, 1) // no meta prefix codes
+	for _, sym := range []uint8{c.G, c.R, c.B, c.A, 0} {
+		w.write(1, 1) // simple code
+		w.write(0, 1) // one symbol
+		w.write(1, 1) // 8-bit symbol
+		w.write(uint32(sym), 8)
+	}
+	return w.bytes()
+}
+
+type bitWriter struct {
+	buf  []byte
+	nbit uint
+}
+
+func (w *bitWriter) write(v uint32, n uint) {
+	for i := uint(0); i < n; i++ {
+		if w.nbit%8 == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		if v>>i&1 == 1 {
+			w.buf[len(w.buf)-1] |= 1 << (w.nbit % 8)
+		}
+		w.nbit++
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	return w.buf
+}
+
+func solidNRGBA(w, h int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+func assertFramePixel(t *testing.T, pngData []byte, x, y int, want color.NRGBA) {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(pngData))
+	if err != nil {
+		t.Fatalf("png decode: %v", err)
+	}
+	got, ok := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+	if !ok || got != want {
+		t.Fatalf("pixel (%d,%d) = %v, want %v", x, y, got, want)
+	}
+}
+
+func TestFormatAndSize(t *testing.T) {
+	frame := testAnimFrame{img: solidNRGBA(6, 4, color.NRGBA{A: 255})}
+	webpData := makeTestWebP(6, 4, 0, []testAnimFrame{frame})
+	apngData := makeTestAPNG(t, 6, 4, 0, []testAnimFrame{frame}, false)
+	var plain bytes.Buffer
+	if err := png.Encode(&plain, frame.img); err != nil {
+		t.Fatalf("png encode: %v", err)
+	}
+	cases := map[string][]byte{
+		"gif":  makeTestGIF(1),
+		"webp": webpData,
+		"apng": apngData,
+		"png":  plain.Bytes(),
+		"":     []byte("nope"),
+	}
+	for want, data := range cases {
+		if got := Format(data); got != want {
+			t.Fatalf("Format = %q, want %q", got, want)
+		}
+	}
+	if w, h := Size(webpData); w != 6 || h != 4 {
+		t.Fatalf("webp size %dx%d", w, h)
+	}
+	if w, h := Size(apngData); w != 6 || h != 4 {
+		t.Fatalf("apng size %dx%d", w, h)
+	}
+}
+
+func TestTimingCountsEveryFrame(t *testing.T) {
+	// Delays run 50ms, 70ms, … and clamp at 1s from frame 49 on.
+	n, d, err := Timing(makeTestGIF(70), DefaultOptions())
+	if err != nil || n != 70 || d != 46960*time.Millisecond {
+		t.Fatalf("expected 70 frames over 46.96s past the decode cap, got %d %v %v", n, d, err)
+	}
+	frame := testAnimFrame{img: solidNRGBA(2, 2, color.NRGBA{A: 255}), delayMS: 40}
+	if n, d, err := Timing(makeTestWebP(2, 2, 0, []testAnimFrame{frame, frame, frame}), DefaultOptions()); err != nil || n != 3 || d != 120*time.Millisecond {
+		t.Fatalf("unexpected WebP timing %d %v %v", n, d, err)
+	}
+	if n, d, err := Timing(makeTestAPNG(t, 2, 2, 0, []testAnimFrame{frame, frame}, true), DefaultOptions()); err != nil || n != 1 || d != 40*time.Millisecond {
+		t.Fatalf("expected the hidden default image skipped, got %d %v %v", n, d, err)
+	}
+	var still bytes.Buffer
+	_ = png.Encode(&still, solidNRGBA(2, 2, color.NRGBA{}))
+	if n, _, err := Timing(still.Bytes(), DefaultOptions()); err != nil || n != 1 {
+		t.Fatalf("expected a still to be one frame, got %d %v", n, err)
+	}
+	if _, _, err := Timing([]byte("nope"), DefaultOptions()); err == nil {
+		t.Fatal("expected unknown data to fail")
+	}
+}
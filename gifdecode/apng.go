@@ -0,0 +1,211 @@
+package gifdecode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"time"
+)
+
+var errBadAPNG = errors.New("apng: malformed animation")
+
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+type apngFrame struct {
+	width, height int
+	x, y          int
+	delay         time.Duration
+	dispose       byte
+	blend         byte
+	data          [][]byte // IDAT payloads
+}
+
+// isAPNG reports whether data is a PNG with an animation control chunk.
+// Plain PNGs go through the single-frame path.
+func isAPNG(data []byte) bool {
+	if !bytes.HasPrefix(data, []byte(pngSignature)) {
+		return false
+	}
+	chunks, err := readPNGChunks(data)
+	if err != nil {
+		return false
+	}
+	for _, c := range chunks {
+		switch c.typ {
+		case "acTL":
+			return true
+		case "IDAT":
+			return false
+		}
+	}
+	return false
+}
+
+func readPNGChunks(data []byte) ([]pngChunk, error) {
+	var chunks []pngChunk
+	p := len(pngSignature)
+	for p+8 <= len(data) {
+		n := int(binary.BigEndian.Uint32(data[p:]))
+		typ := string(data[p+4 : p+8])
+		if p+12+n > len(data) {
+			return nil, errBadAPNG
+		}
+		chunks = append(chunks, pngChunk{typ: typ, data: data[p+8 : p+8+n]})
+		p += 12 + n
+		if typ == "IEND" {
+			break
+		}
+	}
+	return chunks, nil
+}
+
+func decodeAPNG(data []byte, opts Options) (*Frames, error) {
+	chunks, err := readPNGChunks(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) == 0 || chunks[0].typ != "IHDR" || len(chunks[0].data) != 13 {
+		return nil, errBadAPNG
+	}
+	ihdr := chunks[0].data
+	width := int(binary.BigEndian.Uint32(ihdr))
+	height := int(binary.BigEndian.Uint32(ihdr[4:]))
+
+	// Chunks before the first IDAT (palette, transparency, gamma) apply to
+	// every frame.
+	var shared []pngChunk
+	var frames []*apngFrame
+	var cur *apngFrame
+	plays := 0
+	seenIDAT := false
+	for _, c := range chunks[1:] {
+		switch c.typ {
+		case "acTL":
+			if len(c.data) != 8 {
+				return nil, errBadAPNG
+			}
+			plays = int(binary.BigEndian.Uint32(c.data[4:]))
+		case "fcTL":
+			f, err := parseFCTL(c.data)
+			if err != nil {
+				return nil, err
+			}
+			frames = append(frames, f)
+			cur = f
+		case "IDAT":
+			seenIDAT = true
+			// IDAT is only part of the animation when an fcTL precedes it.
+			if cur != nil {
+				cur.data = append(cur.data, c.data)
+			}
+		case "fdAT":
+			if cur == nil || len(c.data) < 4 {
+				return nil, errBadAPNG
+			}
+			cur.data = append(cur.data, c.data[4:])
+		case "IEND":
+		default:
+			if !seenIDAT {
+				shared = append(shared, c)
+			}
+		}
+	}
+	if len(frames) == 0 {
+		return nil, ErrNoFrames
+	}
+
+	return composite(width, height, len(frames), loopCountFromPlays(plays), opts, func(i int) (animFrame, error) {
+		f := frames[i]
+		bounds := image.Rect(f.x, f.y, f.x+f.width, f.y+f.height)
+		if !bounds.In(image.Rect(0, 0, width, height)) {
+			return animFrame{}, fmt.Errorf("%w: frame %d outside canvas", errBadAPNG, i)
+		}
+		img, err := decodeAPNGFrame(ihdr, shared, f)
+		if err != nil {
+			return animFrame{}, fmt.Errorf("apng frame %d: %w", i, err)
+		}
+		af := animFrame{
+			img:     img,
+			bounds:  bounds,
+			delay:   f.delay,
+			replace: f.blend == 0,
+		}
+		switch f.dispose {
+		case 1:
+			af.dispose = disposeBackground
+		case 2:
+			// The spec treats "previous" on the first frame as "background".
+			if i == 0 {
+				af.dispose = disposeBackground
+			} else {
+				af.dispose = disposePrevious
+			}
+		}
+		return af, nil
+	})
+}
+
+func parseFCTL(data []byte) (*apngFrame, error) {
+	if len(data) != 26 {
+		return nil, errBadAPNG
+	}
+	f := &apngFrame{
+		width:   int(binary.BigEndian.Uint32(data[4:])),
+		height:  int(binary.BigEndian.Uint32(data[8:])),
+		x:       int(binary.BigEndian.Uint32(data[12:])),
+		y:       int(binary.BigEndian.Uint32(data[16:])),
+		dispose: data[24],
+		blend:   data[25],
+	}
+	num := int(binary.BigEndian.Uint16(data[20:]))
+	den := int(binary.BigEndian.Uint16(data[22:]))
+	if den == 0 {
+		den = 100
+	}
+	f.delay = time.Duration(num) * time.Second / time.Duration(den)
+	if f.width <= 0 || f.height <= 0 {
+		return nil, errBadAPNG
+	}
+	return f, nil
+}
+
+// decodeAPNGFrame rebuilds a standalone PNG for one frame (IHDR with the
+// frame size, shared chunks, frame data) and decodes it with image/png.
+func decodeAPNGFrame(ihdr []byte, shared []pngChunk, f *apngFrame) (image.Image, error) {
+	var buf bytes.Buffer
+	buf.WriteString(pngSignature)
+	hdr := append([]byte(nil), ihdr...)
+	binary.BigEndian.PutUint32(hdr, uint32(f.width))
+	binary.BigEndian.PutUint32(hdr[4:], uint32(f.height))
+	writePNGChunk(&buf, "IHDR", hdr)
+	for _, c := range shared {
+		writePNGChunk(&buf, c.typ, c.data)
+	}
+	for _, d := range f.data {
+		writePNGChunk(&buf, "IDAT", d)
+	}
+	writePNGChunk(&buf, "IEND", nil)
+	return png.Decode(&buf)
+}
+
+func writePNGChunk(buf *bytes.Buffer, typ string, data []byte) {
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(data)))
+	buf.Write(n[:])
+	crc := crc32.NewIEEE()
+	_, _ = crc.Write([]byte(typ))
+	_, _ = crc.Write(data)
+	buf.WriteString(typ)
+	buf.Write(data)
+	binary.BigEndian.PutUint32(n[:], crc.Sum32())
+	buf.Write(n[:])
+}
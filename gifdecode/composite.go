@@ -0,0 +1,114 @@
+package gifdecode
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"time"
+)
+
+type disposeOp int
+
+const (
+	disposeNone disposeOp = iota
+	disposeBackground
+	disposePrevious
+)
+
+// animFrame is one frame of a GIF, APNG or animated WebP, positioned on the
+// canvas. Formats differ only in how they fill these fields.
+type animFrame struct {
+	img     image.Image
+	bounds  image.Rectangle
+	delay   time.Duration // 0 = opts.DefaultDelay
+	replace bool          // overwrite the frame area instead of alpha blending
+	dispose disposeOp
+	clear   color.Color // fill for disposeBackground; nil = transparent
+}
+
+// composite renders count frames from next onto a width×height canvas and
+// encodes each step, downscaled to the options' pixel box.
+func composite(width, height, count, loopCount int, opts Options, next func(i int) (animFrame, error)) (*Frames, error) {
+	if count == 0 {
+		return nil, ErrNoFrames
+	}
+	if width <= 0 || height <= 0 {
+		return nil, ErrInvalidSize
+	}
+	if exceedsPixels(width, height, opts.MaxPixels) {
+		return nil, fmt.Errorf("%w: pixels=%d limit=%d", ErrTooLarge, width*height, opts.MaxPixels)
+	}
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	prev := image.NewRGBA(canvas.Bounds())
+
+	outW, outH := fitSize(width, height, opts.MaxWidth, opts.MaxHeight)
+	var scaler *resampler
+	if outW != width || outH != height {
+		scaler = newResampler(width, height, outW, outH)
+	}
+
+	limit := count
+	if opts.MaxFrames > 0 && opts.MaxFrames < limit {
+		limit = opts.MaxFrames
+	}
+	frames := make([]Frame, 0, limit)
+
+	for i := 0; i < limit; i++ {
+		f, err := next(i)
+		if err != nil {
+			return nil, err
+		}
+		if f.dispose == disposePrevious {
+			copy(prev.Pix, canvas.Pix)
+		}
+
+		op := draw.Over
+		if f.replace {
+			op = draw.Src
+		}
+		draw.Draw(canvas, f.bounds, f.img, f.img.Bounds().Min, op)
+		var out image.Image = canvas
+		if scaler != nil {
+			out = scaler.resample(canvas)
+		}
+		pngData, err := encodePNG(out)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, Frame{PNG: pngData, Delay: resolveDelay(f.delay, opts)})
+
+		switch f.dispose {
+		case disposeBackground:
+			fill := f.clear
+			if fill == nil {
+				fill = color.Transparent
+			}
+			draw.Draw(canvas, f.bounds, &image.Uniform{C: fill}, image.Point{}, draw.Src)
+		case disposePrevious:
+			copy(canvas.Pix, prev.Pix)
+		}
+	}
+
+	return &Frames{Frames: frames, Width: outW, Height: outH, LoopCount: loopCount}, nil
+}
+
+func resolveDelay(delay time.Duration, opts Options) time.Duration {
+	if delay <= 0 {
+		delay = opts.DefaultDelay
+	}
+	return clampDelay(delay, opts)
+}
+
+// loopCountFromPlays converts a "total plays" count (APNG, WebP; 0 = forever)
+// to the image/gif convention used by Frames.LoopCount.
+func loopCountFromPlays(plays int) int {
+	switch {
+	case plays <= 0:
+		return 0
+	case plays == 1:
+		return -1
+	default:
+		return plays - 1
+	}
+}
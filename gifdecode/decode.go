@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"image"
 	"image/color"
-	"image/draw"
 	"image/gif"
 	_ "image/jpeg" // allow image.Decode fallback for stills
 	"image/png"
@@ -23,6 +22,9 @@ type Frames struct {
 	Frames []Frame
 	Width  int
 	Height int
+	// LoopCount follows image/gif: 0 loops forever, -1 plays once,
+	// n plays n+1 times.
+	LoopCount int
 }
 
 var (
@@ -44,6 +46,14 @@ func DecodeReader(r io.Reader, opts Options) (*Frames, error) {
 }
 
 func decodeBytes(data []byte, opts Options) (*Frames, error) {
+	if !opts.StrictGIF {
+		switch {
+		case isAnimatedWebP(data):
+			return decodeWebP(data, opts)
+		case isAPNG(data):
+			return decodeAPNG(data, opts)
+		}
+	}
 	g, err := gif.DecodeAll(bytes.NewReader(data))
 	if err != nil {
 		if opts.StrictGIF {
@@ -68,49 +78,21 @@ func decodeGIF(g *gif.GIF, opts Options) (*Frames, error) {
 		b := g.Image[0].Bounds()
 		width, height = b.Dx(), b.Dy()
 	}
-	if width <= 0 || height <= 0 {
-		return nil, ErrInvalidSize
-	}
-	if exceedsPixels(width, height, opts.MaxPixels) {
-		return nil, fmt.Errorf("%w: pixels=%d limit=%d", ErrTooLarge, width*height, opts.MaxPixels)
-	}
-
-	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
-	prev := image.NewRGBA(canvas.Bounds())
 	bg := backgroundColor(g)
-
-	limit := len(g.Image)
-	if opts.MaxFrames > 0 && opts.MaxFrames < limit {
-		limit = opts.MaxFrames
-	}
-	frames := make([]Frame, 0, limit)
-
-	for i := 0; i < limit; i++ {
+	return composite(width, height, len(g.Image), g.LoopCount, opts, func(i int) (animFrame, error) {
 		frame := g.Image[i]
-		disposal := gif.DisposalNone
+		f := animFrame{img: frame, bounds: frame.Bounds(), delay: gifDelay(g, i)}
 		if i < len(g.Disposal) {
-			disposal = int(g.Disposal[i])
-		}
-		if disposal == gif.DisposalPrevious {
-			copy(prev.Pix, canvas.Pix)
+			switch g.Disposal[i] {
+			case gif.DisposalBackground:
+				f.dispose = disposeBackground
+				f.clear = disposalColor(frame, g.BackgroundIndex, bg)
+			case gif.DisposalPrevious:
+				f.dispose = disposePrevious
+			}
 		}
-
-		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
-		pngData, err := encodePNG(canvas)
-		if err != nil {
-			return nil, err
-		}
-		frames = append(frames, Frame{PNG: pngData, Delay: frameDelay(g, i, opts)})
-
-		switch disposal {
-		case gif.DisposalBackground:
-			draw.Draw(canvas, frame.Bounds(), &image.Uniform{C: bg}, image.Point{}, draw.Src)
-		case gif.DisposalPrevious:
-			copy(canvas.Pix, prev.Pix)
-		}
-	}
-
-	return &Frames{Frames: frames, Width: width, Height: height}, nil
+		return f, nil
+	})
 }
 
 func singleFrame(img image.Image, opts Options) (*Frames, error) {
@@ -122,26 +104,24 @@ func singleFrame(img image.Image, opts Options) (*Frames, error) {
 	if exceedsPixels(width, height, opts.MaxPixels) {
 		return nil, fmt.Errorf("%w: pixels=%d limit=%d", ErrTooLarge, width*height, opts.MaxPixels)
 	}
+	img = Resize(img, opts.MaxWidth, opts.MaxHeight)
+	b = img.Bounds()
 	pngData, err := encodePNG(img)
 	if err != nil {
 		return nil, err
 	}
 	return &Frames{
 		Frames: []Frame{{PNG: pngData, Delay: clampDelay(opts.DefaultDelay, opts)}},
-		Width:  width,
-		Height: height,
+		Width:  b.Dx(),
+		Height: b.Dy(),
 	}, nil
 }
 
-func frameDelay(g *gif.GIF, idx int, opts Options) time.Duration {
-	var delay time.Duration
+func gifDelay(g *gif.GIF, idx int) time.Duration {
 	if idx < len(g.Delay) {
-		delay = time.Duration(g.Delay[idx]) * 10 * time.Millisecond
-	}
-	if delay <= 0 {
-		delay = opts.DefaultDelay
+		return time.Duration(g.Delay[idx]) * 10 * time.Millisecond
 	}
-	return clampDelay(delay, opts)
+	return 0
 }
 
 func clampDelay(delay time.Duration, opts Options) time.Duration {
@@ -166,6 +146,18 @@ func backgroundColor(g *gif.GIF) color.Color {
 	return pal[idx]
 }
 
+// disposalColor clears to transparent when the background index is the
+// frame's transparent index, matching browsers and encoders that rely on it.
+func disposalColor(frame *image.Paletted, bgIndex byte, bg color.Color) color.Color {
+	idx := int(bgIndex)
+	if idx < len(frame.Palette) {
+		if _, _, _, a := frame.Palette[idx].RGBA(); a == 0 {
+			return color.Transparent
+		}
+	}
+	return bg
+}
+
 func encodePNG(img image.Image) ([]byte, error) {
 	bufAny := pngPool.Get()
 	buf, ok := bufAny.(*bytes.Buffer)
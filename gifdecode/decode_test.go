@@ -61,6 +61,18 @@ func TestDecodeLimits(t *testing.T) {
 		t.Fatalf("expected 2 frames, got %d", len(frames.Frames))
 	}
 
+	long := makeTestGIF(70)
+	frames, err = Decode(long, DefaultOptions())
+	if err != nil || len(frames.Frames) != defaultMaxFrames {
+		t.Fatalf("expected the default cap, got %v", err)
+	}
+	opts = DefaultOptions()
+	opts.MaxFrames = NoFrameLimit
+	frames, err = Decode(long, opts)
+	if err != nil || len(frames.Frames) != 70 {
+		t.Fatalf("expected every frame without a limit, got %v", err)
+	}
+
 	opts = DefaultOptions()
 	opts.MaxPixels = 1
 	_, err = Decode(data, opts)
@@ -289,6 +301,77 @@ func TestWithDefaultsMaxDelayClamp(t *testing.T) {
 	}
 }
 
+func TestDecodeDownscalesToMaxBox(t *testing.T) {
+	data := makeMediumGIF()
+	opts := DefaultOptions()
+	opts.MaxWidth = 40
+	opts.MaxHeight = 40
+	frames, err := Decode(data, opts)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if frames.Width != 40 || frames.Height != 30 {
+		t.Fatalf("expected 40x30, got %dx%d", frames.Width, frames.Height)
+	}
+	img, err := png.Decode(bytes.NewReader(frames.Frames[0].PNG))
+	if err != nil {
+		t.Fatalf("png decode failed: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 40 || b.Dy() != 30 {
+		t.Fatalf("expected png 40x30, got %dx%d", b.Dx(), b.Dy())
+	}
+
+	opts.MaxWidth = 500
+	opts.MaxHeight = 500
+	frames, err = Decode(data, opts)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if frames.Width != 80 || frames.Height != 60 {
+		t.Fatalf("expected no upscale, got %dx%d", frames.Width, frames.Height)
+	}
+}
+
+func TestResizeAveragesPixels(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x += 2 {
+		src.Set(x, 0, color.White)
+		src.Set(x, 1, color.White)
+		src.Set(x+1, 0, color.Black)
+		src.Set(x+1, 1, color.Black)
+	}
+	out := Resize(src, 2, 0)
+	if b := out.Bounds(); b.Dx() != 2 || b.Dy() != 1 {
+		t.Fatalf("expected 2x1, got %dx%d", b.Dx(), b.Dy())
+	}
+	r, g, b, a := out.At(0, 0).RGBA()
+	if a != 0xffff || r>>8 < 126 || r>>8 > 129 || g != r || b != r {
+		t.Fatalf("expected mid gray, got %d %d %d %d", r>>8, g>>8, b>>8, a>>8)
+	}
+	if Resize(src, 10, 10) != image.Image(src) {
+		t.Fatalf("expected unchanged image inside box")
+	}
+}
+
+func TestFitSize(t *testing.T) {
+	cases := []struct {
+		w, h, maxW, maxH int
+		wantW, wantH     int
+	}{
+		{w: 100, h: 50, maxW: 0, maxH: 0, wantW: 100, wantH: 50},
+		{w: 100, h: 50, maxW: 50, maxH: 0, wantW: 50, wantH: 25},
+		{w: 100, h: 50, maxW: 0, maxH: 10, wantW: 20, wantH: 10},
+		{w: 100, h: 50, maxW: 40, maxH: 40, wantW: 40, wantH: 20},
+		{w: 1000, h: 1, maxW: 10, maxH: 10, wantW: 10, wantH: 1},
+	}
+	for _, tc := range cases {
+		w, h := fitSize(tc.w, tc.h, tc.maxW, tc.maxH)
+		if w != tc.wantW || h != tc.wantH {
+			t.Fatalf("fitSize(%d,%d,%d,%d) = %dx%d, want %dx%d", tc.w, tc.h, tc.maxW, tc.maxH, w, h, tc.wantW, tc.wantH)
+		}
+	}
+}
+
 func makeTestGIF(count int) []byte {
 	pal := color.Palette{color.Black, color.White}
 	frames := make([]*image.Paletted, 0, count)
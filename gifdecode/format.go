@@ -0,0 +1,96 @@
+package gifdecode
+
+import (
+	"bytes"
+	"image"
+	"image/gif"
+	"time"
+)
+
+// Format names the container of data: "gif", "apng", "png", "webp", "jpeg",
+// or "" when unknown.
+func Format(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return "gif"
+	case isAPNG(data):
+		return "apng"
+	case bytes.HasPrefix(data, []byte(pngSignature)):
+		return "png"
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return "webp"
+	case bytes.HasPrefix(data, []byte{0xff, 0xd8, 0xff}):
+		return "jpeg"
+	}
+	return ""
+}
+
+// Size reads the canvas size from the header without decoding pixels.
+func Size(data []byte) (int, int) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0
+	}
+	return cfg.Width, cfg.Height
+}
+
+// Timing counts every frame of data and adds up their delays as Decode
+// resolves them with opts, without compositing any frame and regardless
+// of opts.MaxFrames. A still image is one frame with no delay.
+func Timing(data []byte, opts Options) (int, time.Duration, error) {
+	opts = opts.withDefaults()
+	var delays []time.Duration
+	switch Format(data) {
+	case "gif":
+		g, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return 0, 0, err
+		}
+		for i := range g.Image {
+			delays = append(delays, gifDelay(g, i))
+		}
+	case "apng":
+		chunks, err := readPNGChunks(data)
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, c := range chunks {
+			if c.typ == "fcTL" {
+				f, err := parseFCTL(c.data)
+				if err != nil {
+					return 0, 0, err
+				}
+				delays = append(delays, f.delay)
+			}
+		}
+	case "webp":
+		if !isAnimatedWebP(data) {
+			return 1, 0, nil
+		}
+		chunks, err := readRIFFChunks(data[12:])
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, c := range chunks {
+			if c.id == "ANMF" {
+				f, err := parseANMF(c.data)
+				if err != nil {
+					return 0, 0, err
+				}
+				delays = append(delays, f.delay)
+			}
+		}
+	case "png", "jpeg":
+		return 1, 0, nil
+	default:
+		return 0, 0, image.ErrFormat
+	}
+	if len(delays) == 0 {
+		return 0, 0, ErrNoFrames
+	}
+	var total time.Duration
+	for _, d := range delays {
+		total += resolveDelay(d, opts)
+	}
+	return len(delays), total, nil
+}
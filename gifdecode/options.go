@@ -2,6 +2,9 @@ package gifdecode
 
 import "time"
 
+// NoFrameLimit as Options.MaxFrames decodes every frame.
+const NoFrameLimit = -1
+
 const (
 	defaultMaxFrames = 60
 	defaultMaxPixels = 40_000_000
@@ -15,6 +18,8 @@ const (
 )
 
 type Options struct {
+	// MaxFrames caps how many frames are decoded; zero means the default
+	// (60) and NoFrameLimit means all of them.
 	MaxFrames    int
 	MaxPixels    int
 	MaxBytes     int64
@@ -22,6 +27,12 @@ type Options struct {
 	MinDelay     time.Duration
 	MaxDelay     time.Duration
 	StrictGIF    bool
+
+	// MaxWidth and MaxHeight bound the output frame size in pixels. Larger
+	// sources are downscaled (aspect preserved) after compositing; zero
+	// leaves that axis unbounded.
+	MaxWidth  int
+	MaxHeight int
 }
 
 func (o Options) withDefaults() Options {
@@ -0,0 +1,171 @@
+package gifdecode
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// fitSize returns the largest size with the source aspect ratio that fits into
+// maxW×maxH. It never upscales; a zero bound means unbounded on that axis.
+func fitSize(width, height, maxW, maxH int) (int, int) {
+	if width <= 0 || height <= 0 {
+		return width, height
+	}
+	scale := 1.0
+	if maxW > 0 && width > maxW {
+		scale = math.Min(scale, float64(maxW)/float64(width))
+	}
+	if maxH > 0 && height > maxH {
+		scale = math.Min(scale, float64(maxH)/float64(height))
+	}
+	if scale >= 1 {
+		return width, height
+	}
+	w := int(math.Round(float64(width) * scale))
+	h := int(math.Round(float64(height) * scale))
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return w, h
+}
+
+type resampleWeight struct {
+	first   int
+	weights []float64
+}
+
+// areaWeights computes box-filter (area averaging) contributions for scaling
+// srcLen pixels down to dstLen pixels. Each destination pixel covers an exact
+// fractional span of the source, which avoids the aliasing of point sampling.
+func areaWeights(srcLen, dstLen int) []resampleWeight {
+	out := make([]resampleWeight, dstLen)
+	scale := float64(srcLen) / float64(dstLen)
+	for i := range out {
+		start := float64(i) * scale
+		end := start + scale
+		first := int(math.Floor(start))
+		last := int(math.Ceil(end))
+		if last > srcLen {
+			last = srcLen
+		}
+		weights := make([]float64, 0, last-first)
+		for s := first; s < last; s++ {
+			lo := math.Max(start, float64(s))
+			hi := math.Min(end, float64(s+1))
+			weights = append(weights, (hi-lo)/scale)
+		}
+		out[i] = resampleWeight{first: first, weights: weights}
+	}
+	return out
+}
+
+type resampler struct {
+	srcW, srcH int
+	dstW, dstH int
+	xWeights   []resampleWeight
+	yWeights   []resampleWeight
+	tmp        []float64
+	dst        *image.RGBA
+}
+
+func newResampler(srcW, srcH, dstW, dstH int) *resampler {
+	return &resampler{
+		srcW:     srcW,
+		srcH:     srcH,
+		dstW:     dstW,
+		dstH:     dstH,
+		xWeights: areaWeights(srcW, dstW),
+		yWeights: areaWeights(srcH, dstH),
+		tmp:      make([]float64, dstW*srcH*4),
+		dst:      image.NewRGBA(image.Rect(0, 0, dstW, dstH)),
+	}
+}
+
+// resample scales src into the resampler's reusable destination image.
+// Pixels are premultiplied, so averaging them directly keeps edges against
+// transparent areas free of dark fringes.
+func (r *resampler) resample(src *image.RGBA) *image.RGBA {
+	for y := 0; y < r.srcH; y++ {
+		row := src.Pix[y*src.Stride:]
+		for x, xw := range r.xWeights {
+			var cr, cg, cb, ca float64
+			for k, w := range xw.weights {
+				p := (xw.first + k) * 4
+				cr += float64(row[p]) * w
+				cg += float64(row[p+1]) * w
+				cb += float64(row[p+2]) * w
+				ca += float64(row[p+3]) * w
+			}
+			t := (y*r.dstW + x) * 4
+			r.tmp[t] = cr
+			r.tmp[t+1] = cg
+			r.tmp[t+2] = cb
+			r.tmp[t+3] = ca
+		}
+	}
+	for y, yw := range r.yWeights {
+		dstRow := r.dst.Pix[y*r.dst.Stride:]
+		for x := 0; x < r.dstW; x++ {
+			var cr, cg, cb, ca float64
+			for k, w := range yw.weights {
+				t := ((yw.first+k)*r.dstW + x) * 4
+				cr += r.tmp[t] * w
+				cg += r.tmp[t+1] * w
+				cb += r.tmp[t+2] * w
+				ca += r.tmp[t+3] * w
+			}
+			p := x * 4
+			dstRow[p] = clampChannel(cr)
+			dstRow[p+1] = clampChannel(cg)
+			dstRow[p+2] = clampChannel(cb)
+			dstRow[p+3] = clampChannel(ca)
+		}
+	}
+	return r.dst
+}
+
+func clampChannel(v float64) uint8 {
+	v = math.Round(v)
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
+
+// Resize scales img down to fit into maxW×maxH, preserving aspect ratio.
+// Images already inside the box are returned unchanged.
+func Resize(img image.Image, maxW, maxH int) image.Image {
+	b := img.Bounds()
+	w, h := fitSize(b.Dx(), b.Dy(), maxW, maxH)
+	if w == b.Dx() && h == b.Dy() {
+		return img
+	}
+	return Scale(img, w, h)
+}
+
+// Scale resamples img to exactly width×height. Downscaling averages source
+// areas; upscaling only blends neighbours at pixel seams, keeping edges crisp.
+func Scale(img image.Image, width, height int) image.Image {
+	b := img.Bounds()
+	if width <= 0 || height <= 0 || (width == b.Dx() && height == b.Dy()) {
+		return img
+	}
+	return newResampler(b.Dx(), b.Dy(), width, height).resample(toRGBA(img))
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba
+}
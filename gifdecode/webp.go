@@ -0,0 +1,179 @@
+package gifdecode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"time"
+
+	"golang.org/x/image/webp"
+)
+
+var errBadWebP = errors.New("webp: malformed animation")
+
+const webpFlagAnimation = 0x02
+
+type riffChunk struct {
+	id   string
+	data []byte
+}
+
+type webpFrame struct {
+	x, y          int
+	width, height int
+	delay         time.Duration
+	replace       bool
+	dispose       bool
+	chunks        []riffChunk // ALPH + VP8/VP8L
+}
+
+// isAnimatedWebP reports whether data is a WebP whose VP8X header sets the
+// animation flag. Still WebPs decode through image.Decode.
+func isAnimatedWebP(data []byte) bool {
+	if len(data) < 21 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return false
+	}
+	return string(data[12:16]) == "VP8X" && data[20]&webpFlagAnimation != 0
+}
+
+func readRIFFChunks(data []byte) ([]riffChunk, error) {
+	var chunks []riffChunk
+	for p := 0; p+8 <= len(data); {
+		n := int(binary.LittleEndian.Uint32(data[p+4:]))
+		if p+8+n > len(data) {
+			return nil, errBadWebP
+		}
+		chunks = append(chunks, riffChunk{id: string(data[p : p+4]), data: data[p+8 : p+8+n]})
+		p += 8 + n + n&1
+	}
+	return chunks, nil
+}
+
+func decodeWebP(data []byte, opts Options) (*Frames, error) {
+	if len(data) < 12 {
+		return nil, errBadWebP
+	}
+	chunks, err := readRIFFChunks(data[12:])
+	if err != nil {
+		return nil, err
+	}
+	var width, height, plays int
+	var frames []webpFrame
+	for _, c := range chunks {
+		switch c.id {
+		case "VP8X":
+			if len(c.data) < 10 {
+				return nil, errBadWebP
+			}
+			width = int(uint24(c.data[4:])) + 1
+			height = int(uint24(c.data[7:])) + 1
+		case "ANIM":
+			if len(c.data) < 6 {
+				return nil, errBadWebP
+			}
+			plays = int(binary.LittleEndian.Uint16(c.data[4:]))
+		case "ANMF":
+			f, err := parseANMF(c.data)
+			if err != nil {
+				return nil, err
+			}
+			frames = append(frames, f)
+		}
+	}
+	if len(frames) == 0 {
+		return nil, ErrNoFrames
+	}
+
+	// Disposal clears to transparent; the ANIM background color is only a
+	// hint and browsers ignore it too.
+	return composite(width, height, len(frames), loopCountFromPlays(plays), opts, func(i int) (animFrame, error) {
+		f := frames[i]
+		bounds := image.Rect(f.x, f.y, f.x+f.width, f.y+f.height)
+		if !bounds.In(image.Rect(0, 0, width, height)) {
+			return animFrame{}, fmt.Errorf("%w: frame %d outside canvas", errBadWebP, i)
+		}
+		img, err := webp.Decode(bytes.NewReader(webpFrameFile(f)))
+		if err != nil {
+			return animFrame{}, fmt.Errorf("webp frame %d: %w", i, err)
+		}
+		af := animFrame{img: img, bounds: bounds, delay: f.delay, replace: f.replace}
+		if f.dispose {
+			af.dispose = disposeBackground
+		}
+		return af, nil
+	})
+}
+
+func parseANMF(data []byte) (webpFrame, error) {
+	if len(data) < 16 {
+		return webpFrame{}, errBadWebP
+	}
+	flags := data[15]
+	f := webpFrame{
+		x:       2 * int(uint24(data[0:])),
+		y:       2 * int(uint24(data[3:])),
+		width:   int(uint24(data[6:])) + 1,
+		height:  int(uint24(data[9:])) + 1,
+		delay:   time.Duration(uint24(data[12:])) * time.Millisecond,
+		replace: flags&0x02 != 0,
+		dispose: flags&0x01 != 0,
+	}
+	sub, err := readRIFFChunks(data[16:])
+	if err != nil {
+		return webpFrame{}, err
+	}
+	for _, c := range sub {
+		switch c.id {
+		case "ALPH", "VP8 ", "VP8L":
+			f.chunks = append(f.chunks, c)
+		}
+	}
+	if len(f.chunks) == 0 {
+		return webpFrame{}, errBadWebP
+	}
+	return f, nil
+}
+
+// webpFrameFile wraps one frame's bitstream in a standalone WebP file. Lossy
+// frames with an alpha chunk need the extended (VP8X) header.
+func webpFrameFile(f webpFrame) []byte {
+	var body bytes.Buffer
+	body.WriteString("WEBP")
+	if f.chunks[0].id == "ALPH" {
+		vp8x := make([]byte, 10)
+		vp8x[0] = 0x10 // alpha
+		putUint24(vp8x[4:], uint32(f.width-1))
+		putUint24(vp8x[7:], uint32(f.height-1))
+		writeRIFFChunk(&body, "VP8X", vp8x)
+	}
+	for _, c := range f.chunks {
+		writeRIFFChunk(&body, c.id, c.data)
+	}
+	out := make([]byte, 8, 8+body.Len())
+	copy(out, "RIFF")
+	binary.LittleEndian.PutUint32(out[4:], uint32(body.Len()))
+	return append(out, body.Bytes()...)
+}
+
+func writeRIFFChunk(buf *bytes.Buffer, id string, data []byte) {
+	var n [4]byte
+	binary.LittleEndian.PutUint32(n[:], uint32(len(data)))
+	buf.WriteString(id)
+	buf.Write(n[:])
+	buf.Write(data)
+	if len(data)&1 == 1 {
+		buf.WriteByte(0)
+	}
+}
+
+func uint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
+
+func putUint24(b []byte, v uint32) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+}
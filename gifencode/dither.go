@@ -0,0 +1,78 @@
+package gifencode
+
+// ditherer carries Floyd–Steinberg error terms for the current and next row.
+// A disabled ditherer passes colors through untouched.
+type ditherer struct {
+	enabled bool
+	cur     []int32
+	next    []int32
+}
+
+func newDitherer(width int, enabled bool) *ditherer {
+	d := &ditherer{enabled: enabled}
+	if enabled {
+		// One pixel of padding on each side keeps the spread loop branch-free.
+		d.cur = make([]int32, (width+2)*3)
+		d.next = make([]int32, (width+2)*3)
+	}
+	return d
+}
+
+func (d *ditherer) reset() {
+	if !d.enabled {
+		return
+	}
+	clear(d.cur)
+	clear(d.next)
+}
+
+func (d *ditherer) apply(x, r, g, b int) (int, int, int) {
+	if !d.enabled {
+		return r, g, b
+	}
+	i := (x + 1) * 3
+	return clamp8(r + int(d.cur[i]>>4)), clamp8(g + int(d.cur[i+1]>>4)), clamp8(b + int(d.cur[i+2]>>4))
+}
+
+// skip drops accumulated error at transparent pixels so it doesn't bleed into
+// the opaque edge.
+func (d *ditherer) skip(x int) {
+	if !d.enabled {
+		return
+	}
+	i := (x + 1) * 3
+	d.cur[i], d.cur[i+1], d.cur[i+2] = 0, 0, 0
+}
+
+// spread distributes the quantization error (in 1/16ths) to the right
+// neighbour and the three pixels below.
+func (d *ditherer) spread(x, er, eg, eb int) {
+	if !d.enabled {
+		return
+	}
+	i := (x + 1) * 3
+	for c, e := range [3]int32{int32(er), int32(eg), int32(eb)} {
+		d.cur[i+3+c] += e * 7
+		d.next[i-3+c] += e * 3
+		d.next[i+c] += e * 5
+		d.next[i+3+c] += e
+	}
+}
+
+func (d *ditherer) nextRow() {
+	if !d.enabled {
+		return
+	}
+	d.cur, d.next = d.next, d.cur
+	clear(d.next)
+}
+
+func clamp8(v int) int {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return v
+}
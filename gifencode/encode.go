@@ -0,0 +1,290 @@
+package gifencode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"time"
+
+	"github.com/steipete/gifgrep/gifdecode"
+)
+
+// Frame is one fully composited animation frame.
+type Frame struct {
+	Image image.Image
+	Delay time.Duration
+}
+
+// FromDecoded converts decoded PNG frames back into images for editing.
+func FromDecoded(decoded *gifdecode.Frames) ([]Frame, error) {
+	if decoded == nil || len(decoded.Frames) == 0 {
+		return nil, ErrNoFrames
+	}
+	out := make([]Frame, 0, len(decoded.Frames))
+	for _, f := range decoded.Frames {
+		img, err := png.Decode(bytes.NewReader(f.PNG))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Frame{Image: img, Delay: f.Delay})
+	}
+	return out, nil
+}
+
+// Encode writes frames as an optimized animated GIF. All frames must have the
+// same size. Colors are reduced to one shared palette with median cut.
+func Encode(w io.Writer, frames []Frame, opts Options) error {
+	g, err := Build(frames, opts)
+	if err != nil {
+		return err
+	}
+	return gif.EncodeAll(w, g)
+}
+
+// EncodeBytes is Encode into a byte slice.
+func EncodeBytes(frames []Frame, opts Options) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, frames, opts); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Build quantizes and optimizes frames into an image/gif structure without
+// serializing it.
+func Build(frames []Frame, opts Options) (*gif.GIF, error) {
+	opts = opts.withDefaults()
+	if len(frames) == 0 {
+		return nil, ErrNoFrames
+	}
+
+	sources, err := normalizeFrames(frames)
+	if err != nil {
+		return nil, err
+	}
+	bounds := sources[0].Rect
+
+	histogram, hasAlpha := buildHistogram(sources)
+	optimize := !opts.NoOptimize && len(sources) > 1
+	palette, pal, transparentIndex := buildPalette(histogram, opts.MaxColors, hasAlpha || optimize)
+
+	mapper := newPaletteMapper(pal)
+	ditherer := newDitherer(bounds.Dx(), opts.Dither)
+
+	g := &gif.GIF{
+		LoopCount: opts.LoopCount,
+		Config: image.Config{
+			ColorModel: palette,
+			Width:      bounds.Dx(),
+			Height:     bounds.Dy(),
+		},
+	}
+	if transparentIndex >= 0 {
+		g.BackgroundIndex = uint8(transparentIndex)
+	}
+
+	var prev *image.Paletted
+	for i, src := range sources {
+		cur := quantizeFrame(src, palette, mapper, ditherer, transparentIndex)
+		delay := delayCentiseconds(frames[i].Delay)
+
+		if !optimize || prev == nil {
+			appendFrame(g, cur, delay, gif.DisposalNone)
+			prev = cur
+			continue
+		}
+
+		changed, needsClear := diffFrames(prev, cur, transparentIndex)
+		if changed.Empty() {
+			last := len(g.Delay) - 1
+			if g.Delay[last]+delay <= maxDelayCentiseconds {
+				// Identical to what's on screen: fold the delay into the previous frame.
+				g.Delay[last] += delay
+				continue
+			}
+			// The merged delay wouldn't fit; keep showing prev with a
+			// transparent single-pixel frame instead.
+			changed = image.Rectangle{Min: bounds.Min, Max: bounds.Min.Add(image.Pt(1, 1))}
+		}
+		if needsClear {
+			// Pixels turn transparent, which "do not dispose" can't express.
+			// Redraw the previous frame in full and clear it afterwards, then
+			// draw this one in full too.
+			last := len(g.Image) - 1
+			g.Image[last] = clonePaletted(prev)
+			g.Disposal[last] = gif.DisposalBackground
+			appendFrame(g, cur, delay, gif.DisposalNone)
+			prev = cur
+			continue
+		}
+
+		appendFrame(g, cropDelta(prev, cur, changed, transparentIndex), delay, gif.DisposalNone)
+		prev = cur
+	}
+	return g, nil
+}
+
+// Quantize reduces a single image to its own palette of at most
+// opts.MaxColors colors. When the image has transparent pixels, the last
+// palette entry is fully transparent.
+func Quantize(img image.Image, opts Options) (*image.Paletted, error) {
+	opts = opts.withDefaults()
+	sources, err := normalizeFrames([]Frame{{Image: img}})
+	if err != nil {
+		return nil, err
+	}
+	src := sources[0]
+	histogram, hasAlpha := buildHistogram(sources)
+	palette, pal, transparentIndex := buildPalette(histogram, opts.MaxColors, hasAlpha)
+	return quantizeFrame(src, palette, newPaletteMapper(pal), newDitherer(src.Rect.Dx(), opts.Dither), transparentIndex), nil
+}
+
+// buildPalette runs median cut over histogram and, when transparent is set,
+// appends a transparent entry. It returns the full palette, the opaque colors
+// and the transparent index (-1 if none).
+func buildPalette(histogram []colorCount, maxColors int, transparent bool) (color.Palette, []color.NRGBA, int) {
+	colors := maxColors
+	if transparent {
+		colors--
+	}
+	pal := medianCut(histogram, colors)
+	palette := make(color.Palette, 0, len(pal)+1)
+	for _, c := range pal {
+		palette = append(palette, c)
+	}
+	transparentIndex := -1
+	if transparent {
+		transparentIndex = len(palette)
+		palette = append(palette, color.NRGBA{})
+	}
+	return palette, pal, transparentIndex
+}
+
+func normalizeFrames(frames []Frame) ([]*image.NRGBA, error) {
+	out := make([]*image.NRGBA, 0, len(frames))
+	var size image.Point
+	for i, f := range frames {
+		if f.Image == nil {
+			return nil, fmt.Errorf("%w: frame %d is empty", ErrInvalidSize, i)
+		}
+		b := f.Image.Bounds()
+		if b.Dx() <= 0 || b.Dy() <= 0 || b.Dx() > 0xffff || b.Dy() > 0xffff {
+			return nil, fmt.Errorf("%w: frame %d is %dx%d", ErrInvalidSize, i, b.Dx(), b.Dy())
+		}
+		if i == 0 {
+			size = b.Size()
+		} else if b.Size() != size {
+			return nil, fmt.Errorf("%w: frame %d is %dx%d, want %dx%d", ErrSizeMismatch, i, b.Dx(), b.Dy(), size.X, size.Y)
+		}
+		nrgba := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(nrgba, nrgba.Rect, f.Image, b.Min, draw.Src)
+		out = append(out, nrgba)
+	}
+	return out, nil
+}
+
+func quantizeFrame(src *image.NRGBA, palette color.Palette, mapper *paletteMapper, d *ditherer, transparentIndex int) *image.Paletted {
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewPaletted(src.Rect, palette)
+	d.reset()
+	for y := 0; y < h; y++ {
+		row := src.Pix[y*src.Stride:]
+		out := dst.Pix[y*dst.Stride:]
+		for x := 0; x < w; x++ {
+			p := x * 4
+			if row[p+3] < alphaThreshold && transparentIndex >= 0 {
+				out[x] = uint8(transparentIndex)
+				d.skip(x)
+				continue
+			}
+			r, g, b := d.apply(x, int(row[p]), int(row[p+1]), int(row[p+2]))
+			idx := mapper.index(r, g, b)
+			out[x] = idx
+			c := mapper.pal[idx]
+			d.spread(x, r-int(c.R), g-int(c.G), b-int(c.B))
+		}
+		d.nextRow()
+	}
+	return dst
+}
+
+// diffFrames returns the bounding box of pixels that differ between two
+// quantized frames, and whether any pixel goes from opaque to transparent.
+func diffFrames(prev, cur *image.Paletted, transparentIndex int) (image.Rectangle, bool) {
+	w, h := cur.Rect.Dx(), cur.Rect.Dy()
+	minX, minY, maxX, maxY := w, h, -1, -1
+	needsClear := false
+	for y := 0; y < h; y++ {
+		a := prev.Pix[y*prev.Stride : y*prev.Stride+w]
+		b := cur.Pix[y*cur.Stride : y*cur.Stride+w]
+		for x := 0; x < w; x++ {
+			if a[x] == b[x] {
+				continue
+			}
+			if int(b[x]) == transparentIndex {
+				needsClear = true
+			}
+			if x < minX {
+				minX = x
+			}
+			if x > maxX {
+				maxX = x
+			}
+			if y < minY {
+				minY = y
+			}
+			if y > maxY {
+				maxY = y
+			}
+		}
+	}
+	if maxX < 0 {
+		return image.Rectangle{}, false
+	}
+	return image.Rect(minX, minY, maxX+1, maxY+1), needsClear
+}
+
+// cropDelta cuts cur down to the changed rectangle and marks pixels that
+// match the previous frame as transparent, so they show through and
+// compress to long runs.
+func cropDelta(prev, cur *image.Paletted, r image.Rectangle, transparentIndex int) *image.Paletted {
+	out := image.NewPaletted(r, cur.Palette)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			v := cur.Pix[cur.PixOffset(x, y)]
+			if transparentIndex >= 0 && v == prev.Pix[prev.PixOffset(x, y)] {
+				v = uint8(transparentIndex)
+			}
+			out.Pix[out.PixOffset(x, y)] = v
+		}
+	}
+	return out
+}
+
+func clonePaletted(src *image.Paletted) *image.Paletted {
+	out := image.NewPaletted(src.Rect, src.Palette)
+	copy(out.Pix, src.Pix)
+	return out
+}
+
+func appendFrame(g *gif.GIF, img *image.Paletted, delay int, disposal byte) {
+	g.Image = append(g.Image, img)
+	g.Delay = append(g.Delay, delay)
+	g.Disposal = append(g.Disposal, disposal)
+}
+
+const maxDelayCentiseconds = int(MaxDelay / (10 * time.Millisecond))
+
+func delayCentiseconds(d time.Duration) int {
+	d = min(d, MaxDelay)
+	cs := int((d + 5*time.Millisecond) / (10 * time.Millisecond))
+	if cs < 1 {
+		return 1
+	}
+	return cs
+}
@@ -0,0 +1,239 @@
+package gifencode
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/steipete/gifgrep/gifdecode"
+)
+
+func TestEncodeRoundTrip(t *testing.T) {
+	frames := []Frame{
+		{Image: solidFrame(8, 6, color.NRGBA{R: 255, A: 255}), Delay: 100 * time.Millisecond},
+		{Image: solidFrame(8, 6, color.NRGBA{G: 255, A: 255}), Delay: 200 * time.Millisecond},
+	}
+	data, err := EncodeBytes(frames, Options{LoopCount: 3})
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	decoded, err := gifdecode.Decode(data, gifdecode.DefaultOptions())
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if len(decoded.Frames) != 2 || decoded.Width != 8 || decoded.Height != 6 {
+		t.Fatalf("unexpected decode result: %d frames %dx%d", len(decoded.Frames), decoded.Width, decoded.Height)
+	}
+	if decoded.LoopCount != 3 {
+		t.Fatalf("expected loop count 3, got %d", decoded.LoopCount)
+	}
+	if decoded.Frames[0].Delay != 100*time.Millisecond || decoded.Frames[1].Delay != 200*time.Millisecond {
+		t.Fatalf("unexpected delays: %v %v", decoded.Frames[0].Delay, decoded.Frames[1].Delay)
+	}
+	assertPixel(t, decoded.Frames[1].PNG, 4, 3, color.NRGBA{G: 255, A: 255})
+}
+
+func TestEncodeCropsUnchangedArea(t *testing.T) {
+	base := solidFrame(20, 20, color.NRGBA{B: 200, A: 255})
+	next := solidFrame(20, 20, color.NRGBA{B: 200, A: 255})
+	next.Set(5, 7, color.NRGBA{R: 255, A: 255})
+	next.Set(6, 8, color.NRGBA{R: 255, A: 255})
+
+	g, err := Build([]Frame{{Image: base, Delay: 50 * time.Millisecond}, {Image: next, Delay: 50 * time.Millisecond}}, DefaultOptions())
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if got := g.Image[1].Bounds(); got != image.Rect(5, 7, 7, 9) {
+		t.Fatalf("expected cropped delta frame, got %v", got)
+	}
+	if g.Image[1].ColorIndexAt(6, 7) != uint8(g.BackgroundIndex) {
+		t.Fatalf("expected unchanged pixel to reuse transparency")
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	decoded, err := gifdecode.Decode(buf.Bytes(), gifdecode.DefaultOptions())
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	assertPixel(t, decoded.Frames[1].PNG, 0, 0, color.NRGBA{B: 200, A: 255})
+	assertPixel(t, decoded.Frames[1].PNG, 5, 7, color.NRGBA{R: 255, A: 255})
+}
+
+func TestEncodeMergesDuplicateFrames(t *testing.T) {
+	img := solidFrame(4, 4, color.NRGBA{R: 10, G: 20, B: 30, A: 255})
+	g, err := Build([]Frame{
+		{Image: img, Delay: 50 * time.Millisecond},
+		{Image: img, Delay: 70 * time.Millisecond},
+	}, DefaultOptions())
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if len(g.Image) != 1 || g.Delay[0] != 12 {
+		t.Fatalf("expected one frame with merged delay, got %d frames %v", len(g.Image), g.Delay)
+	}
+
+	g, err = Build([]Frame{{Image: img}, {Image: img}}, Options{NoOptimize: true})
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if len(g.Image) != 2 {
+		t.Fatalf("expected no merging without optimization")
+	}
+}
+
+func TestEncodeSplitsMergedDelayAtGIFMax(t *testing.T) {
+	img := solidFrame(4, 4, color.NRGBA{R: 10, G: 20, B: 30, A: 255})
+	g, err := Build([]Frame{
+		{Image: img, Delay: 400 * time.Second},
+		{Image: img, Delay: 400 * time.Second},
+		{Image: img, Delay: time.Second},
+	}, DefaultOptions())
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if len(g.Image) != 2 || g.Delay[0] != 40000 || g.Delay[1] != 40100 {
+		t.Fatalf("expected the delay to split at the GIF max, got %d frames %v", len(g.Image), g.Delay)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	decoded, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if decoded.Delay[0] != 40000 || decoded.Delay[1] != 40100 {
+		t.Fatalf("delays did not survive encoding: %v", decoded.Delay)
+	}
+}
+
+func TestEncodeClearsPixelsThatTurnTransparent(t *testing.T) {
+	opaque := solidFrame(4, 4, color.NRGBA{R: 255, A: 255})
+	holey := solidFrame(4, 4, color.NRGBA{R: 255, A: 255})
+	holey.Set(1, 1, color.NRGBA{})
+
+	data, err := EncodeBytes([]Frame{{Image: opaque}, {Image: holey}}, DefaultOptions())
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	decoded, err := gifdecode.Decode(data, gifdecode.DefaultOptions())
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(decoded.Frames[1].PNG))
+	if err != nil {
+		t.Fatalf("png decode failed: %v", err)
+	}
+	if _, _, _, a := img.At(1, 1).RGBA(); a != 0 {
+		t.Fatalf("expected transparent pixel, alpha=%d", a)
+	}
+}
+
+func TestEncodeQuantizesToMaxColors(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 4), G: uint8(y * 4), B: 128, A: 255})
+		}
+	}
+	for _, dither := range []bool{false, true} {
+		g, err := Build([]Frame{{Image: img}}, Options{MaxColors: 16, Dither: dither})
+		if err != nil {
+			t.Fatalf("build failed: %v", err)
+		}
+		pal, ok := g.Config.ColorModel.(color.Palette)
+		if !ok || len(pal) > 16 {
+			t.Fatalf("expected <=16 colors, got %d", len(pal))
+		}
+	}
+}
+
+func TestQuantizeSingleImage(t *testing.T) {
+	img := solidFrame(4, 4, color.NRGBA{R: 200, G: 10, B: 10, A: 255})
+	img.Set(0, 0, color.NRGBA{})
+	img.Set(1, 0, color.NRGBA{B: 255, A: 255})
+	p, err := Quantize(img, Options{MaxColors: 4})
+	if err != nil {
+		t.Fatalf("quantize failed: %v", err)
+	}
+	if len(p.Palette) != 3 {
+		t.Fatalf("expected 2 colors + transparent, got %d", len(p.Palette))
+	}
+	if _, _, _, a := p.Palette[p.ColorIndexAt(0, 0)].RGBA(); a != 0 {
+		t.Fatalf("expected transparent pixel at 0,0")
+	}
+	if r, _, _, _ := p.Palette[p.ColorIndexAt(2, 2)].RGBA(); r>>8 != 200 {
+		t.Fatalf("expected red pixel at 2,2, got r=%d", r>>8)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	if _, err := EncodeBytes(nil, DefaultOptions()); !errors.Is(err, ErrNoFrames) {
+		t.Fatalf("expected ErrNoFrames, got %v", err)
+	}
+	_, err := EncodeBytes([]Frame{
+		{Image: solidFrame(2, 2, color.NRGBA{A: 255})},
+		{Image: solidFrame(3, 2, color.NRGBA{A: 255})},
+	}, DefaultOptions())
+	if !errors.Is(err, ErrSizeMismatch) {
+		t.Fatalf("expected ErrSizeMismatch, got %v", err)
+	}
+	if _, err := FromDecoded(nil); !errors.Is(err, ErrNoFrames) {
+		t.Fatalf("expected ErrNoFrames, got %v", err)
+	}
+}
+
+func TestFromDecoded(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, solidFrame(3, 2, color.NRGBA{B: 255, A: 255})); err != nil {
+		t.Fatalf("png encode failed: %v", err)
+	}
+	frames, err := FromDecoded(&gifdecode.Frames{
+		Frames: []gifdecode.Frame{{PNG: buf.Bytes(), Delay: 40 * time.Millisecond}},
+	})
+	if err != nil {
+		t.Fatalf("from decoded failed: %v", err)
+	}
+	if len(frames) != 1 || frames[0].Delay != 40*time.Millisecond || frames[0].Image.Bounds().Dx() != 3 {
+		t.Fatalf("unexpected frames: %+v", frames)
+	}
+}
+
+func TestDelayCentisecondsClampsToGIFMax(t *testing.T) {
+	if cs := delayCentiseconds(time.Hour); cs != 65535 {
+		t.Fatalf("expected the longest GIF delay, got %d", cs)
+	}
+	if opts := DecodeOptions(); opts.MaxFrames != gifdecode.NoFrameLimit || opts.MaxDelay != MaxDelay {
+		t.Fatalf("unexpected decode options %+v", opts)
+	}
+}
+
+func solidFrame(w, h int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func assertPixel(t *testing.T, pngData []byte, x, y int, want color.NRGBA) {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(pngData))
+	if err != nil {
+		t.Fatalf("png decode failed: %v", err)
+	}
+	got := color.NRGBAModel.Convert(img.At(x, y))
+	if got != want {
+		t.Fatalf("pixel (%d,%d) = %v, want %v", x, y, got, want)
+	}
+}
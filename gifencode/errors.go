@@ -0,0 +1,9 @@
+package gifencode
+
+import "errors"
+
+var (
+	ErrNoFrames     = errors.New("no frames to encode")
+	ErrInvalidSize  = errors.New("frame has invalid size")
+	ErrSizeMismatch = errors.New("frames differ in size")
+)
@@ -0,0 +1,56 @@
+package gifencode
+
+import (
+	"time"
+
+	"github.com/steipete/gifgrep/gifdecode"
+)
+
+const (
+	defaultMaxColors = 256
+	minColors        = 2
+)
+
+// MaxDelay is the longest delay a GIF can store (65535 centiseconds).
+const MaxDelay = 65535 * 10 * time.Millisecond
+
+type Options struct {
+	// LoopCount follows image/gif: 0 loops forever, -1 plays once,
+	// n plays n+1 times.
+	LoopCount int
+	// MaxColors caps the shared palette size (2..256, default 256). One
+	// slot is reserved for transparency when the output needs it.
+	MaxColors int
+	// Dither enables Floyd–Steinberg error diffusion while mapping pixels
+	// to the palette.
+	Dither bool
+	// NoOptimize disables frame-difference cropping, transparency reuse and
+	// dropping of duplicate frames. Every frame is then written in full.
+	NoOptimize bool
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxColors <= 0 || o.MaxColors > 256 {
+		o.MaxColors = defaultMaxColors
+	}
+	if o.MaxColors < minColors {
+		o.MaxColors = minColors
+	}
+	if o.LoopCount < -1 {
+		o.LoopCount = -1
+	}
+	return o
+}
+
+func DefaultOptions() Options {
+	return Options{MaxColors: defaultMaxColors}
+}
+
+// DecodeOptions are the gifdecode options for re-encoding a GIF: every
+// frame, and long holds kept instead of the preview clamp.
+func DecodeOptions() gifdecode.Options {
+	opts := gifdecode.DefaultOptions()
+	opts.MaxFrames = gifdecode.NoFrameLimit
+	opts.MaxDelay = MaxDelay
+	return opts
+}
@@ -0,0 +1,254 @@
+package gifencode
+
+import (
+	"image"
+	"image/color"
+	"sort"
+)
+
+// alphaThreshold is the cut-off below which a pixel is written as transparent.
+// GIF has 1-bit alpha, so anything in between has to go one way or the other.
+const alphaThreshold = 128
+
+// maxHistogramSamples bounds the work spent building the palette; large or
+// long animations are sampled with a stride instead of visiting every pixel.
+const maxHistogramSamples = 1 << 20
+
+type colorCount struct {
+	r, g, b uint8
+	n       int
+}
+
+type colorBox struct {
+	colors []colorCount
+	total  int
+}
+
+// buildHistogram counts opaque colors across all frames. It also reports
+// whether any pixel falls below the alpha threshold.
+func buildHistogram(frames []*image.NRGBA) ([]colorCount, bool) {
+	pixels := 0
+	for _, f := range frames {
+		pixels += f.Rect.Dx() * f.Rect.Dy()
+	}
+	stride := 1
+	if pixels > maxHistogramSamples {
+		stride = pixels/maxHistogramSamples + 1
+	}
+
+	counts := map[uint32]int{}
+	transparent := false
+	for _, f := range frames {
+		w, h := f.Rect.Dx(), f.Rect.Dy()
+		for y := 0; y < h; y++ {
+			row := f.Pix[y*f.Stride : y*f.Stride+w*4]
+			for x := 0; x < w; x++ {
+				p := x * 4
+				if row[p+3] < alphaThreshold {
+					transparent = true
+					continue
+				}
+				if (y*w+x)%stride != 0 {
+					continue
+				}
+				key := uint32(row[p])<<16 | uint32(row[p+1])<<8 | uint32(row[p+2])
+				counts[key]++
+			}
+		}
+	}
+
+	out := make([]colorCount, 0, len(counts))
+	for key, n := range counts {
+		out = append(out, colorCount{r: uint8(key >> 16), g: uint8(key >> 8), b: uint8(key), n: n})
+	}
+	// Map iteration order is random; sort so palettes are reproducible.
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.r != b.r {
+			return a.r < b.r
+		}
+		if a.g != b.g {
+			return a.g < b.g
+		}
+		return a.b < b.b
+	})
+	return out, transparent
+}
+
+// medianCut reduces colors to at most n representatives. Boxes are split
+// along their widest channel at the population-weighted median, always
+// picking the box with the largest range×population next.
+func medianCut(colors []colorCount, n int) []color.NRGBA {
+	if n <= 0 {
+		return nil
+	}
+	if len(colors) == 0 {
+		return []color.NRGBA{{A: 0xff}}
+	}
+	if len(colors) <= n {
+		pal := make([]color.NRGBA, 0, len(colors))
+		for _, c := range colors {
+			pal = append(pal, color.NRGBA{R: c.r, G: c.g, B: c.b, A: 0xff})
+		}
+		return pal
+	}
+
+	boxes := []colorBox{newColorBox(colors)}
+	for len(boxes) < n {
+		best := -1
+		bestScore := 0
+		for i, box := range boxes {
+			if len(box.colors) < 2 {
+				continue
+			}
+			_, width := box.widestChannel()
+			score := width * box.total
+			if score > bestScore {
+				best = i
+				bestScore = score
+			}
+		}
+		if best < 0 {
+			break
+		}
+		a, b := boxes[best].split()
+		boxes[best] = a
+		boxes = append(boxes, b)
+	}
+
+	pal := make([]color.NRGBA, 0, len(boxes))
+	for _, box := range boxes {
+		pal = append(pal, box.average())
+	}
+	return pal
+}
+
+func newColorBox(colors []colorCount) colorBox {
+	total := 0
+	for _, c := range colors {
+		total += c.n
+	}
+	return colorBox{colors: colors, total: total}
+}
+
+func (b colorBox) widestChannel() (int, int) {
+	minC := [3]int{255, 255, 255}
+	maxC := [3]int{}
+	for _, c := range b.colors {
+		for i, v := range [3]int{int(c.r), int(c.g), int(c.b)} {
+			if v < minC[i] {
+				minC[i] = v
+			}
+			if v > maxC[i] {
+				maxC[i] = v
+			}
+		}
+	}
+	channel, width := 0, -1
+	for i := range minC {
+		if w := maxC[i] - minC[i]; w > width {
+			channel, width = i, w
+		}
+	}
+	return channel, width
+}
+
+func (b colorBox) split() (colorBox, colorBox) {
+	channel, _ := b.widestChannel()
+	value := func(c colorCount) uint8 {
+		switch channel {
+		case 0:
+			return c.r
+		case 1:
+			return c.g
+		default:
+			return c.b
+		}
+	}
+	sort.SliceStable(b.colors, func(i, j int) bool {
+		return value(b.colors[i]) < value(b.colors[j])
+	})
+
+	half := b.total / 2
+	acc := 0
+	cut := 1
+	for i, c := range b.colors {
+		acc += c.n
+		if acc >= half {
+			cut = i + 1
+			break
+		}
+	}
+	if cut >= len(b.colors) {
+		cut = len(b.colors) - 1
+	}
+	return newColorBox(b.colors[:cut]), newColorBox(b.colors[cut:])
+}
+
+func (b colorBox) average() color.NRGBA {
+	var r, g, bl, total int
+	for _, c := range b.colors {
+		r += int(c.r) * c.n
+		g += int(c.g) * c.n
+		bl += int(c.b) * c.n
+		total += c.n
+	}
+	if total == 0 {
+		return color.NRGBA{A: 0xff}
+	}
+	return color.NRGBA{
+		R: uint8((r + total/2) / total),
+		G: uint8((g + total/2) / total),
+		B: uint8((bl + total/2) / total),
+		A: 0xff,
+	}
+}
+
+// paletteMapper finds the nearest palette entry for an RGB color. Lookups are
+// memoized on a 5-bit-per-channel grid, which keeps dithered frames (where
+// nearly every pixel is a new color) fast.
+type paletteMapper struct {
+	pal    []color.NRGBA
+	exact  map[uint32]uint8
+	lookup []int16
+}
+
+func newPaletteMapper(pal []color.NRGBA) *paletteMapper {
+	lookup := make([]int16, 1<<15)
+	for i := range lookup {
+		lookup[i] = -1
+	}
+	exact := make(map[uint32]uint8, len(pal))
+	for i, c := range pal {
+		exact[uint32(c.R)<<16|uint32(c.G)<<8|uint32(c.B)] = uint8(i)
+	}
+	return &paletteMapper{pal: pal, exact: exact, lookup: lookup}
+}
+
+func (m *paletteMapper) index(r, g, b int) uint8 {
+	if idx, ok := m.exact[uint32(r)<<16|uint32(g)<<8|uint32(b)]; ok {
+		return idx
+	}
+	key := (r>>3)<<10 | (g>>3)<<5 | (b >> 3)
+	if idx := m.lookup[key]; idx >= 0 {
+		return uint8(idx)
+	}
+	best := 0
+	bestDist := int(^uint(0) >> 1)
+	for i, c := range m.pal {
+		dr := r - int(c.R)
+		dg := g - int(c.G)
+		db := b - int(c.B)
+		// Weighted distance approximating perceived brightness differences.
+		d := 2*dr*dr + 4*dg*dg + 3*db*db
+		if d < bestDist {
+			best = i
+			bestDist = d
+			if d == 0 {
+				break
+			}
+		}
+	}
+	m.lookup[key] = int16(best)
+	return uint8(best)
+}
@@ -5,12 +5,12 @@ go 1.25
 toolchain go1.25.5
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/alecthomas/kong v1.13.0
 	github.com/mattn/go-runewidth v0.0.19
+	golang.org/x/image v0.25.0
+	golang.org/x/sys v0.39.0
 	golang.org/x/term v0.38.0
 )
 
-require (
-	github.com/clipperhouse/uax29/v2 v2.2.0 // indirect
-	golang.org/x/sys v0.39.0 // indirect
-)
+require github.com/clipperhouse/uax29/v2 v2.2.0 // indirect
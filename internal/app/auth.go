@@ -0,0 +1,66 @@
+package app
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/steipete/gifgrep/internal/auth"
+	"golang.org/x/term"
+)
+
+type AuthCmd struct {
+	Set AuthSetCmd `cmd:"" help:"Store an API key (read from stdin, never an argument)."`
+}
+
+type AuthSetCmd struct {
+	Provider string `arg:"" enum:"giphy,tenor" help:"giphy or tenor."`
+	Backend  string `help:"Where the key lives: file (credentials.toml, 0600), pass, command or secret-service." enum:"file,pass,command,secret-service" default:"file"`
+	Entry    string `help:"pass entry name (default gifgrep/PROVIDER)." placeholder:"NAME"`
+	Command  string `help:"Shell command that prints the key (command backend)." placeholder:"CMD"`
+}
+
+func (c *AuthSetCmd) Run(ctx *kong.Context) error {
+	e := auth.Entry{Backend: auth.Backend(c.Backend), Name: c.Entry, Command: c.Command}
+	if e.Backend == auth.BackendCommand {
+		if err := auth.Set(c.Provider, e, ""); err != nil {
+			return err
+		}
+	} else {
+		key, err := readKeyFn(ctx.Stderr, c.Provider)
+		if err != nil {
+			return err
+		}
+		if err := auth.Set(c.Provider, e, key); err != nil {
+			return err
+		}
+	}
+	_, _ = fmt.Fprintf(ctx.Stderr, "%s key stored (%s) in %s\n", c.Provider, c.Backend, auth.Path())
+	return nil
+}
+
+var readKeyFn = readKey
+
+// readKey prompts without echo on a terminal, else reads the first line of
+// stdin, so keys stay out of shell history and ps.
+func readKey(prompt io.Writer, provider string) (string, error) {
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		_, _ = fmt.Fprintf(prompt, "%s API key: ", provider)
+		key, err := term.ReadPassword(fd)
+		_, _ = fmt.Fprintln(prompt)
+		return string(key), err
+	}
+	return readKeyLine(os.Stdin)
+}
+
+func readKeyLine(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
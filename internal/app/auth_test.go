@@ -0,0 +1,40 @@
+package app
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/steipete/gifgrep/internal/auth"
+)
+
+func TestAuthSet(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("GIPHY_API_KEY", "")
+	prev := readKeyFn
+	t.Cleanup(func() { readKeyFn = prev })
+	readKeyFn = func(io.Writer, string) (string, error) { return "giphy-secret", nil }
+
+	if code, _ := captureRun(t, "auth", "set", "giphy"); code != 0 {
+		t.Fatalf("auth set exited %d", code)
+	}
+	key, source, err := auth.Key("giphy")
+	if err != nil || key != "giphy-secret" || source != "credentials (file)" {
+		t.Fatalf("unexpected key lookup: %q %q %v", key, source, err)
+	}
+	info, err := os.Stat(auth.Path())
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected 0600 credentials file, got %v %v", info, err)
+	}
+	if code, _ := captureRun(t, "auth", "set", "giphy", "--backend", "command"); code != 1 {
+		t.Fatalf("expected failure without --command, got %d", code)
+	}
+}
+
+func TestReadKeyLine(t *testing.T) {
+	key, err := readKeyLine(strings.NewReader("  abc123 \nignored\n"))
+	if err != nil || key != "abc123" {
+		t.Fatalf("unexpected key %q %v", key, err)
+	}
+}
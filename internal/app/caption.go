@@ -0,0 +1,74 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/steipete/gifgrep/gifencode"
+	"github.com/steipete/gifgrep/internal/caption"
+	"github.com/steipete/gifgrep/internal/model"
+	"github.com/steipete/gifgrep/internal/stills"
+)
+
+// runCaption writes a captioned GIF, or a captioned PNG of the frame at
+// stillAt when one is given (or the output ends in .png).
+func runCaption(opts model.Options, capOpts caption.Options, stillAt *time.Duration) error {
+	if opts.GifInput == "" {
+		return errors.New("missing GIF input")
+	}
+	if capOpts.Size < 0 {
+		return errors.New("bad args: --size must be >= 0")
+	}
+
+	data, err := readInput(opts.GifInput)
+	if err != nil {
+		return err
+	}
+	outPath := resolveCaptionOutPath(opts, stillAt != nil)
+	if stillAt == nil && !strings.HasSuffix(strings.ToLower(outPath), ".png") {
+		output, err := caption.GIF(data, capOpts)
+		if err != nil {
+			return err
+		}
+		return writeOutput(outPath, output)
+	}
+
+	at := time.Duration(0)
+	if stillAt != nil {
+		at = *stillAt
+	}
+	decoded, err := decodeForEdit(data)
+	if err != nil {
+		return err
+	}
+	idx, err := stills.FrameIndexAt(decoded.Frames, at)
+	if err != nil {
+		return err
+	}
+	frames, err := gifencode.FromDecoded(decoded)
+	if err != nil {
+		return err
+	}
+	frames, err = caption.Render(frames[idx:idx+1], capOpts)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, frames[0].Image); err != nil {
+		return err
+	}
+	return writeOutput(outPath, buf.Bytes())
+}
+
+func resolveCaptionOutPath(opts model.Options, still bool) string {
+	if opts.OutPath != "" {
+		return opts.OutPath
+	}
+	if still {
+		return "caption.png"
+	}
+	return "caption.gif"
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/internal/caption"
+	"github.com/steipete/gifgrep/internal/model"
+	"github.com/steipete/gifgrep/internal/testutil"
+)
+
+func TestRunCaptionWritesGIFAndStill(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "in.gif")
+	if err := os.WriteFile(inPath, testutil.MakeTestGIF(), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+
+	gifPath := filepath.Join(dir, "out.gif")
+	if err := runCaption(model.Options{GifInput: inPath, OutPath: gifPath}, caption.Options{Top: "hi"}, nil); err != nil {
+		t.Fatalf("runCaption gif failed: %v", err)
+	}
+	data, err := os.ReadFile(gifPath)
+	if err != nil {
+		t.Fatalf("read gif: %v", err)
+	}
+	if decoded, err := gifdecode.Decode(data, gifdecode.DefaultOptions()); err != nil || len(decoded.Frames) != 2 {
+		t.Fatalf("expected 2-frame gif, err=%v", err)
+	}
+
+	pngPath := filepath.Join(dir, "out.png")
+	at := 50 * time.Millisecond
+	if err := runCaption(model.Options{GifInput: inPath, OutPath: pngPath}, caption.Options{Bottom: "hi"}, &at); err != nil {
+		t.Fatalf("runCaption still failed: %v", err)
+	}
+	data, err = os.ReadFile(pngPath)
+	if err != nil {
+		t.Fatalf("read png: %v", err)
+	}
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatalf("expected png output: %v", err)
+	}
+}
+
+func TestRunCaptionErrors(t *testing.T) {
+	if err := runCaption(model.Options{}, caption.Options{Top: "x"}, nil); err == nil {
+		t.Fatalf("expected missing input error")
+	}
+	inPath := filepath.Join(t.TempDir(), "in.gif")
+	if err := os.WriteFile(inPath, testutil.MakeTestGIF(), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	opts := model.Options{GifInput: inPath, OutPath: filepath.Join(t.TempDir(), "out.gif")}
+	if err := runCaption(opts, caption.Options{}, nil); !errors.Is(err, caption.ErrNoText) {
+		t.Fatalf("expected ErrNoText, got %v", err)
+	}
+	if got := resolveCaptionOutPath(model.Options{}, true); got != "caption.png" {
+		t.Fatalf("unexpected default still path %q", got)
+	}
+}
@@ -5,13 +5,20 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"image"
 	"io"
 	"os"
+	"runtime"
 	"strings"
 	"time"
 
 	"github.com/alecthomas/kong"
+	"github.com/steipete/gifgrep/gifencode"
+	"github.com/steipete/gifgrep/internal/caption"
+	"github.com/steipete/gifgrep/internal/clipboard"
 	"github.com/steipete/gifgrep/internal/download"
+	"github.com/steipete/gifgrep/internal/edit"
+	"github.com/steipete/gifgrep/internal/kitty"
 	"github.com/steipete/gifgrep/internal/model"
 	"github.com/steipete/gifgrep/internal/reveal"
 	"github.com/steipete/gifgrep/internal/search"
@@ -23,10 +30,15 @@ import (
 type CLI struct {
 	Globals Globals `embed:""`
 
-	Search SearchCmd `cmd:"" default:"withargs" help:"Search and print GIF URLs."`
-	TUI    TUICmd    `cmd:"" help:"Interactive browser with inline preview."`
-	Still  StillCmd  `cmd:"" help:"Extract a single frame as PNG."`
-	Sheet  SheetCmd  `cmd:"" help:"Generate a sheet PNG of sampled frames."`
+	Search  SearchCmd  `cmd:"" default:"withargs" help:"Search and print GIF URLs."`
+	TUI     TUICmd     `cmd:"" help:"Interactive browser with inline preview."`
+	Still   StillCmd   `cmd:"" help:"Extract a single frame as PNG."`
+	Sheet   SheetCmd   `cmd:"" help:"Generate a sheet PNG of sampled frames."`
+	Edit    EditCmd    `cmd:"" help:"Trim, crop, resize or retime a GIF."`
+	Caption CaptionCmd `cmd:"" help:"Draw top/bottom meme text onto a GIF."`
+	Doctor  DoctorCmd  `cmd:"" help:"Report terminal, tool and environment capabilities."`
+	Config  ConfigCmd  `cmd:"" help:"Read or change config.toml settings."`
+	Auth    AuthCmd    `cmd:"" help:"Store API keys outside the environment."`
 }
 
 type Globals struct {
@@ -35,6 +47,7 @@ type Globals struct {
 	Reveal  bool             `help:"Reveal output file in file manager."`
 	Verbose int              `help:"Verbose stderr logs (-vv for more)." short:"v" type:"counter"`
 	Quiet   bool             `help:"Suppress non-essential stderr output." short:"q"`
+	Profile string           `help:"Config profile ([profile.NAME] in config.toml)." placeholder:"NAME"`
 	Version kong.VersionFlag `help:"Show version."`
 }
 
@@ -52,22 +65,26 @@ func (g Globals) toOptions() model.Options {
 }
 
 type SearchCmd struct {
-	Source   string `help:"Source to search." enum:"auto,tenor,giphy" default:"auto"`
+	Source   string `help:"Source to search (favorites: the GIFs starred in the TUI)." enum:"auto,tenor,giphy,favorites" default:"auto"`
 	Max      int    `help:"Max results to fetch." name:"max" short:"m" default:"20"`
 	JSON     bool   `help:"Emit JSON array of results."`
 	Number   bool   `help:"Prefix lines with 1-based index." short:"n"`
 	Download bool   `help:"Download results to ~/Downloads."`
+	Copy     bool   `help:"Copy the first result to the clipboard (OSC 52 over SSH)."`
+	CopyAs   string `help:"What --copy puts on the clipboard." name:"copy-as" enum:"url,md,html,gif,data" default:"url"`
 	Format   string `help:"Output format." enum:"auto,plain,tsv,md,url,comment,json" default:"auto"`
 	Thumbs   string `help:"Inline thumbnails (Kitty protocol / iTerm2 images; TTY only)." enum:"auto,always,never" default:"auto"`
+	Rating   string `help:"Content rating (default: g on Giphy, pg-13 on Tenor)." enum:",g,pg,pg-13,r" default:""`
+	Dir      string `help:"Download directory." name:"download-dir" placeholder:"DIR" default:"~/Downloads"`
 
-	Query []string `arg:"" name:"query" help:"Search query."`
+	FormatPref []string `help:"Preferred media formats, best first (gif,webp,mp4,webm)." name:"format-pref" enum:"gif,webp,mp4,webm" default:"gif"`
+	Size       string   `help:"Preferred rendition size." enum:"small,medium,original" default:"original"`
+
+	Query []string `arg:"" optional:"" name:"query" help:"Search query (optional with --source favorites)."`
 }
 
 func (c *SearchCmd) Run(ctx *kong.Context, cli *CLI) error {
 	query := strings.TrimSpace(strings.Join(c.Query, " "))
-	if query == "" {
-		return errors.New("missing query")
-	}
 
 	opts := cli.Globals.toOptions()
 	opts.JSON = c.JSON
@@ -77,12 +94,29 @@ func (c *SearchCmd) Run(ctx *kong.Context, cli *CLI) error {
 	opts.Format = c.Format
 	opts.Thumbs = c.Thumbs
 	opts.Download = c.Download
+	opts.Copy = c.Copy
+	opts.CopyAs = c.CopyAs
+	opts.Rating = c.Rating
+	opts.DownloadDir = c.Dir
+	opts.FormatPref = c.FormatPref
+	opts.MediaSize = c.Size
 	return runSearch(ctx.Stdout, ctx.Stderr, opts, query)
 }
 
 type TUICmd struct {
-	Source string `help:"Source to search." enum:"auto,tenor,giphy" default:"auto"`
-	Max    int    `help:"Max results to fetch." name:"max" short:"m" default:"20"`
+	Source string            `help:"Source to search (favorites: the GIFs starred with *)." enum:"auto,tenor,giphy,favorites" default:"auto"`
+	Max    int               `help:"Max results to fetch." name:"max" short:"m" default:"20"`
+	Rating string            `help:"Content rating (default: g on Giphy, pg-13 on Tenor)." enum:",g,pg,pg-13,r" default:""`
+	Dir    string            `help:"Download directory." name:"download-dir" placeholder:"DIR" default:"~/Downloads"`
+	Theme  string            `help:"Color theme." enum:"default,light,mono" default:"default"`
+	Keymap string            `help:"Key preset." enum:"default,vim,emacs" default:"default"`
+	Mouse  bool              `help:"Click to select, double-click to download, wheel to scroll (--no-mouse keeps the terminal's text selection)." default:"true" negatable:""`
+	Keys   map[string]string `help:"Rebind keys (action=keys;…, keys space-separated)." placeholder:"ACTION=KEYS"`
+
+	KittyReplies bool `help:"Show failed Kitty uploads in the status line and retry them more cheaply (or GIFGREP_KITTY_REPLIES=1)." name:"kitty-replies"`
+
+	FormatPref []string `help:"Preferred download formats, best first (gif,webp,mp4,webm)." name:"format-pref" enum:"gif,webp,mp4,webm" default:"gif"`
+	Size       string   `help:"Preferred download size." enum:"small,medium,original" default:"original"`
 
 	Query []string `arg:"" optional:"" name:"query" help:"Initial query."`
 }
@@ -91,6 +125,16 @@ func (c *TUICmd) Run(_ *kong.Context, cli *CLI) error {
 	opts := cli.Globals.toOptions()
 	opts.Limit = c.Max
 	opts.Source = c.Source
+	opts.FormatPref = c.FormatPref
+	opts.MediaSize = c.Size
+	opts.Rating = c.Rating
+	opts.DownloadDir = c.Dir
+	opts.Theme = c.Theme
+	opts.Keymap = c.Keymap
+	opts.Mouse = c.Mouse
+	opts.Keys = c.Keys
+	opts.KittyReplies = c.KittyReplies
+	download.SetDir(opts.DownloadDir)
 
 	query := strings.TrimSpace(strings.Join(c.Query, " "))
 	return tui.Run(opts, query)
@@ -149,11 +193,120 @@ func (c *SheetCmd) Run(_ *kong.Context, cli *CLI) error {
 	return nil
 }
 
+type EditCmd struct {
+	GIF       string        `arg:"" name:"gif" help:"GIF path or URL."`
+	From      DurationValue `help:"Start time (e.g. 1.5s or 1.5)." name:"from"`
+	To        DurationValue `help:"End time (default: end of GIF)." name:"to"`
+	Crop      *CropValue    `help:"Crop rectangle as WxH+X+Y (source pixels)." name:"crop" placeholder:"WxH+X+Y"`
+	Width     int           `help:"Output width in px (height follows aspect unless set)." name:"width"`
+	Height    int           `help:"Output height in px (width follows aspect unless set)." name:"height"`
+	Speed     SpeedValue    `help:"Playback speed (e.g. 2x, 0.5x, 150%)." name:"speed" default:"1x"`
+	FPS       float64       `help:"Max frames per second (0 = keep)." name:"fps" placeholder:"N"`
+	Reverse   bool          `help:"Play backwards."`
+	Boomerang bool          `help:"Play forward, then backward."`
+	Colors    int           `help:"Max palette colors (2-256)." name:"colors" default:"256"`
+	Dither    bool          `help:"Dither colors (smoother gradients, larger files)."`
+	MaxBytes  ByteSizeValue `help:"Shrink until the file fits (e.g. 8MB, 500k)." name:"max-bytes" placeholder:"SIZE"`
+	Output    string        `help:"Output path or '-' for stdout." name:"output" short:"o" default:"edit.gif"`
+}
+
+func (c *EditCmd) Run(_ *kong.Context, cli *CLI) error {
+	opts := cli.Globals.toOptions()
+	opts.GifInput = c.GIF
+	opts.OutPath = c.Output
+
+	editOpts := edit.Options{
+		From:      time.Duration(c.From),
+		To:        time.Duration(c.To),
+		Width:     c.Width,
+		Height:    c.Height,
+		Speed:     float64(c.Speed),
+		FPS:       c.FPS,
+		Reverse:   c.Reverse,
+		Boomerang: c.Boomerang,
+	}
+	if c.Crop != nil {
+		editOpts.Crop = image.Rectangle(*c.Crop)
+	}
+	encOpts := gifencode.Options{MaxColors: c.Colors, Dither: c.Dither}
+	if err := runEdit(opts, editOpts, encOpts, int64(c.MaxBytes)); err != nil {
+		return err
+	}
+	if opts.Reveal {
+		outPath := resolveEditOutPath(opts)
+		if outPath != "-" {
+			return reveal.Reveal(outPath)
+		}
+	}
+	return nil
+}
+
+type CaptionCmd struct {
+	GIF      string         `arg:"" name:"gif" help:"GIF path or URL."`
+	Top      string         `help:"Text at the top." name:"top"`
+	Bottom   string         `help:"Text at the bottom." name:"bottom"`
+	Text     string         `help:"Text placed by --position." name:"text"`
+	Position string         `help:"Where --text goes." enum:"top,middle,bottom" default:"bottom"`
+	Size     int            `help:"Font size in px (0 = fit to the GIF)." name:"size" placeholder:"PX"`
+	At       *DurationValue `help:"Caption a single frame at this time and write PNG." name:"at"`
+	Output   string         `help:"Output path or '-' for stdout (.png writes a still)." name:"output" short:"o"`
+}
+
+func (c *CaptionCmd) Run(_ *kong.Context, cli *CLI) error {
+	opts := cli.Globals.toOptions()
+	opts.GifInput = c.GIF
+	opts.OutPath = c.Output
+
+	capOpts := caption.Options{Top: c.Top, Bottom: c.Bottom, Size: c.Size}
+	if c.Text != "" {
+		switch c.Position {
+		case "top":
+			capOpts.Top = joinCaption(capOpts.Top, c.Text)
+		case "middle":
+			capOpts.Middle = c.Text
+		default:
+			capOpts.Bottom = joinCaption(capOpts.Bottom, c.Text)
+		}
+	}
+	var stillAt *time.Duration
+	if c.At != nil {
+		at := time.Duration(*c.At)
+		stillAt = &at
+	}
+	if err := runCaption(opts, capOpts, stillAt); err != nil {
+		return err
+	}
+	if opts.Reveal {
+		outPath := resolveCaptionOutPath(opts, stillAt != nil)
+		if outPath != "-" {
+			return reveal.Reveal(outPath)
+		}
+	}
+	return nil
+}
+
+type DoctorCmd struct {
+	JSON   bool `help:"Emit the report as JSON." name:"json"`
+	Render bool `help:"Draw a test image with every inline protocol." name:"render"`
+}
+
+func (c *DoctorCmd) Run(ctx *kong.Context, _ *CLI) error {
+	return runDoctor(ctx.Stdout, c.JSON, c.Render)
+}
+
+func joinCaption(a, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + "\n" + b
+}
+
 func runSearch(stdout io.Writer, stderr io.Writer, opts model.Options, query string) error {
-	if strings.TrimSpace(query) == "" {
+	if strings.TrimSpace(query) == "" && !search.AllowsEmptyQuery(opts.Source) {
 		return errors.New("missing query")
 	}
 	logSearchConfig(stderr, opts)
+	download.SetDir(opts.DownloadDir)
 
 	results, err := search.Search(query, opts)
 	if err != nil {
@@ -163,10 +316,32 @@ func runSearch(stdout io.Writer, stderr io.Writer, opts model.Options, query str
 	if err := downloadSearchResults(results, opts, stderr); err != nil {
 		return err
 	}
+	if err := copySearchResult(results, opts, stderr); err != nil {
+		return err
+	}
 
 	format := resolveOutputFormat(opts, stdout)
-	out := bufio.NewWriter(stdout)
-	defer func() { _ = out.Flush() }()
+	thumbs := termcaps.InlineNone
+	if len(results) > 0 {
+		thumbs = thumbsProtocol(opts, stdout, format)
+	}
+	if thumbs != termcaps.InlineNone {
+		_, _ = termcaps.DetectCellSize()
+	}
+	var sender kitty.Sender
+	if thumbs == termcaps.InlineKitty {
+		sender.Transport = termcaps.DetectKittyTransport(os.Getenv)
+	}
+	out := bufio.NewWriter(termcaps.NewGraphicsWriter(stdout, thumbs))
+	defer func() {
+		_ = out.Flush()
+		if sender.Transport != termcaps.KittyDirect {
+			// Thumbs the terminal read are gone; wait until it has read
+			// them all before removing any it couldn't.
+			termcaps.AwaitTTY(time.Second)
+			sender.Close()
+		}
+	}()
 	if format == formatJSON {
 		enc := json.NewEncoder(out)
 		enc.SetIndent("", "  ")
@@ -174,10 +349,9 @@ func runSearch(stdout io.Writer, stderr io.Writer, opts model.Options, query str
 	}
 
 	useColor := shouldUseColor(opts, stdout)
-	thumbs := thumbsProtocol(opts, stdout, format)
 	termCols := termColumns(stdout, thumbs)
 
-	writeSearchResults(out, opts, useColor, thumbs, results, termCols, format)
+	writeSearchResults(out, opts, useColor, thumbs, &sender, results, termCols, format)
 	return nil
 }
 
@@ -187,6 +361,78 @@ func logSearchConfig(stderr io.Writer, opts model.Options) {
 	}
 }
 
+var copyPayloadFn = clipboard.Copy
+
+func copySearchResult(results []model.Result, opts model.Options, stderr io.Writer) error {
+	if !opts.Copy {
+		return nil
+	}
+	for _, res := range results {
+		if res.URL == "" {
+			continue
+		}
+		p, err := searchPayload(res, clipboard.Format(opts.CopyAs))
+		if err == nil {
+			err = copyPayloadFn(p, nil)
+			removeCopyTemp(p)
+		}
+		if err != nil {
+			return fmt.Errorf("copy: %w", err)
+		}
+		if opts.Verbose > 0 && !opts.Quiet {
+			_, _ = fmt.Fprintf(stderr, "copied %s\n", res.URL)
+		}
+		return nil
+	}
+	return nil
+}
+
+// removeCopyTemp removes the GIF searchPayload wrote once xclip or wl-copy
+// has read it. The macOS pasteboard refers to the file by path, so there
+// it stays.
+func removeCopyTemp(p clipboard.Payload) {
+	if runtime.GOOS == "darwin" {
+		return
+	}
+	for _, item := range p {
+		if item.Path != "" {
+			_ = os.Remove(item.Path)
+		}
+	}
+}
+
+// searchPayload fetches the GIF when the format needs its bytes. The image
+// format goes through a temp file, since macOS copies files by reference.
+func searchPayload(res model.Result, format clipboard.Format) (clipboard.Payload, error) {
+	if format == "" {
+		format = clipboard.FormatURL
+	}
+	src := clipboard.Source{URL: res.URL, Title: res.Title}
+	if format == clipboard.FormatGIF || format == clipboard.FormatDataURI {
+		data, err := fetchURL(res.URL)
+		if err != nil {
+			return nil, err
+		}
+		src.Data = data
+	}
+	if format == clipboard.FormatGIF {
+		f, err := os.CreateTemp("", "gifgrep-*"+download.MediaExt(res.URL))
+		if err != nil {
+			return nil, err
+		}
+		_, err = f.Write(src.Data)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			_ = os.Remove(f.Name())
+			return nil, err
+		}
+		src.Path = f.Name()
+	}
+	return clipboard.NewPayload(format, src)
+}
+
 func downloadSearchResults(results []model.Result, opts model.Options, stderr io.Writer) error {
 	if !opts.Download {
 		return nil
@@ -227,10 +473,10 @@ func termColumns(w io.Writer, thumbs termcaps.InlineProtocol) int {
 	return cols
 }
 
-func writeSearchResults(out *bufio.Writer, opts model.Options, useColor bool, thumbs termcaps.InlineProtocol, results []model.Result, termCols int, format outputFormat) {
+func writeSearchResults(out *bufio.Writer, opts model.Options, useColor bool, thumbs termcaps.InlineProtocol, sender *kitty.Sender, results []model.Result, termCols int, format outputFormat) {
 	switch format {
 	case formatPlain:
-		renderPlain(out, opts, useColor, thumbs, results, termCols)
+		renderPlain(out, opts, useColor, thumbs, sender, results, termCols)
 		return
 	case formatURL:
 		for i, res := range results {
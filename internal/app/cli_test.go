@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"io"
 	"os"
+	"runtime"
 	"strings"
 	"testing"
 
+	"github.com/steipete/gifgrep/internal/clipboard"
 	"github.com/steipete/gifgrep/internal/model"
 	"github.com/steipete/gifgrep/internal/testutil"
 )
@@ -43,6 +45,85 @@ func TestRunSearchJSON(t *testing.T) {
 	})
 }
 
+func TestRunSearchCopy(t *testing.T) {
+	prev := copyPayloadFn
+	t.Cleanup(func() { copyPayloadFn = prev })
+	var copied []clipboard.Payload
+	copyPayloadFn = func(p clipboard.Payload, _ io.Writer) error {
+		copied = append(copied, p)
+		return nil
+	}
+
+	testutil.WithTransport(t, &testutil.FakeTransport{GIFData: testutil.MakeTestGIF()}, func() {
+		var stdout, stderr bytes.Buffer
+		err := runSearch(&stdout, &stderr, model.Options{Copy: true, Limit: 2, Source: "tenor", Format: "url"}, "cats")
+		if err != nil {
+			t.Fatalf("runSearch failed: %v", err)
+		}
+		first := strings.TrimSpace(strings.Split(stdout.String(), "\n")[0])
+		if len(copied) != 1 {
+			t.Fatalf("expected one copy, got %d", len(copied))
+		}
+		if text, ok := copied[0].Text(); !ok || text != first {
+			t.Fatalf("expected first url copied, got %q (stdout %q)", text, stdout.String())
+		}
+	})
+}
+
+func TestRunSearchCopyAsGIF(t *testing.T) {
+	prev := copyPayloadFn
+	t.Cleanup(func() { copyPayloadFn = prev })
+	var copied clipboard.Payload
+	var data []byte
+	copyPayloadFn = func(p clipboard.Payload, _ io.Writer) error {
+		copied = p
+		if len(p) > 0 && p[0].Path != "" {
+			data, _ = os.ReadFile(p[0].Path)
+		}
+		return nil
+	}
+
+	gifData := testutil.MakeTestGIF()
+	testutil.WithTransport(t, &testutil.FakeTransport{GIFData: gifData}, func() {
+		var stdout, stderr bytes.Buffer
+		err := runSearch(&stdout, &stderr, model.Options{Copy: true, CopyAs: "gif", Limit: 1, Source: "tenor", Format: "url"}, "cats")
+		if err != nil {
+			t.Fatalf("runSearch failed: %v", err)
+		}
+	})
+	if len(copied) == 0 || copied[0].MIME != "image/gif" || copied[0].Path == "" {
+		t.Fatalf("expected image item first, got %#v", copied)
+	}
+	t.Cleanup(func() { _ = os.Remove(copied[0].Path) })
+	if !bytes.Equal(data, gifData) {
+		t.Fatal("expected GIF bytes in the temp file")
+	}
+	if _, err := os.Stat(copied[0].Path); runtime.GOOS != "darwin" && err == nil {
+		t.Fatal("expected the temp file removed once copied")
+	}
+}
+
+func TestRunFormatPrefURL(t *testing.T) {
+	testutil.WithTransport(t, &testutil.FakeTransport{}, func() {
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+		t.Cleanup(func() {
+			os.Stdout = oldStdout
+		})
+
+		code := Run([]string{"search", "--source", "tenor", "--format", "url", "--format-pref", "webm,mp4", "--size", "small", "cats"})
+		_ = w.Close()
+		if code != 0 {
+			t.Fatalf("expected exit 0, got %d", code)
+		}
+		out, _ := io.ReadAll(r)
+		if strings.TrimSpace(string(out)) != "https://example.test/preview.mp4" {
+			t.Fatalf("unexpected url output: %q", out)
+		}
+	})
+}
+
 func TestHelpOutput(t *testing.T) {
 	oldStdout := os.Stdout
 	r, w, _ := os.Pipe()
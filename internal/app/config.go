@@ -0,0 +1,163 @@
+package app
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/steipete/gifgrep/internal/config"
+)
+
+type ConfigCmd struct {
+	Get  ConfigGetCmd  `cmd:"" help:"Print a setting (dotted key, e.g. profile.work.source)."`
+	Set  ConfigSetCmd  `cmd:"" help:"Write a setting to config.toml."`
+	Path ConfigPathCmd `cmd:"" help:"Print the config file path."`
+}
+
+type ConfigGetCmd struct {
+	Key string `arg:"" name:"key" help:"Setting, table or profile.NAME.setting."`
+}
+
+func (c *ConfigGetCmd) Run(ctx *kong.Context) error {
+	f, err := config.Load(configPath())
+	if err != nil {
+		return err
+	}
+	v, ok := f.Get(c.Key)
+	if !ok {
+		return fmt.Errorf("%s: %w", c.Key, config.ErrNotSet)
+	}
+	_, _ = fmt.Fprintln(ctx.Stdout, config.Format(v))
+	return nil
+}
+
+type ConfigSetCmd struct {
+	Key   string `arg:"" name:"key" help:"Setting, keys.ACTION or profile.NAME.setting."`
+	Value string `arg:"" name:"value" help:"Value; lists are comma-separated."`
+}
+
+func (c *ConfigSetCmd) Run(ctx *kong.Context) error {
+	v, err := configValue(ctx.Model.Node, c.Key, c.Value)
+	if err != nil {
+		return err
+	}
+	return config.Set(configPath(), c.Key, v)
+}
+
+type ConfigPathCmd struct{}
+
+func (c *ConfigPathCmd) Run(ctx *kong.Context) error {
+	_, _ = fmt.Fprintln(ctx.Stdout, configPath())
+	return nil
+}
+
+var configPath = config.Path
+
+// configValue checks key against the flags it would set and types raw the
+// way that flag expects. Plain keys are global, search and tui flags;
+// COMMAND.key sets a flag of that command only.
+func configValue(app *kong.Node, key, raw string) (any, error) {
+	parts := strings.Split(key, ".")
+	if len(parts) >= 3 && parts[0] == "profile" {
+		parts = parts[2:]
+	}
+	lookup := func(name string) *kong.Flag { return sharedFlag(app, name) }
+	if cmd := findCommand(app, parts[0]); cmd != nil && len(parts) > 1 {
+		parts = parts[1:]
+		lookup = func(name string) *kong.Flag { return findFlag(cmd, name) }
+	} else {
+		switch {
+		case parts[0] == "default-profile" && key == parts[0]:
+			return raw, nil
+		case len(parts) == 1 && config.EnvKeys[parts[0]] != "":
+			return config.Guess(raw, false), nil
+		}
+	}
+	switch {
+	case len(parts) == 2 && parts[0] == "keys" && lookup("keys") != nil:
+		return raw, nil
+	case len(parts) != 1:
+		return nil, fmt.Errorf("unknown setting %q", key)
+	}
+	fl := lookup(parts[0])
+	if fl == nil || fl.Name == "profile" || fl.Name == "help" || fl.Name == "version" {
+		return nil, fmt.Errorf("unknown setting %q", key)
+	}
+	if len(fl.Enum) > 0 {
+		values := []string{raw}
+		if fl.IsSlice() {
+			values = strings.Split(raw, ",")
+		}
+		for _, v := range values {
+			if _, ok := fl.EnumMap()[strings.TrimSpace(v)]; !ok {
+				return nil, fmt.Errorf("%s must be one of %s", parts[0], strings.Join(fl.EnumSlice(), ", "))
+			}
+		}
+	}
+	switch fl.Target.Kind() {
+	case reflect.String:
+		return raw, nil
+	case reflect.Int, reflect.Int64:
+		i, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number", parts[0])
+		}
+		return i, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false", parts[0])
+		}
+		return b, nil
+	case reflect.Slice:
+		return config.Guess(raw, true), nil
+	case reflect.Map:
+		return nil, fmt.Errorf("set %s.NAME instead", parts[0])
+	default:
+		return config.Guess(raw, false), nil
+	}
+}
+
+// sharedFlag finds a flag that top-level settings feed: a global flag or
+// one of config.SharedCommands.
+func sharedFlag(app *kong.Node, name string) *kong.Flag {
+	for _, fl := range app.Flags {
+		if fl.Name == name {
+			return fl
+		}
+	}
+	for _, cmd := range app.Children {
+		if !config.SharedCommands[cmd.Name] {
+			continue
+		}
+		if fl := findFlag(cmd, name); fl != nil {
+			return fl
+		}
+	}
+	return nil
+}
+
+func findFlag(n *kong.Node, name string) *kong.Flag {
+	for _, fl := range n.Flags {
+		if fl.Name == name {
+			return fl
+		}
+	}
+	for _, child := range n.Children {
+		if fl := findFlag(child, name); fl != nil {
+			return fl
+		}
+	}
+	return nil
+}
+
+func findCommand(app *kong.Node, name string) *kong.Node {
+	for _, cmd := range app.Children {
+		if cmd.Name == name {
+			return cmd
+		}
+	}
+	return nil
+}
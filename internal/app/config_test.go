@@ -0,0 +1,161 @@
+package app
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steipete/gifgrep/internal/testutil"
+)
+
+// captureRun runs the CLI with stdout captured.
+func captureRun(t *testing.T, args ...string) (int, string) {
+	t.Helper()
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	code := Run(args)
+	_ = w.Close()
+	os.Stdout = oldStdout
+	out, _ := io.ReadAll(r)
+	return code, string(out)
+}
+
+func TestConfigCommands(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	path := filepath.Join(dir, "gifgrep", "config.toml")
+
+	if code, out := captureRun(t, "config", "path"); code != 0 || strings.TrimSpace(out) != path {
+		t.Fatalf("unexpected path output %d %q", code, out)
+	}
+	for _, args := range [][]string{
+		{"config", "set", "max", "3"},
+		{"config", "set", "format-pref", "mp4,gif"},
+		{"config", "set", "profile.work.source", "giphy"},
+		{"config", "set", "keys.download", "D"},
+		{"config", "set", "inline", "sixel"},
+	} {
+		if code, _ := captureRun(t, args...); code != 0 {
+			t.Fatalf("%v exited %d", args, code)
+		}
+	}
+	data, _ := os.ReadFile(path)
+	want := "max = 3\nformat-pref = [\"mp4\", \"gif\"]\ninline = \"sixel\"\n\n[profile.work]\nsource = \"giphy\"\n\n[keys]\ndownload = \"D\"\n"
+	if string(data) != want {
+		t.Fatalf("unexpected config:\n%s", data)
+	}
+
+	if code, out := captureRun(t, "config", "get", "profile.work.source"); code != 0 || strings.TrimSpace(out) != "giphy" {
+		t.Fatalf("unexpected get output %d %q", code, out)
+	}
+	if code, _ := captureRun(t, "config", "get", "rating"); code != 1 {
+		t.Fatalf("expected unset key to fail, got %d", code)
+	}
+	for _, args := range [][]string{
+		{"config", "set", "colour", "never"},
+		{"config", "set", "max", "lots"},
+		{"config", "set", "source", "bing"},
+		{"config", "set", "format-pref", "mp4,mov"},
+	} {
+		if code, _ := captureRun(t, args...); code != 1 {
+			t.Fatalf("%v: expected failure, got %d", args, code)
+		}
+	}
+}
+
+func TestConfigScopesCommandSettings(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("GIFGREP_PROFILE", "")
+	path := filepath.Join(dir, "gifgrep", "config.toml")
+	for _, args := range [][]string{
+		{"config", "set", "size", "small"},
+		{"config", "set", "caption.size", "12"},
+		{"config", "set", "profile.work.caption.output", "work.gif"},
+	} {
+		if code, _ := captureRun(t, args...); code != 0 {
+			t.Fatalf("%v exited %d", args, code)
+		}
+	}
+	if code, _ := captureRun(t, "config", "set", "caption.size", "small"); code != 1 {
+		t.Fatalf("expected caption.size to want a number, got %d", code)
+	}
+
+	in := filepath.Join(dir, "in.gif")
+	if err := os.WriteFile(in, testutil.MakeTestGIF(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out.gif")
+	if code, _ := captureRun(t, "caption", in, "--top", "hi", "-o", out); code != 0 {
+		t.Fatalf("expected caption to ignore the search size, got %d", code)
+	}
+
+	data, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, append([]byte("bogus = 1\n"), data...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := captureRun(t, "caption", in, "--top", "hi", "-o", out); code != 2 {
+		t.Fatalf("expected the unknown setting to fail, got %d", code)
+	}
+	if code, out := captureRun(t, "config", "get", "bogus"); code != 0 || strings.TrimSpace(out) != "1" {
+		t.Fatalf("expected config commands to still work, got %d %q", code, out)
+	}
+}
+
+func TestConfigCommandsFixBrokenFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	path := filepath.Join(dir, "gifgrep", "config.toml")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("max = = 3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if code, _ := captureRun(t, "search", "cats"); code != 2 {
+		t.Fatalf("expected search to report the broken file, got %d", code)
+	}
+	if code, out := captureRun(t, "config", "path"); code != 0 || strings.TrimSpace(out) != path {
+		t.Fatalf("expected config path to work, got %d %q", code, out)
+	}
+	if code, _ := captureRun(t, "config", "set", "source", "giphy"); code != 1 {
+		t.Fatalf("expected a set that leaves the file broken to fail, got %d", code)
+	}
+	if code, _ := captureRun(t, "config", "set", "max", "5"); code != 0 {
+		t.Fatalf("expected config set to fix the file, got %d", code)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "max = 5\n" {
+		t.Fatalf("unexpected config %q", data)
+	}
+}
+
+func TestConfigProfileAppliesToSearch(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("GIFGREP_PROFILE", "")
+	if err := os.MkdirAll(filepath.Join(dir, "gifgrep"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := "format = \"tsv\"\n\n[profile.links]\nformat = \"url\"\nsize = \"small\"\nformat-pref = [\"webm\", \"mp4\"]\n"
+	if err := os.WriteFile(filepath.Join(dir, "gifgrep", "config.toml"), []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.WithTransport(t, &testutil.FakeTransport{}, func() {
+		code, out := captureRun(t, "--profile", "links", "search", "--source", "tenor", "cats")
+		if code != 0 || strings.TrimSpace(out) != "https://example.test/preview.mp4" {
+			t.Fatalf("expected profile settings, got %d %q", code, out)
+		}
+		code, out = captureRun(t, "search", "--source", "tenor", "cats")
+		if code != 0 || !strings.Contains(out, "\t") {
+			t.Fatalf("expected top-level tsv format, got %d %q", code, out)
+		}
+		if code, _ := captureRun(t, "--profile", "nope", "search", "cats"); code == 0 {
+			t.Fatalf("expected unknown profile to fail")
+		}
+	})
+}
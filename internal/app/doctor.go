@@ -0,0 +1,307 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/internal/auth"
+	"github.com/steipete/gifgrep/internal/blocks"
+	"github.com/steipete/gifgrep/internal/clipboard"
+	"github.com/steipete/gifgrep/internal/config"
+	"github.com/steipete/gifgrep/internal/download"
+	"github.com/steipete/gifgrep/internal/iterm"
+	"github.com/steipete/gifgrep/internal/kitty"
+	"github.com/steipete/gifgrep/internal/model"
+	"github.com/steipete/gifgrep/internal/reveal"
+	"github.com/steipete/gifgrep/internal/sixel"
+	"github.com/steipete/gifgrep/internal/termcaps"
+)
+
+type doctorReport struct {
+	Version   string            `json:"version"`
+	OS        string            `json:"os"`
+	Env       map[string]string `json:"env"`
+	Graphics  doctorGraphics    `json:"graphics"`
+	Probe     termcaps.RawProbe `json:"probe"`
+	Tools     map[string]string `json:"tools"`
+	APIKeys   map[string]string `json:"api_keys"`
+	Config    string            `json:"config"`
+	TempDir   string            `json:"temp_dir"`
+	Downloads string            `json:"downloads_dir"`
+}
+
+type doctorGraphics struct {
+	Inline               string `json:"inline"`
+	TextStyle            string `json:"text_style"`
+	Truecolor            bool   `json:"truecolor"`
+	Passthrough          string `json:"passthrough"`
+	TmuxAllowPassthrough string `json:"tmux_allow_passthrough,omitempty"`
+	KittyTransport       string `json:"kitty_transport,omitempty"`
+	KittyPlaceholders    bool   `json:"kitty_placeholders"`
+	CellSize             string `json:"cell_size"`
+}
+
+// doctorEnv lists the variables that steer detection. API keys are only
+// reported as set or not.
+var doctorEnv = []string{
+	"TERM", "TERM_PROGRAM", "TERM_PROGRAM_VERSION", "COLORTERM", "LC_TERMINAL",
+	"KITTY_WINDOW_ID", "ITERM_SESSION_ID", "WT_SESSION", "TMUX", "STY",
+	"SSH_CONNECTION", "NO_COLOR",
+	"GIFGREP_INLINE", "GIFGREP_TEXT_STYLE", "GIFGREP_SOFTWARE_ANIM", "GIFGREP_CELL_ASPECT",
+	"GIFGREP_KITTY_TRANSPORT", "GIFGREP_KITTY_PLACEHOLDERS", "GIFGREP_KITTY_REPLIES",
+	"GIFGREP_CLIPBOARD", "GIFGREP_PROFILE", "XDG_CONFIG_HOME",
+}
+
+var (
+	doctorProbe    = termcaps.ProbeRaw
+	doctorInline   = func() termcaps.InlineProtocol { return termcaps.DetectInlineRobust(os.Getenv) }
+	doctorCellSize = termcaps.DetectCellSize
+)
+
+func runDoctor(stdout io.Writer, asJSON, render bool) error {
+	r := buildDoctorReport(os.Getenv)
+	if asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	} else {
+		writeDoctorText(stdout, r)
+	}
+	if render {
+		return renderDoctorImages(stdout)
+	}
+	return nil
+}
+
+func buildDoctorReport(getenv func(string) string) doctorReport {
+	r := doctorReport{
+		Version: model.Version,
+		OS:      runtime.GOOS + "/" + runtime.GOARCH,
+		Env:     map[string]string{},
+		Tools:   map[string]string{},
+		APIKeys: map[string]string{},
+	}
+	for _, key := range doctorEnv {
+		if v := getenv(key); v != "" {
+			r.Env[key] = v
+		}
+	}
+
+	inline := doctorInline()
+	style := termcaps.DetectTextStyle(getenv)
+	passthrough := termcaps.DetectPassthrough(getenv)
+	r.Graphics = doctorGraphics{
+		Inline:            inline.String(),
+		TextStyle:         style.String(),
+		Truecolor:         style == termcaps.TextTruecolor,
+		Passthrough:       passthrough.String(),
+		KittyPlaceholders: termcaps.KittyPlaceholders(getenv),
+		CellSize:          "unknown",
+	}
+	if passthrough == termcaps.PassthroughTmux {
+		r.Graphics.TmuxAllowPassthrough = termcaps.TmuxAllowPassthrough()
+		if r.Graphics.TmuxAllowPassthrough == "" {
+			r.Graphics.TmuxAllowPassthrough = "unset (tmux < 3.3 always passes through)"
+		}
+	}
+	if inline == termcaps.InlineKitty {
+		r.Graphics.KittyTransport = termcaps.DetectKittyTransport(getenv).String()
+	}
+	if c, ok := doctorCellSize(); ok {
+		r.Graphics.CellSize = fmt.Sprintf("%dx%d", c.Width, c.Height)
+	}
+	r.Probe = doctorProbe()
+
+	r.Tools["clipboard"] = toolStatus(clipboard.Tool())
+	r.Tools["clipboard_text"] = clipboard.DetectBackend(getenv).String()
+	r.Tools["reveal"] = toolStatus(reveal.Tool())
+
+	r.APIKeys["TENOR_API_KEY"] = "built-in default"
+	r.APIKeys["GIPHY_API_KEY"] = "missing (needed for --source giphy)"
+	for _, provider := range auth.Providers {
+		name := auth.EnvVar(provider)
+		if getenv(name) != "" {
+			r.APIKeys[name] = "set"
+		}
+		if e, ok, err := auth.Stored(provider); err != nil {
+			r.APIKeys[name] = "invalid credentials: " + err.Error()
+		} else if ok {
+			r.APIKeys[name] = "stored (" + string(e.Backend) + ")"
+		}
+	}
+
+	r.Config = configPath() + " (missing)"
+	if _, err := os.Stat(configPath()); err == nil {
+		r.Config = configPath()
+		if _, err := config.Load(configPath()); err != nil {
+			r.Config += " (invalid: " + err.Error() + ")"
+		}
+	}
+	r.TempDir = dirStatus(os.TempDir())
+	if dir, err := download.DefaultDir(); err != nil {
+		r.Downloads = "unknown: " + err.Error()
+	} else {
+		r.Downloads = dirStatus(dir)
+	}
+	return r
+}
+
+func toolStatus(name string, err error) string {
+	if err != nil {
+		return "none: " + err.Error()
+	}
+	return name
+}
+
+// dirStatus reports whether dir takes new files.
+func dirStatus(dir string) string {
+	if _, err := os.Stat(dir); err != nil {
+		return dir + " (missing)"
+	}
+	f, err := os.CreateTemp(dir, ".gifgrep-doctor-*")
+	if err != nil {
+		return dir + " (not writable: " + err.Error() + ")"
+	}
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+	return dir + " (writable)"
+}
+
+func writeDoctorText(w io.Writer, r doctorReport) {
+	section := func(title string, rows [][2]string) {
+		_, _ = fmt.Fprintln(w, title)
+		for _, row := range rows {
+			_, _ = fmt.Fprintf(w, "  %-24s %s\n", row[0], row[1])
+		}
+		_, _ = fmt.Fprintln(w)
+	}
+	_, _ = fmt.Fprintf(w, "%s %s (%s)\n\n", model.AppName, r.Version, r.OS)
+
+	var env [][2]string
+	for _, key := range doctorEnv {
+		if v, ok := r.Env[key]; ok {
+			env = append(env, [2]string{key, v})
+		}
+	}
+	section("Environment", env)
+
+	g := r.Graphics
+	graphics := [][2]string{
+		{"inline", g.Inline},
+		{"text style", g.TextStyle},
+		{"truecolor", fmt.Sprint(g.Truecolor)},
+		{"passthrough", g.Passthrough},
+	}
+	if g.TmuxAllowPassthrough != "" {
+		graphics = append(graphics, [2]string{"tmux allow-passthrough", g.TmuxAllowPassthrough})
+	}
+	if g.KittyTransport != "" {
+		graphics = append(graphics, [2]string{"kitty transport", g.KittyTransport})
+	}
+	graphics = append(graphics,
+		[2]string{"kitty placeholders", fmt.Sprint(g.KittyPlaceholders)},
+		[2]string{"cell size", g.CellSize},
+	)
+	section("Graphics", graphics)
+
+	quote := func(s string) string {
+		if s == "" {
+			return "(no reply)"
+		}
+		return fmt.Sprintf("%q", s)
+	}
+	section("Probe replies", [][2]string{
+		{"kitty graphics", quote(r.Probe.Kitty)},
+		{"DA1", quote(r.Probe.DA1)},
+		{"DA2", quote(r.Probe.DA2)},
+		{"XTVERSION", quote(r.Probe.XTVersion)},
+		{"CSI 16 t", quote(r.Probe.CellSize)},
+		{"CSI 14 t", quote(r.Probe.WinSize)},
+	})
+	section("Tools", [][2]string{
+		{"clipboard", r.Tools["clipboard"]},
+		{"clipboard text", r.Tools["clipboard_text"]},
+		{"reveal", r.Tools["reveal"]},
+	})
+	section("API keys", [][2]string{
+		{"TENOR_API_KEY", r.APIKeys["TENOR_API_KEY"]},
+		{"GIPHY_API_KEY", r.APIKeys["GIPHY_API_KEY"]},
+	})
+	section("Directories", [][2]string{
+		{"config", r.Config},
+		{"temp", r.TempDir},
+		{"downloads", r.Downloads},
+	})
+}
+
+// doctorTestImage is a hue sweep over a brightness ramp, so color depth and
+// orientation problems show.
+func doctorTestImage() *image.NRGBA {
+	const w, h = 96, 48
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := 255 - y*255/(h-1)
+			img.Set(x, y, color.NRGBA{
+				R: uint8(v * x / (w - 1)),
+				G: uint8(v * (w - 1 - x) / (w - 1)),
+				B: uint8(v * y / (h - 1)),
+				A: 255,
+			})
+		}
+	}
+	return img
+}
+
+// renderDoctorImages draws the test image with every protocol, labelled, so
+// a bug report shows which ones the terminal really supports.
+func renderDoctorImages(stdout io.Writer) error {
+	const cols, rows = 12, 3
+	img := doctorTestImage()
+	var pngBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, img); err != nil {
+		return err
+	}
+	frame := gifdecode.Frame{PNG: pngBuf.Bytes()}
+
+	out := bufio.NewWriter(termcaps.NewPassthroughWriter(stdout, termcaps.DetectPassthrough(os.Getenv)))
+	defer func() { _ = out.Flush() }()
+
+	var sender kitty.Sender
+	protocols := []termcaps.InlineProtocol{termcaps.InlineKitty, termcaps.InlineIterm, termcaps.InlineSixel, termcaps.InlineBlocks}
+	for i, p := range protocols {
+		_, _ = fmt.Fprintf(out, "%s:\n", p)
+		switch p {
+		case termcaps.InlineKitty:
+			writeInGrid(out, rows, func() { sender.SendFrame(out, uint32(0x646f6300+i), frame, cols, rows) })
+		case termcaps.InlineIterm:
+			writeInGrid(out, rows, func() {
+				iterm.SendInlineFile(out, iterm.File{Name: "doctor.png", Data: frame.PNG, WidthCells: cols, HeightCells: rows})
+			})
+		case termcaps.InlineSixel:
+			w, h := termcaps.CellSizeOr(termcaps.SixelCellSize).Box(cols, rows)
+			data, err := sixel.EncodeFrame(frame, w, h)
+			if err != nil {
+				return err
+			}
+			writeInGrid(out, rows, func() { _, _ = out.Write(data) })
+		case termcaps.InlineBlocks:
+			data := blocks.Encode(img, cols, rows, termcaps.DetectTextStyle(os.Getenv))
+			writeInGrid(out, rows, func() { _, _ = out.Write(data) })
+		}
+		_, _ = fmt.Fprint(out, strings.Repeat("\n", rows))
+	}
+	return nil
+}
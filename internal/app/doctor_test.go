@@ -0,0 +1,95 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/steipete/gifgrep/internal/auth"
+	"github.com/steipete/gifgrep/internal/termcaps"
+)
+
+func stubDoctor(t *testing.T) {
+	t.Helper()
+	prevProbe, prevInline, prevCell := doctorProbe, doctorInline, doctorCellSize
+	doctorProbe = func() termcaps.RawProbe { return termcaps.RawProbe{DA1: "\x1b[?62;c"} }
+	doctorInline = func() termcaps.InlineProtocol { return termcaps.InlineSixel }
+	doctorCellSize = func() (termcaps.CellSize, bool) { return termcaps.CellSize{Width: 9, Height: 18}, true }
+	t.Cleanup(func() { doctorProbe, doctorInline, doctorCellSize = prevProbe, prevInline, prevCell })
+}
+
+func TestBuildDoctorReport(t *testing.T) {
+	stubDoctor(t)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	env := map[string]string{"TERM": "xterm-256color", "GIPHY_API_KEY": "secret", "COLORTERM": "truecolor"}
+	r := buildDoctorReport(func(k string) string { return env[k] })
+
+	if r.Graphics.Inline != "sixel" || r.Graphics.CellSize != "9x18" || !r.Graphics.Truecolor {
+		t.Fatalf("unexpected graphics: %#v", r.Graphics)
+	}
+	if r.Env["TERM"] != "xterm-256color" {
+		t.Fatalf("expected TERM in env, got %#v", r.Env)
+	}
+	if _, ok := r.Env["GIPHY_API_KEY"]; ok {
+		t.Fatalf("api key leaked into env")
+	}
+	if r.APIKeys["GIPHY_API_KEY"] != "set" || r.APIKeys["TENOR_API_KEY"] != "built-in default" {
+		t.Fatalf("unexpected api keys: %#v", r.APIKeys)
+	}
+	if err := auth.Set("tenor", auth.Entry{}, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if got := buildDoctorReport(func(string) string { return "" }).APIKeys["TENOR_API_KEY"]; got != "stored (file)" {
+		t.Fatalf("expected stored tenor key, got %q", got)
+	}
+	if !strings.HasSuffix(r.TempDir, "(writable)") {
+		t.Fatalf("expected writable temp dir, got %q", r.TempDir)
+	}
+}
+
+func TestDoctorOutput(t *testing.T) {
+	stubDoctor(t)
+	t.Setenv("GIPHY_API_KEY", "secret")
+
+	var text bytes.Buffer
+	if err := runDoctor(&text, false, false); err != nil {
+		t.Fatalf("doctor: %v", err)
+	}
+	out := text.String()
+	for _, want := range []string{"Graphics", "inline", "sixel", `"\x1b[?62;c"`, "(no reply)", "GIPHY_API_KEY"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "secret") {
+		t.Fatalf("api key value leaked:\n%s", out)
+	}
+
+	var js bytes.Buffer
+	if err := runDoctor(&js, true, false); err != nil {
+		t.Fatalf("doctor json: %v", err)
+	}
+	var r doctorReport
+	if err := json.Unmarshal(js.Bytes(), &r); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if r.Probe.DA1 != "\x1b[?62;c" || r.Graphics.Inline != "sixel" {
+		t.Fatalf("unexpected report: %#v", r)
+	}
+}
+
+func TestRenderDoctorImages(t *testing.T) {
+	t.Setenv("TMUX", "")
+	t.Setenv("STY", "")
+	var buf bytes.Buffer
+	if err := renderDoctorImages(&buf); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"kitty:\n", "iterm:\n", "sixel:\n", "blocks:\n", "\x1b_G", "\x1b]1337;File=", "\x1bP"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in render output", want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"errors"
+
+	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/gifencode"
+	"github.com/steipete/gifgrep/internal/edit"
+	"github.com/steipete/gifgrep/internal/model"
+)
+
+func runEdit(opts model.Options, editOpts edit.Options, encOpts gifencode.Options, maxBytes int64) error {
+	if opts.GifInput == "" {
+		return errors.New("missing GIF input")
+	}
+	if editOpts.Width < 0 || editOpts.Height < 0 {
+		return errors.New("bad args: --width/--height must be >= 0")
+	}
+	if editOpts.FPS < 0 {
+		return errors.New("bad args: --fps must be >= 0")
+	}
+	if maxBytes < 0 {
+		return errors.New("bad args: --max-bytes must be >= 0")
+	}
+
+	data, err := readInput(opts.GifInput)
+	if err != nil {
+		return err
+	}
+	decoded, err := decodeForEdit(data)
+	if err != nil {
+		return err
+	}
+	frames, err := gifencode.FromDecoded(decoded)
+	if err != nil {
+		return err
+	}
+	frames, err = edit.Apply(frames, editOpts)
+	if err != nil {
+		return err
+	}
+
+	encOpts.LoopCount = decoded.LoopCount
+	output, err := edit.EncodeUnder(frames, encOpts, maxBytes)
+	if err != nil {
+		return err
+	}
+	return writeOutput(resolveEditOutPath(opts), output)
+}
+
+func decodeForEdit(data []byte) (*gifdecode.Frames, error) {
+	return gifdecode.Decode(data, gifencode.DecodeOptions())
+}
+
+func resolveEditOutPath(opts model.Options) string {
+	if opts.OutPath == "" {
+		return "edit.gif"
+	}
+	return opts.OutPath
+}
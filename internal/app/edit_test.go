@@ -0,0 +1,101 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/gifencode"
+	"github.com/steipete/gifgrep/internal/edit"
+	"github.com/steipete/gifgrep/internal/model"
+	"github.com/steipete/gifgrep/internal/testutil"
+)
+
+func TestRunEditWritesGIF(t *testing.T) {
+	inPath := filepath.Join(t.TempDir(), "in.gif")
+	if err := os.WriteFile(inPath, testutil.MakeTestGIF(), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	outPath := filepath.Join(t.TempDir(), "out.gif")
+
+	opts := model.Options{GifInput: inPath, OutPath: outPath}
+	err := runEdit(opts, edit.Options{Width: 4, Reverse: true}, gifencode.DefaultOptions(), 0)
+	if err != nil {
+		t.Fatalf("runEdit failed: %v", err)
+	}
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	decoded, err := gifdecode.Decode(data, gifdecode.DefaultOptions())
+	if err != nil {
+		t.Fatalf("decode output: %v", err)
+	}
+	if decoded.Width != 4 || decoded.Height != 4 || len(decoded.Frames) != 2 {
+		t.Fatalf("unexpected output: %dx%d, %d frames", decoded.Width, decoded.Height, len(decoded.Frames))
+	}
+}
+
+func TestRunEditKeepsEveryFrame(t *testing.T) {
+	inPath := filepath.Join(t.TempDir(), "in.gif")
+	if err := os.WriteFile(inPath, testutil.MakeLongGIF(75), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	outPath := filepath.Join(t.TempDir(), "out.gif")
+	opts := model.Options{GifInput: inPath, OutPath: outPath}
+	if err := runEdit(opts, edit.Options{Reverse: true}, gifencode.DefaultOptions(), 0); err != nil {
+		t.Fatalf("runEdit failed: %v", err)
+	}
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode output: %v", err)
+	}
+	if len(g.Image) != 75 {
+		t.Fatalf("expected 75 frames, got %d", len(g.Image))
+	}
+}
+
+func TestRunEditErrors(t *testing.T) {
+	if err := runEdit(model.Options{}, edit.Options{}, gifencode.DefaultOptions(), 0); err == nil {
+		t.Fatalf("expected missing input error")
+	}
+	inPath := filepath.Join(t.TempDir(), "in.gif")
+	if err := os.WriteFile(inPath, testutil.MakeTestGIF(), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	opts := model.Options{GifInput: inPath, OutPath: filepath.Join(t.TempDir(), "out.gif")}
+	err := runEdit(opts, edit.Options{Crop: image.Rect(0, 0, 10, 10)}, gifencode.DefaultOptions(), 0)
+	if !errors.Is(err, edit.ErrCropBounds) {
+		t.Fatalf("expected crop error, got %v", err)
+	}
+	if err := runEdit(opts, edit.Options{Width: -1}, gifencode.DefaultOptions(), 0); err == nil {
+		t.Fatalf("expected bad width error")
+	}
+}
+
+func TestEditValues(t *testing.T) {
+	var crop CropValue
+	if err := crop.UnmarshalText([]byte("10x20+1+2")); err != nil || image.Rectangle(crop) != image.Rect(1, 2, 11, 22) {
+		t.Fatalf("unexpected crop: %v %v", image.Rectangle(crop), err)
+	}
+	var speed SpeedValue
+	if err := speed.UnmarshalText([]byte("2x")); err != nil || speed != 2 {
+		t.Fatalf("unexpected speed: %v %v", speed, err)
+	}
+	var size ByteSizeValue
+	if err := size.UnmarshalText([]byte("1k")); err != nil || size != 1024 {
+		t.Fatalf("unexpected size: %v %v", size, err)
+	}
+	if err := size.UnmarshalText([]byte("nope")); err == nil {
+		t.Fatalf("expected size error")
+	}
+}
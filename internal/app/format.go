@@ -5,13 +5,16 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"os"
 	"strings"
 
 	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/internal/blocks"
 	"github.com/steipete/gifgrep/internal/iterm"
 	"github.com/steipete/gifgrep/internal/kitty"
 	"github.com/steipete/gifgrep/internal/model"
+	"github.com/steipete/gifgrep/internal/sixel"
 	"github.com/steipete/gifgrep/internal/termcaps"
 	"golang.org/x/term"
 )
@@ -30,6 +33,12 @@ const (
 
 type thumbsMode string
 
+const (
+	thumbCols    = 16
+	thumbMinRows = 3
+	thumbMaxRows = 10
+)
+
 const (
 	thumbsAuto   thumbsMode = "auto"
 	thumbsAlways thumbsMode = "always"
@@ -49,10 +58,31 @@ var (
 	decodeThumb = func(data []byte) (*gifdecode.Frames, error) {
 		decodeOpts := gifdecode.DefaultOptions()
 		decodeOpts.MaxFrames = 1
+		decodeOpts.MaxWidth, decodeOpts.MaxHeight = termcaps.CellSizeOr(termcaps.DefaultCellSize).Box(thumbCols, thumbMaxRows)
 		return gifdecode.Decode(data, decodeOpts)
 	}
-	sendThumbKitty = func(out *bufio.Writer, id uint32, frame gifdecode.Frame, cols, rows int) {
-		kitty.SendFrame(out, id, frame, cols, rows)
+	sendThumbKitty = func(s *kitty.Sender, out *bufio.Writer, id uint32, frame gifdecode.Frame, cols, rows int) {
+		s.SendFrame(out, id, frame, cols, rows)
+	}
+	sendThumbKittyVirtual = func(s *kitty.Sender, out *bufio.Writer, id uint32, frame gifdecode.Frame, cols, rows int) {
+		s.SendVirtualFrame(out, id, frame, cols, rows)
+	}
+	// kittyPlaceholders reports whether Kitty thumbs are drawn as placeholder
+	// text, which scrolls and reflows with the output.
+	kittyPlaceholders = func() bool {
+		return termcaps.KittyPlaceholders(os.Getenv)
+	}
+	// thumbIDBase keeps placeholder image ids unique per run: thumbs from an
+	// earlier run still refer to theirs in the scrollback.
+	thumbIDBase = func() uint32 {
+		return rand.Uint32()&0xffff00 | 0x100
+	}
+	encodeThumbGrid = func(thumbs termcaps.InlineProtocol, frame gifdecode.Frame, cols, rows int) ([]byte, error) {
+		if thumbs == termcaps.InlineSixel {
+			w, h := termcaps.CellSizeOr(termcaps.SixelCellSize).Box(cols, rows)
+			return sixel.EncodeFrame(frame, w, h)
+		}
+		return blocks.EncodeFrame(frame, cols, rows, termcaps.DetectTextStyle(os.Getenv))
 	}
 	sendThumbIterm = func(out *bufio.Writer, data []byte, cols, rows int) {
 		iterm.SendInlineFile(out, iterm.File{
@@ -98,7 +128,11 @@ func thumbsProtocol(opts model.Options, stdout io.Writer, format outputFormat) t
 	case thumbsNever:
 		return termcaps.InlineNone
 	case thumbsAlways:
-		return termcaps.DetectInlineRobust(os.Getenv)
+		// Without an image protocol, fall back to text blocks.
+		if p := termcaps.DetectInlineRobust(os.Getenv); p != termcaps.InlineNone {
+			return p
+		}
+		return termcaps.InlineBlocks
 	case thumbsAuto:
 		return termcaps.DetectInlineRobust(os.Getenv)
 	}
@@ -110,10 +144,14 @@ func renderPlain(
 	opts model.Options,
 	useColor bool,
 	thumbs termcaps.InlineProtocol,
+	sender *kitty.Sender,
 	results []model.Result,
 	termCols int,
 ) {
 	nextID := uint32(1)
+	if thumbs == termcaps.InlineKitty && kittyPlaceholders() {
+		nextID = thumbIDBase() + 1
+	}
 	withThumbs := thumbs != termcaps.InlineNone
 	for i, res := range results {
 		title := normalizeTitle(res)
@@ -124,7 +162,7 @@ func renderPlain(
 			nPrefix = fmt.Sprintf("%d. ", i+1)
 		}
 
-		if withThumbs && renderThumbBlock(out, thumbs, nextID, res, nPrefix, title, url, useColor, termCols) == nil {
+		if withThumbs && renderThumbBlock(out, thumbs, sender, nextID, res, nPrefix, title, url, useColor, termCols) == nil {
 			nextID++
 			if i < len(results)-1 {
 				if thumbs == termcaps.InlineIterm {
@@ -148,7 +186,7 @@ func renderPlain(
 	}
 }
 
-func renderThumbBlock(out *bufio.Writer, thumbs termcaps.InlineProtocol, id uint32, res model.Result, nPrefix, title, url string, useColor bool, termCols int) error {
+func renderThumbBlock(out *bufio.Writer, thumbs termcaps.InlineProtocol, sender *kitty.Sender, id uint32, res model.Result, nPrefix, title, url string, useColor bool, termCols int) error {
 	data, src, err := fetchThumbForResult(res)
 	if err != nil {
 		return err
@@ -159,7 +197,7 @@ func renderThumbBlock(out *bufio.Writer, thumbs termcaps.InlineProtocol, id uint
 	if err != nil {
 		return err
 	}
-	if err := sendThumb(out, thumbs, id, data, cols, rows); err != nil {
+	if err := sendThumb(out, thumbs, sender, id, data, cols, rows); err != nil {
 		return err
 	}
 
@@ -264,11 +302,11 @@ func fetchThumbForResult(res model.Result) ([]byte, string, error) {
 }
 
 func thumbBlockSize(thumbs termcaps.InlineProtocol, data []byte, res model.Result) (int, int) {
-	cols := 16
+	cols := thumbCols
 	rows := 8
 	if w, h := thumbDims(data, res); w > 0 && h > 0 {
 		if thumbs != termcaps.InlineIterm {
-			rows = clampInt(3, 10, int(float64(cols)*0.5*float64(h)/float64(w)))
+			rows = clampInt(thumbMinRows, thumbMaxRows, int(float64(cols)*termcaps.CellSizeOr(termcaps.DefaultCellSize).Aspect()*float64(h)/float64(w)))
 		}
 	}
 	return cols, rows
@@ -288,10 +326,15 @@ func prepareThumbData(thumbs termcaps.InlineProtocol, data []byte, src string, r
 			return nil, fmt.Errorf("empty image")
 		}
 		if !isSupportedItermImage(data) {
-			return nil, fmt.Errorf("unsupported image")
+			// iTerm2 can't show WebP; send the first frame as PNG.
+			decoded, err := decodeThumb(data)
+			if err != nil || len(decoded.Frames) == 0 {
+				return nil, fmt.Errorf("unsupported image")
+			}
+			return decoded.Frames[0].PNG, nil
 		}
 		return data, nil
-	case termcaps.InlineKitty:
+	case termcaps.InlineKitty, termcaps.InlineSixel, termcaps.InlineBlocks:
 		if len(data) == 0 {
 			return nil, fmt.Errorf("empty image")
 		}
@@ -301,7 +344,7 @@ func prepareThumbData(thumbs termcaps.InlineProtocol, data []byte, src string, r
 	}
 }
 
-func sendThumb(out *bufio.Writer, thumbs termcaps.InlineProtocol, id uint32, data []byte, cols, rows int) error {
+func sendThumb(out *bufio.Writer, thumbs termcaps.InlineProtocol, sender *kitty.Sender, id uint32, data []byte, cols, rows int) error {
 	switch thumbs {
 	case termcaps.InlineNone:
 		return fmt.Errorf("inline thumbnails not supported")
@@ -320,13 +363,57 @@ func sendThumb(out *bufio.Writer, thumbs termcaps.InlineProtocol, id uint32, dat
 		if decoded == nil || len(decoded.Frames) == 0 {
 			return fmt.Errorf("no frames")
 		}
-		sendThumbKitty(out, id, decoded.Frames[0], cols, rows)
+		if !kittyPlaceholders() {
+			sendThumbKitty(sender, out, id, decoded.Frames[0], cols, rows)
+			return nil
+		}
+		sendThumbKittyVirtual(sender, out, id, decoded.Frames[0], cols, rows)
+		writeInGrid(out, rows, func() { kitty.WritePlaceholders(out, id, cols, rows) })
+		return nil
+	case termcaps.InlineSixel, termcaps.InlineBlocks:
+		decoded, err := decodeThumb(data)
+		if err != nil {
+			return err
+		}
+		if decoded == nil || len(decoded.Frames) == 0 {
+			return fmt.Errorf("no frames")
+		}
+		encoded, err := encodeThumbGrid(thumbs, decoded.Frames[0], cols, rows)
+		if err != nil {
+			return err
+		}
+		writeInGrid(out, rows, func() { _, _ = out.Write(encoded) })
 		return nil
 	default:
 		return fmt.Errorf("inline thumbnails not supported")
 	}
 }
 
+// writeInGrid reserves rows first so the terminal scrolls before the image
+// is drawn, then comes back up; the text block is written beside it.
+func writeInGrid(out *bufio.Writer, rows int, draw func()) {
+	_, _ = fmt.Fprint(out, "\r"+strings.Repeat("\n", rows-1))
+	if rows > 1 {
+		_, _ = fmt.Fprintf(out, "\x1b[%dA", rows-1)
+	}
+	_, _ = fmt.Fprint(out, "\x1b7")
+	draw()
+	_, _ = fmt.Fprint(out, "\x1b8")
+}
+
+// thumbInGrid reports whether the thumb is part of the text grid, where
+// writing spaces over it would erase it.
+func thumbInGrid(thumbs termcaps.InlineProtocol) bool {
+	switch thumbs {
+	case termcaps.InlineIterm, termcaps.InlineSixel, termcaps.InlineBlocks:
+		return true
+	case termcaps.InlineKitty:
+		return kittyPlaceholders()
+	default:
+		return false
+	}
+}
+
 func thumbIndentCols(thumbs termcaps.InlineProtocol, cols int) int {
 	if thumbs == termcaps.InlineIterm {
 		return cols
@@ -374,7 +461,7 @@ func writeThumbTextBlock(out *bufio.Writer, thumbs termcaps.InlineProtocol, rows
 			}
 		}
 
-		if thumbs == termcaps.InlineIterm {
+		if thumbInGrid(thumbs) {
 			col := indentCols + 1
 			if col < 1 {
 				col = 1
@@ -11,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/internal/kitty"
 	"github.com/steipete/gifgrep/internal/model"
 	"github.com/steipete/gifgrep/internal/termcaps"
 )
@@ -52,7 +53,7 @@ func TestRenderPlainNoThumbs(t *testing.T) {
 	var buf bytes.Buffer
 	out := bufio.NewWriter(&buf)
 
-	renderPlain(out, model.Options{Number: true}, false, termcaps.InlineNone, []model.Result{
+	renderPlain(out, model.Options{Number: true}, false, termcaps.InlineNone, nil, []model.Result{
 		{Title: "A dog", URL: "https://example.test/a.gif"},
 	}, 0)
 	_ = out.Flush()
@@ -70,24 +71,27 @@ func TestRenderPlainThumbsIncludesBlockGap(t *testing.T) {
 	prevFetch := fetchThumb
 	prevDecode := decodeThumb
 	prevSend := sendThumbKitty
+	prevPlaceholders := kittyPlaceholders
 	t.Cleanup(func() {
 		fetchThumb = prevFetch
 		decodeThumb = prevDecode
 		sendThumbKitty = prevSend
+		kittyPlaceholders = prevPlaceholders
 	})
+	kittyPlaceholders = func() bool { return false }
 
 	fetchThumb = func(_ string) ([]byte, error) { return []byte("gif"), nil }
 	decodeThumb = func(_ []byte) (*gifdecode.Frames, error) {
 		return &gifdecode.Frames{Frames: []gifdecode.Frame{{PNG: []byte{1}}}}, nil
 	}
-	sendThumbKitty = func(out *bufio.Writer, id uint32, _ gifdecode.Frame, _, _ int) {
+	sendThumbKitty = func(_ *kitty.Sender, out *bufio.Writer, id uint32, _ gifdecode.Frame, _, _ int) {
 		_, _ = fmt.Fprintf(out, "<IMG%d>", id)
 	}
 
 	var buf bytes.Buffer
 	out := bufio.NewWriter(&buf)
 
-	renderPlain(out, model.Options{}, false, termcaps.InlineKitty, []model.Result{
+	renderPlain(out, model.Options{}, false, termcaps.InlineKitty, nil, []model.Result{
 		{Title: "A", URL: "https://example.test/a.gif"},
 		{Title: "B", URL: "https://example.test/b.gif"},
 	}, 0)
@@ -105,6 +109,83 @@ func TestRenderPlainThumbsIncludesBlockGap(t *testing.T) {
 	}
 }
 
+func TestRenderPlainThumbsGridKeepsImage(t *testing.T) {
+	prevFetch := fetchThumb
+	prevDecode := decodeThumb
+	prevEncode := encodeThumbGrid
+	t.Cleanup(func() {
+		fetchThumb = prevFetch
+		decodeThumb = prevDecode
+		encodeThumbGrid = prevEncode
+	})
+
+	fetchThumb = func(_ string) ([]byte, error) { return []byte("gif"), nil }
+	decodeThumb = func(_ []byte) (*gifdecode.Frames, error) {
+		return &gifdecode.Frames{Frames: []gifdecode.Frame{{PNG: []byte{1}}}}, nil
+	}
+	encodeThumbGrid = func(thumbs termcaps.InlineProtocol, _ gifdecode.Frame, cols, rows int) ([]byte, error) {
+		return []byte(fmt.Sprintf("<%s %dx%d>", thumbs, cols, rows)), nil
+	}
+
+	for _, thumbs := range []termcaps.InlineProtocol{termcaps.InlineSixel, termcaps.InlineBlocks} {
+		var buf bytes.Buffer
+		out := bufio.NewWriter(&buf)
+		renderPlain(out, model.Options{}, false, thumbs, nil, []model.Result{
+			{Title: "A", URL: "https://example.test/a.gif"},
+		}, 80)
+		_ = out.Flush()
+
+		text := buf.String()
+		if !strings.Contains(text, "\x1b7<"+thumbs.String()+" 16x8>\x1b8") {
+			t.Fatalf("expected image between cursor save/restore: %q", text)
+		}
+		// Text goes beside the image via CHA; leading spaces would erase it.
+		if !strings.Contains(text, "\x1b[19GA") {
+			t.Fatalf("expected title after the image: %q", text)
+		}
+	}
+}
+
+func TestRenderPlainThumbsKittyPlaceholders(t *testing.T) {
+	prevFetch := fetchThumb
+	prevDecode := decodeThumb
+	prevSend := sendThumbKittyVirtual
+	prevPlaceholders := kittyPlaceholders
+	prevBase := thumbIDBase
+	t.Cleanup(func() {
+		fetchThumb = prevFetch
+		decodeThumb = prevDecode
+		sendThumbKittyVirtual = prevSend
+		kittyPlaceholders = prevPlaceholders
+		thumbIDBase = prevBase
+	})
+
+	fetchThumb = func(_ string) ([]byte, error) { return []byte("gif"), nil }
+	decodeThumb = func(_ []byte) (*gifdecode.Frames, error) {
+		return &gifdecode.Frames{Frames: []gifdecode.Frame{{PNG: []byte{1}}}}, nil
+	}
+	sendThumbKittyVirtual = func(_ *kitty.Sender, out *bufio.Writer, id uint32, _ gifdecode.Frame, _, _ int) {
+		_, _ = fmt.Fprintf(out, "<VIRT%d>", id)
+	}
+	kittyPlaceholders = func() bool { return true }
+	thumbIDBase = func() uint32 { return 0x020300 }
+
+	var buf bytes.Buffer
+	out := bufio.NewWriter(&buf)
+	renderPlain(out, model.Options{}, false, termcaps.InlineKitty, nil, []model.Result{
+		{Title: "A", URL: "https://example.test/a.gif"},
+	}, 80)
+	_ = out.Flush()
+
+	text := buf.String()
+	if !strings.Contains(text, "<VIRT131841>") || !strings.Contains(text, "\x1b7\x1b[38;2;2;3;1m\U0010EEEE") {
+		t.Fatalf("expected placeholder cells after the virtual placement: %q", text)
+	}
+	if !strings.Contains(text, "\x1b[19GA") {
+		t.Fatalf("expected title beside the placeholders: %q", text)
+	}
+}
+
 func TestRenderPlainThumbsItermUsesRawGIF(t *testing.T) {
 	prevFetch := fetchThumb
 	prevDecode := decodeThumb
@@ -131,7 +212,7 @@ func TestRenderPlainThumbsItermUsesRawGIF(t *testing.T) {
 	var buf bytes.Buffer
 	out := bufio.NewWriter(&buf)
 
-	renderPlain(out, model.Options{}, false, termcaps.InlineIterm, []model.Result{
+	renderPlain(out, model.Options{}, false, termcaps.InlineIterm, nil, []model.Result{
 		{Title: "A", URL: "https://example.test/a.gif"},
 	}, 80)
 	_ = out.Flush()
@@ -163,7 +244,7 @@ func TestRenderPlainThumbsItermWrapsURLToTerminalWidth(t *testing.T) {
 
 	termCols := 40
 	url := strings.Repeat("a", 30)
-	renderPlain(out, model.Options{}, false, termcaps.InlineIterm, []model.Result{
+	renderPlain(out, model.Options{}, false, termcaps.InlineIterm, nil, []model.Result{
 		{Title: "T", URL: url},
 	}, termCols)
 	_ = out.Flush()
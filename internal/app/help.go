@@ -171,6 +171,9 @@ func helpExtras(ctx *kong.Context) []string {
 	if selected == nil {
 		return rootHelpExtras()
 	}
+	if selected.Parent != nil && selected.Parent.Name == "auth" {
+		return authHelpExtras()
+	}
 	switch selected.Name {
 	case "search":
 		return searchHelpExtras()
@@ -180,6 +183,16 @@ func helpExtras(ctx *kong.Context) []string {
 		return stillHelpExtras()
 	case "sheet":
 		return sheetHelpExtras()
+	case "edit":
+		return editHelpExtras()
+	case "caption":
+		return captionHelpExtras()
+	case "doctor":
+		return doctorHelpExtras()
+	case "config", "get", "set", "path":
+		return configHelpExtras()
+	case "auth":
+		return authHelpExtras()
 	default:
 		return rootHelpExtras()
 	}
@@ -193,10 +206,16 @@ func rootHelpExtras() []string {
 		"  gifgrep tui cats",
 		"  gifgrep still cat.gif --at 1.5s -o still.png",
 		"  gifgrep sheet cat.gif --frames 12 --cols 4 -o sheet.png",
+		"  gifgrep edit cat.gif --from 1s --to 3s --width 320 -o clip.gif",
+		"  gifgrep caption cat.gif --top \"me\" --bottom \"also me\" -o meme.gif",
+		"  gifgrep doctor --render",
+		"  gifgrep config set max 40",
+		"  gifgrep auth set giphy",
 		"",
 		"Environment:",
-		"  TENOR_API_KEY  optional (defaults to Tenor demo key)",
-		"  GIPHY_API_KEY  required for --source giphy",
+		"  TENOR_API_KEY    optional (defaults to Tenor demo key)",
+		"  GIPHY_API_KEY    needed for --source giphy unless stored with `gifgrep auth set giphy`",
+		"  GIFGREP_PROFILE  config profile when --profile is not given",
 	}
 }
 
@@ -206,27 +225,58 @@ func searchHelpExtras() []string {
 		"  Default (--format auto): plain (TTY), url (pipe).",
 		"  Use --format plain|tsv|md|url|comment|json, or --json.",
 		"  Use --download to save results to ~/Downloads (combine with --reveal).",
+		"  Use --copy to copy the first result; --copy-as url|md|html|gif|data picks the form.",
+		"  Use --format-pref mp4,webp,gif and --size small|medium|original to pick renditions.",
 		"",
 		"Examples:",
 		"  gifgrep cats | head -n 5",
 		"  gifgrep cats --download --max 1 --format url",
+		"  gifgrep cats --max 1 --copy",
+		"  gifgrep cats --max 1 --copy --copy-as md",
 		"  gifgrep search --json cats | jq '.[] | .url'",
 		"  gifgrep search --source tenor cats",
+		"  gifgrep cats --format-pref mp4,gif --size small --format url",
 		"  GIPHY_API_KEY=... gifgrep search --source giphy cats",
+		"  gifgrep search --source favorites",
 	}
 }
 
 func tuiHelpExtras() []string {
 	return []string{
-		"Keys:",
+		"Keys (default keymap; --keymap vim|emacs, rebind with --keys or [keys]):",
 		"  /      edit search",
-		"  ↑↓     select",
-		"  d      download selection",
+		"  ↑↓ jk  select (PgUp/PgDn, Home/End or g/G, ^U/^D half page; 5j moves 5, 5⏎ jumps to result 5)",
+		"  ^F     find in results, then n/N for next/previous match",
+		"  =      filter/sort results (cat wide 320x frames>=10 sort:-size)",
+		"  Tab    thumbnail grid (←→ between tiles) / back to the list",
+		"  c      copy selected GIF (image, plus URL and HTML)",
+		"  y      copy URL (OSC 52 over SSH)",
+		"  Y      copy Markdown image link",
+		"  C      copy HTML <img> tag",
+		"  U      copy GIF as a data: URI",
+		"  d      download selection (or every marked GIF)",
+		"  Space  mark and move on (V marks a range, u clears)",
+		"  *      add selection or marks to favorites (browse them with --source favorites)",
+		"  E      export selection or marks as Markdown",
+		"  p      pause/resume preview (, . step a frame; [ ] slower/faster)",
+		"  S      save the frame on screen as PNG",
+		"  t      caption selection (top | bottom) into ~/Downloads",
 		"  f      reveal last download in file manager",
+		"  ?      show every key of the active keymap",
 		"  q      quit",
 		"",
+		"Mouse (--no-mouse to turn off):",
+		"  click a row or tile to select, double-click to download, wheel to scroll,",
+		"  click the preview to pause/resume.",
+		"",
+		"Previews:",
+		"  Kitty/Ghostty, iTerm2 and Sixel terminals get images; anything else gets",
+		"  text previews (GIFGREP_TEXT_STYLE=truecolor|256|braille).",
+		"  In tmux, set allow-passthrough on to keep Kitty/iTerm2 images.",
+		"",
 		"Examples:",
 		"  gifgrep tui cats",
+		"  gifgrep tui --keymap vim --keys 'download=D;quit=q ctrl+x'",
 	}
 }
 
@@ -245,3 +295,80 @@ func sheetHelpExtras() []string {
 		"  gifgrep sheet cat.gif --frames 16 --cols 4 --padding 4 -o sheet.png",
 	}
 }
+
+func editHelpExtras() []string {
+	return []string{
+		"Pipeline:",
+		"  trim (--from/--to) → crop → resize → --fps → --speed → --reverse → --boomerang",
+		"  --max-bytes then lowers colors, size and frame count until the file fits.",
+		"",
+		"Examples:",
+		"  gifgrep edit cat.gif --from 0.5s --to 2s -o clip.gif",
+		"  gifgrep edit cat.gif --crop 240x240+40+0 --width 120 -o avatar.gif",
+		"  gifgrep edit cat.gif --speed 2x --boomerang -o fast.gif",
+		"  gifgrep edit https://example.com/cat.gif --max-bytes 8MB -o - > small.gif",
+	}
+}
+
+func captionHelpExtras() []string {
+	return []string{
+		"Text:",
+		"  Long lines wrap; use a real newline to force a break.",
+		"  Without --size the font fits the GIF (about 1/8 of its height).",
+		"  --at or a .png output writes a captioned still instead of a GIF.",
+		"",
+		"Examples:",
+		"  gifgrep caption cat.gif --top \"one does not simply\" --bottom \"ship on friday\" -o meme.gif",
+		"  gifgrep caption cat.gif --text \"mood\" --position middle --size 48 -o mood.gif",
+		"  gifgrep caption cat.gif --bottom \"same\" --at 1.2s -o same.png",
+	}
+}
+
+func doctorHelpExtras() []string {
+	return []string{
+		"Report:",
+		"  Detected protocols, raw terminal replies (Kitty, DA1/DA2, XTVERSION, cell size),",
+		"  tmux passthrough, clipboard/reveal tools, API key presence and directory health.",
+		"  API key values are never printed.",
+		"",
+		"Examples:",
+		"  gifgrep doctor",
+		"  gifgrep doctor --json > report.json",
+		"  gifgrep doctor --render",
+	}
+}
+
+func configHelpExtras() []string {
+	return []string{
+		"File:",
+		"  $XDG_CONFIG_HOME/gifgrep/config.toml (default ~/.config/gifgrep/config.toml).",
+		"  Keys are global, search and tui flag names (source, max, format, rating, download-dir,",
+		"  theme, …); other commands read [COMMAND] tables such as [caption].",
+		"  [keys] rebinds TUI actions, and [profile.NAME] tables override them for --profile NAME.",
+		"  Flags beat environment variables, which beat the profile, which beats the top level.",
+		"",
+		"Examples:",
+		"  gifgrep config set source giphy",
+		"  gifgrep config set profile.work.rating g",
+		"  gifgrep config set keys.download D",
+		"  gifgrep config set caption.size 32",
+		"  gifgrep config get profile.work",
+		"  gifgrep --profile work tui",
+	}
+}
+
+func authHelpExtras() []string {
+	return []string{
+		"Lookup order:",
+		"  credentials.toml (next to config.toml), then GIPHY_API_KEY/TENOR_API_KEY,",
+		"  then Tenor's public demo key. --source auto picks giphy when a key is stored or set.",
+		"  The key is prompted for without echo, or read from the first line of stdin.",
+		"",
+		"Examples:",
+		"  gifgrep auth set giphy",
+		"  pbpaste | gifgrep auth set giphy",
+		"  gifgrep auth set giphy --backend pass --entry api/giphy",
+		"  gifgrep auth set giphy --backend command --command 'op read op://dev/giphy/key'",
+		"  gifgrep auth set tenor --backend secret-service",
+	}
+}
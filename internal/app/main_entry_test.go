@@ -43,6 +43,12 @@ func TestRunArgs(t *testing.T) {
 		}
 	})
 
+	t.Run("bad format pref", func(t *testing.T) {
+		if code := Run([]string{"search", "--format-pref", "mp4,mov", "cats"}); code != 2 {
+			t.Fatalf("expected exit 2")
+		}
+	})
+
 	t.Run("tui", func(t *testing.T) {
 		t.Cleanup(func() { tui.SetDefaultEnvForTest(nil) })
 		t.Setenv("GIFGREP_INLINE", "kitty")
@@ -3,10 +3,12 @@ package app
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
 	"github.com/alecthomas/kong"
+	"github.com/steipete/gifgrep/internal/config"
 	"github.com/steipete/gifgrep/internal/model"
 )
 
@@ -20,7 +22,20 @@ func Run(args []string) int {
 	}
 
 	cli := &CLI{}
+	var cfg *config.File
+	var cfgErr error
 	parser, err := kong.New(cli,
+		kong.Configuration(func(r io.Reader) (kong.Resolver, error) {
+			f, err := config.Parse(r)
+			if err != nil {
+				// applyConfig reports it, after config commands had a
+				// chance to fix the file.
+				cfgErr = err
+				return new(config.File).Resolver(), nil
+			}
+			cfg = f
+			return f.Resolver(), nil
+		}, configPath()),
 		kong.Name(model.AppName),
 		kong.Vars{"version": model.AppName + " " + model.Version},
 		kong.Help(helpPrinter),
@@ -58,6 +73,10 @@ func Run(args []string) int {
 	if ctx == nil {
 		return 0
 	}
+	if err := applyConfig(ctx, cfg, cfgErr, cli.Globals.Profile); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
 
 	if err := ctx.Run(); err != nil {
 		_, _ = fmt.Fprintln(os.Stderr, err.Error())
@@ -66,6 +85,32 @@ func Run(args []string) int {
 	return 0
 }
 
+// applyConfig checks the config file and exports the flagless settings of
+// the active profile. `config` commands skip it, so they can fix the file,
+// and doctor reports a file that doesn't parse instead of failing on it.
+func applyConfig(ctx *kong.Context, cfg *config.File, cfgErr error, profile string) error {
+	if strings.HasPrefix(ctx.Command(), "config ") {
+		return nil
+	}
+	if cfgErr != nil {
+		if ctx.Command() == "doctor" {
+			return nil
+		}
+		return fmt.Errorf("%s: %w", configPath(), cfgErr)
+	}
+	if cfg == nil {
+		if profile != "" {
+			return fmt.Errorf("--profile %s: no config file at %s", profile, configPath())
+		}
+		return nil
+	}
+	profile = cfg.ProfileName(profile, os.Getenv)
+	if err := cfg.Check(ctx.Model.Node, profile); err != nil {
+		return fmt.Errorf("%s: %w", configPath(), err)
+	}
+	return cfg.ApplyEnv(profile, os.Getenv, os.Setenv)
+}
+
 func parseWithExit(parser *kong.Kong, args []string) (ctx *kong.Context, err error) {
 	defer func() {
 		if r := recover(); r != nil {
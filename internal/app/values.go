@@ -0,0 +1,47 @@
+package app
+
+import (
+	"encoding"
+	"image"
+
+	"github.com/steipete/gifgrep/internal/edit"
+)
+
+type CropValue image.Rectangle
+
+var _ encoding.TextUnmarshaler = (*CropValue)(nil)
+
+func (c *CropValue) UnmarshalText(text []byte) error {
+	r, err := edit.ParseCrop(string(text))
+	if err != nil {
+		return err
+	}
+	*c = CropValue(r)
+	return nil
+}
+
+type SpeedValue float64
+
+var _ encoding.TextUnmarshaler = (*SpeedValue)(nil)
+
+func (s *SpeedValue) UnmarshalText(text []byte) error {
+	v, err := edit.ParseSpeed(string(text))
+	if err != nil {
+		return err
+	}
+	*s = SpeedValue(v)
+	return nil
+}
+
+type ByteSizeValue int64
+
+var _ encoding.TextUnmarshaler = (*ByteSizeValue)(nil)
+
+func (b *ByteSizeValue) UnmarshalText(text []byte) error {
+	v, err := edit.ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = ByteSizeValue(v)
+	return nil
+}
@@ -2,9 +2,27 @@ package assets
 
 import _ "embed"
 
+//go:generate go run gen_caption_font.go
+
 //go:embed giphy-32.png
 var giphyIcon32PNG []byte
 
+//go:embed caption-font.png
+var captionFontPNG []byte
+
+//go:embed caption-font.json
+var captionFontJSON []byte
+
 func GiphyIcon32PNG() []byte {
 	return giphyIcon32PNG
 }
+
+// CaptionFontPNG is a grayscale glyph atlas of DejaVu Sans Bold (ASCII).
+func CaptionFontPNG() []byte {
+	return captionFontPNG
+}
+
+// CaptionFontJSON holds the atlas glyph rectangles and metrics.
+func CaptionFontJSON() []byte {
+	return captionFontJSON
+}
@@ -0,0 +1,500 @@
+//go:build ignore
+
+// gen_caption_font rasterizes ASCII glyphs of a TrueType font into the
+// caption atlas (caption-font.png + caption-font.json).
+//
+//	go run gen_caption_font.go -font /usr/share/fonts/truetype/dejavu/DejaVuSans-Bold.ttf
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"math"
+	"os"
+	"sort"
+)
+
+const (
+	firstRune  = 32
+	lastRune   = 126
+	atlasWidth = 1024
+	padding    = 2
+	subrows    = 4
+)
+
+type glyphMetrics struct {
+	X       int `json:"x"`
+	Y       int `json:"y"`
+	W       int `json:"w"`
+	H       int `json:"h"`
+	Left    int `json:"left"`
+	Top     int `json:"top"`
+	Advance int `json:"advance"`
+}
+
+type fontMetrics struct {
+	Em      int                     `json:"em"`
+	Ascent  int                     `json:"ascent"`
+	Descent int                     `json:"descent"`
+	LineGap int                     `json:"line_gap"`
+	Glyphs  map[string]glyphMetrics `json:"glyphs"`
+}
+
+type ttf struct {
+	data       []byte
+	tables     map[string][]byte
+	unitsPerEm int
+	longLoca   bool
+	numHMetric int
+}
+
+type point struct {
+	x, y    float64
+	onCurve bool
+}
+
+type edge struct {
+	x0, y0, x1, y1 float64
+}
+
+func main() {
+	fontPath := flag.String("font", "/usr/share/fonts/truetype/dejavu/DejaVuSans-Bold.ttf", "TrueType font")
+	em := flag.Int("em", 64, "Pixels per em")
+	outPNG := flag.String("png", "caption-font.png", "Atlas output")
+	outJSON := flag.String("json", "caption-font.json", "Metrics output")
+	flag.Parse()
+
+	data, err := os.ReadFile(*fontPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	f, err := parseTTF(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+	scale := float64(*em) / float64(f.unitsPerEm)
+
+	hhea := f.tables["hhea"]
+	metrics := fontMetrics{
+		Em:      *em,
+		Ascent:  int(math.Round(float64(int16(binary.BigEndian.Uint16(hhea[4:]))) * scale)),
+		Descent: int(math.Round(float64(-int16(binary.BigEndian.Uint16(hhea[6:]))) * scale)),
+		LineGap: int(math.Round(float64(int16(binary.BigEndian.Uint16(hhea[8:]))) * scale)),
+		Glyphs:  map[string]glyphMetrics{},
+	}
+
+	type raster struct {
+		r    rune
+		img  *image.Alpha
+		left int
+		top  int
+		adv  int
+	}
+	var rasters []raster
+	for r := rune(firstRune); r <= lastRune; r++ {
+		gid, err := f.glyphIndex(r)
+		if err != nil {
+			log.Fatal(err)
+		}
+		contours, err := f.glyphContours(gid, 0)
+		if err != nil {
+			log.Fatal(err)
+		}
+		img, left, top := rasterize(contours, scale)
+		rasters = append(rasters, raster{r: r, img: img, left: left, top: top, adv: int(math.Round(float64(f.advance(gid)) * scale))})
+	}
+
+	// Shelf-pack tallest first for a compact atlas.
+	order := make([]int, len(rasters))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return rasters[order[a]].img.Rect.Dy() > rasters[order[b]].img.Rect.Dy()
+	})
+	x, y, shelf := padding, padding, 0
+	pos := make([]image.Point, len(rasters))
+	for _, i := range order {
+		w, h := rasters[i].img.Rect.Dx(), rasters[i].img.Rect.Dy()
+		if x+w+padding > atlasWidth {
+			x = padding
+			y += shelf + padding
+			shelf = 0
+		}
+		pos[i] = image.Pt(x, y)
+		x += w + padding
+		if h > shelf {
+			shelf = h
+		}
+	}
+	atlas := image.NewGray(image.Rect(0, 0, atlasWidth, y+shelf+padding))
+	for i, ras := range rasters {
+		p := pos[i]
+		for yy := 0; yy < ras.img.Rect.Dy(); yy++ {
+			for xx := 0; xx < ras.img.Rect.Dx(); xx++ {
+				atlas.SetGray(p.X+xx, p.Y+yy, color.Gray{Y: ras.img.AlphaAt(xx, yy).A})
+			}
+		}
+		metrics.Glyphs[fmt.Sprint(int(ras.r))] = glyphMetrics{
+			X: p.X, Y: p.Y, W: ras.img.Rect.Dx(), H: ras.img.Rect.Dy(),
+			Left: ras.left, Top: ras.top, Advance: ras.adv,
+		}
+	}
+
+	out, err := os.Create(*outPNG)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(out, atlas); err != nil {
+		log.Fatal(err)
+	}
+	if err := out.Close(); err != nil {
+		log.Fatal(err)
+	}
+	js, err := json.MarshalIndent(metrics, "", " ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*outJSON, append(js, '\n'), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func parseTTF(data []byte) (*ttf, error) {
+	if len(data) < 12 {
+		return nil, errors.New("short font")
+	}
+	n := int(binary.BigEndian.Uint16(data[4:]))
+	f := &ttf{data: data, tables: map[string][]byte{}}
+	for i := 0; i < n; i++ {
+		rec := data[12+16*i:]
+		tag := string(rec[:4])
+		off := binary.BigEndian.Uint32(rec[8:])
+		length := binary.BigEndian.Uint32(rec[12:])
+		f.tables[tag] = data[off : off+length]
+	}
+	for _, tag := range []string{"head", "hhea", "hmtx", "cmap", "loca", "glyf"} {
+		if f.tables[tag] == nil {
+			return nil, fmt.Errorf("missing %s table", tag)
+		}
+	}
+	f.unitsPerEm = int(binary.BigEndian.Uint16(f.tables["head"][18:]))
+	f.longLoca = binary.BigEndian.Uint16(f.tables["head"][50:]) == 1
+	f.numHMetric = int(binary.BigEndian.Uint16(f.tables["hhea"][34:]))
+	return f, nil
+}
+
+func (f *ttf) glyphIndex(r rune) (int, error) {
+	cmap := f.tables["cmap"]
+	n := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < n; i++ {
+		rec := cmap[4+8*i:]
+		platform := binary.BigEndian.Uint16(rec)
+		encoding := binary.BigEndian.Uint16(rec[2:])
+		off := binary.BigEndian.Uint32(rec[4:])
+		sub := cmap[off:]
+		if platform != 3 || encoding != 1 || binary.BigEndian.Uint16(sub) != 4 {
+			continue
+		}
+		segX2 := int(binary.BigEndian.Uint16(sub[6:]))
+		ends := sub[14:]
+		starts := sub[16+segX2:]
+		deltas := sub[16+2*segX2:]
+		rangeOffsets := sub[16+3*segX2:]
+		for s := 0; s < segX2; s += 2 {
+			end := rune(binary.BigEndian.Uint16(ends[s:]))
+			start := rune(binary.BigEndian.Uint16(starts[s:]))
+			if r < start || r > end {
+				continue
+			}
+			delta := int(int16(binary.BigEndian.Uint16(deltas[s:])))
+			ro := int(binary.BigEndian.Uint16(rangeOffsets[s:]))
+			if ro == 0 {
+				return (int(r) + delta) & 0xffff, nil
+			}
+			idx := s + ro + 2*int(r-start)
+			gid := int(binary.BigEndian.Uint16(rangeOffsets[idx:]))
+			if gid == 0 {
+				return 0, nil
+			}
+			return (gid + delta) & 0xffff, nil
+		}
+		return 0, nil
+	}
+	return 0, errors.New("no unicode BMP cmap")
+}
+
+func (f *ttf) advance(gid int) int {
+	if gid >= f.numHMetric {
+		gid = f.numHMetric - 1
+	}
+	return int(binary.BigEndian.Uint16(f.tables["hmtx"][4*gid:]))
+}
+
+func (f *ttf) glyphData(gid int) []byte {
+	loca := f.tables["loca"]
+	var start, end int
+	if f.longLoca {
+		start = int(binary.BigEndian.Uint32(loca[4*gid:]))
+		end = int(binary.BigEndian.Uint32(loca[4*gid+4:]))
+	} else {
+		start = 2 * int(binary.BigEndian.Uint16(loca[2*gid:]))
+		end = 2 * int(binary.BigEndian.Uint16(loca[2*gid+2:]))
+	}
+	return f.tables["glyf"][start:end]
+}
+
+func (f *ttf) glyphContours(gid, depth int) ([][]point, error) {
+	g := f.glyphData(gid)
+	if len(g) == 0 {
+		return nil, nil
+	}
+	nContours := int(int16(binary.BigEndian.Uint16(g)))
+	if nContours < 0 {
+		return f.compositeContours(g[10:], depth)
+	}
+	endPts := make([]int, nContours)
+	for i := range endPts {
+		endPts[i] = int(binary.BigEndian.Uint16(g[10+2*i:]))
+	}
+	numPts := 0
+	if nContours > 0 {
+		numPts = endPts[nContours-1] + 1
+	}
+	p := 10 + 2*nContours
+	p += 2 + int(binary.BigEndian.Uint16(g[p:]))
+
+	flags := make([]byte, 0, numPts)
+	for len(flags) < numPts {
+		fl := g[p]
+		p++
+		flags = append(flags, fl)
+		if fl&8 != 0 {
+			rep := int(g[p])
+			p++
+			for i := 0; i < rep; i++ {
+				flags = append(flags, fl)
+			}
+		}
+	}
+	readCoords := func(shortBit, sameBit byte) []int {
+		out := make([]int, numPts)
+		v := 0
+		for i, fl := range flags {
+			switch {
+			case fl&shortBit != 0:
+				d := int(g[p])
+				p++
+				if fl&sameBit == 0 {
+					d = -d
+				}
+				v += d
+			case fl&sameBit == 0:
+				v += int(int16(binary.BigEndian.Uint16(g[p:])))
+				p += 2
+			}
+			out[i] = v
+		}
+		return out
+	}
+	xs := readCoords(2, 16)
+	ys := readCoords(4, 32)
+
+	contours := make([][]point, 0, nContours)
+	start := 0
+	for _, end := range endPts {
+		c := make([]point, 0, end-start+1)
+		for i := start; i <= end; i++ {
+			c = append(c, point{x: float64(xs[i]), y: float64(ys[i]), onCurve: flags[i]&1 != 0})
+		}
+		contours = append(contours, c)
+		start = end + 1
+	}
+	return contours, nil
+}
+
+func (f *ttf) compositeContours(g []byte, depth int) ([][]point, error) {
+	if depth > 8 {
+		return nil, errors.New("composite glyph nesting too deep")
+	}
+	var out [][]point
+	p := 0
+	for {
+		flags := binary.BigEndian.Uint16(g[p:])
+		gid := int(binary.BigEndian.Uint16(g[p+2:]))
+		p += 4
+		var dx, dy float64
+		if flags&1 != 0 {
+			dx = float64(int16(binary.BigEndian.Uint16(g[p:])))
+			dy = float64(int16(binary.BigEndian.Uint16(g[p+2:])))
+			p += 4
+		} else {
+			dx = float64(int8(g[p]))
+			dy = float64(int8(g[p+1]))
+			p += 2
+		}
+		a, b, c, d := 1.0, 0.0, 0.0, 1.0
+		f2dot14 := func(o int) float64 { return float64(int16(binary.BigEndian.Uint16(g[o:]))) / 16384 }
+		switch {
+		case flags&0x08 != 0:
+			a = f2dot14(p)
+			d = a
+			p += 2
+		case flags&0x40 != 0:
+			a, d = f2dot14(p), f2dot14(p+2)
+			p += 4
+		case flags&0x80 != 0:
+			a, b, c, d = f2dot14(p), f2dot14(p+2), f2dot14(p+4), f2dot14(p+6)
+			p += 8
+		}
+		sub, err := f.glyphContours(gid, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		for _, contour := range sub {
+			moved := make([]point, len(contour))
+			for i, pt := range contour {
+				moved[i] = point{x: a*pt.x + c*pt.y + dx, y: b*pt.x + d*pt.y + dy, onCurve: pt.onCurve}
+			}
+			out = append(out, moved)
+		}
+		if flags&0x20 == 0 {
+			return out, nil
+		}
+	}
+}
+
+// flatten converts quadratic TrueType contours into line edges in pixel
+// space (y down, origin on the baseline).
+func flatten(contours [][]point, scale float64) []edge {
+	var edges []edge
+	for _, c := range contours {
+		if len(c) == 0 {
+			continue
+		}
+		// Expand implied on-curve points between consecutive off-curve points.
+		var pts []point
+		for i, p := range c {
+			prev := c[(i+len(c)-1)%len(c)]
+			if !p.onCurve && !prev.onCurve {
+				pts = append(pts, point{x: (p.x + prev.x) / 2, y: (p.y + prev.y) / 2, onCurve: true})
+			}
+			pts = append(pts, p)
+		}
+		startIdx := 0
+		for i, p := range pts {
+			if p.onCurve {
+				startIdx = i
+				break
+			}
+		}
+		n := len(pts)
+		cur := pts[startIdx]
+		for k := 1; k <= n; k++ {
+			p := pts[(startIdx+k)%n]
+			if p.onCurve {
+				edges = append(edges, edge{cur.x * scale, -cur.y * scale, p.x * scale, -p.y * scale})
+				cur = p
+				continue
+			}
+			next := pts[(startIdx+k+1)%n]
+			const steps = 8
+			px, py := cur.x, cur.y
+			for s := 1; s <= steps; s++ {
+				t := float64(s) / steps
+				mt := 1 - t
+				x := mt*mt*cur.x + 2*mt*t*p.x + t*t*next.x
+				y := mt*mt*cur.y + 2*mt*t*p.y + t*t*next.y
+				edges = append(edges, edge{px * scale, -py * scale, x * scale, -y * scale})
+				px, py = x, y
+			}
+			cur = next
+			k++
+		}
+	}
+	return edges
+}
+
+// rasterize fills edges with the nonzero rule. Coverage is exact
+// horizontally and 4x supersampled vertically.
+func rasterize(contours [][]point, scale float64) (*image.Alpha, int, int) {
+	edges := flatten(contours, scale)
+	if len(edges) == 0 {
+		return image.NewAlpha(image.Rect(0, 0, 0, 0)), 0, 0
+	}
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, e := range edges {
+		minX = math.Min(minX, math.Min(e.x0, e.x1))
+		maxX = math.Max(maxX, math.Max(e.x0, e.x1))
+		minY = math.Min(minY, math.Min(e.y0, e.y1))
+		maxY = math.Max(maxY, math.Max(e.y0, e.y1))
+	}
+	ox, oy := int(math.Floor(minX)), int(math.Floor(minY))
+	w := int(math.Ceil(maxX)) - ox + 1
+	h := int(math.Ceil(maxY)) - oy + 1
+	cov := make([]float64, w*h)
+
+	type crossing struct {
+		x   float64
+		dir int
+	}
+	for row := 0; row < h; row++ {
+		for sub := 0; sub < subrows; sub++ {
+			sy := float64(oy+row) + (float64(sub)+0.5)/subrows
+			var xs []crossing
+			for _, e := range edges {
+				if e.y0 == e.y1 {
+					continue
+				}
+				dir := 1
+				y0, y1, x0, x1 := e.y0, e.y1, e.x0, e.x1
+				if y0 > y1 {
+					y0, y1, x0, x1 = y1, y0, x1, x0
+					dir = -1
+				}
+				if sy < y0 || sy >= y1 {
+					continue
+				}
+				xs = append(xs, crossing{x: x0 + (sy-y0)*(x1-x0)/(y1-y0), dir: dir})
+			}
+			sort.Slice(xs, func(i, j int) bool { return xs[i].x < xs[j].x })
+			wind := 0
+			for i := 0; i+1 < len(xs); i++ {
+				wind += xs[i].dir
+				if wind == 0 {
+					continue
+				}
+				addSpan(cov[row*w:(row+1)*w], xs[i].x-float64(ox), xs[i+1].x-float64(ox), 1.0/subrows)
+			}
+		}
+	}
+	img := image.NewAlpha(image.Rect(0, 0, w, h))
+	for i, c := range cov {
+		img.Pix[i] = uint8(math.Round(math.Min(1, c) * 255))
+	}
+	return img, ox, -oy
+}
+
+func addSpan(row []float64, x0, x1, weight float64) {
+	if x1 <= x0 {
+		return
+	}
+	for px := int(math.Floor(x0)); px < int(math.Ceil(x1)) && px < len(row); px++ {
+		if px < 0 {
+			continue
+		}
+		lo := math.Max(x0, float64(px))
+		hi := math.Min(x1, float64(px+1))
+		if hi > lo {
+			row[px] += (hi - lo) * weight
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/steipete/gifgrep/internal/config"
+)
+
+// Providers are the APIs that take a key.
+var Providers = []string{"giphy", "tenor"}
+
+// Backend is where a stored key lives.
+type Backend string
+
+const (
+	// BackendFile keeps the key in the credentials file itself.
+	BackendFile Backend = "file"
+	// BackendPass keeps it in pass(1) under an entry name.
+	BackendPass Backend = "pass"
+	// BackendCommand runs a shell command that prints the key, e.g.
+	// `op read op://dev/giphy/key`. Nothing is stored.
+	BackendCommand Backend = "command"
+	// BackendSecretService keeps it in the desktop keyring via secret-tool.
+	BackendSecretService Backend = "secret-service"
+)
+
+var Backends = []Backend{BackendFile, BackendPass, BackendCommand, BackendSecretService}
+
+// TenorDemoKey is Tenor's public demo key, used when nothing else is set.
+const TenorDemoKey = "LIVDSRZULELA"
+
+var (
+	ErrNoKey       = errors.New("no API key")
+	ErrUnavailable = errors.New("backend not available")
+)
+
+var (
+	lookPath = exec.LookPath
+	runFn    = run
+)
+
+// Path is credentials.toml next to config.toml.
+func Path() string {
+	p := config.Path()
+	if p == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(p), "credentials.toml")
+}
+
+// EnvVar is the variable that holds provider's key.
+func EnvVar(provider string) string {
+	return strings.ToUpper(provider) + "_API_KEY"
+}
+
+// Entry is a provider's table in the credentials file.
+type Entry struct {
+	Backend Backend
+	Key     string
+	Name    string
+	Command string
+}
+
+// Stored returns provider's entry in the credentials file without running
+// its backend.
+func Stored(provider string) (Entry, bool, error) {
+	f, err := config.Load(Path())
+	if err != nil {
+		return Entry{}, false, fmt.Errorf("%s: %w", Path(), err)
+	}
+	v, ok := f.Get(provider)
+	table, isTable := v.(map[string]any)
+	if !ok || !isTable {
+		return Entry{}, false, nil
+	}
+	str := func(k string) string {
+		s, _ := table[k].(string)
+		return s
+	}
+	e := Entry{Backend: Backend(str("backend")), Key: str("key"), Name: str("entry"), Command: str("command")}
+	if e.Backend == "" {
+		e.Backend = BackendFile
+	}
+	return e, true, nil
+}
+
+// Configured reports whether a key is stored or in the environment; it
+// never runs a backend, so it is cheap enough for source auto-selection.
+func Configured(provider string) bool {
+	if strings.TrimSpace(os.Getenv(EnvVar(provider))) != "" {
+		return true
+	}
+	_, ok, _ := Stored(provider)
+	return ok
+}
+
+type lookup struct {
+	key, source string
+	err         error
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = map[string]lookup{}
+)
+
+// Key finds provider's key: the credentials file (and its backend), then
+// the environment, then Tenor's demo key. source says which one won.
+// Backend results are cached for the life of the process.
+func Key(provider string) (key, source string, err error) {
+	e, ok, err := Stored(provider)
+	if err != nil {
+		return "", "", err
+	}
+	if ok {
+		id := Path() + "\x00" + provider + "\x00" + fmt.Sprint(e)
+		cacheMu.Lock()
+		defer cacheMu.Unlock()
+		if l, hit := cache[id]; hit {
+			return l.key, l.source, l.err
+		}
+		key, err := e.fetch(provider)
+		l := lookup{key: key, source: "credentials (" + string(e.Backend) + ")"}
+		if err != nil {
+			l.err = fmt.Errorf("%s key from %s: %w", provider, e.Backend, err)
+		}
+		if e.Backend != BackendFile {
+			cache[id] = l
+		}
+		return l.key, l.source, l.err
+	}
+	if env := strings.TrimSpace(os.Getenv(EnvVar(provider))); env != "" {
+		return env, "env " + EnvVar(provider), nil
+	}
+	if provider == "tenor" {
+		return TenorDemoKey, "demo key", nil
+	}
+	return "", "", fmt.Errorf("%w for %s (set %s or run `gifgrep auth set %s`)", ErrNoKey, provider, EnvVar(provider), provider)
+}
+
+func (e Entry) fetch(provider string) (string, error) {
+	var out string
+	var err error
+	switch e.Backend {
+	case BackendFile:
+		out = e.Key
+	case BackendPass:
+		out, err = runFn(nil, "pass", "show", e.passEntry(provider))
+	case BackendCommand:
+		if e.Command == "" {
+			return "", errors.New("no command set")
+		}
+		out, err = runFn(nil, "sh", "-c", e.Command)
+	case BackendSecretService:
+		if err := secretServiceAvailable(); err != nil {
+			return "", err
+		}
+		out, err = runFn(nil, "secret-tool", "lookup", "service", "gifgrep", "provider", provider)
+	default:
+		return "", fmt.Errorf("unknown backend %q", e.Backend)
+	}
+	if err != nil {
+		return "", err
+	}
+	key, _, _ := strings.Cut(out, "\n")
+	if key = strings.TrimSpace(key); key == "" {
+		return "", ErrNoKey
+	}
+	return key, nil
+}
+
+func (e Entry) passEntry(provider string) string {
+	if e.Name != "" {
+		return e.Name
+	}
+	return "gifgrep/" + provider
+}
+
+func secretServiceAvailable() error {
+	if _, err := lookPath("secret-tool"); err != nil {
+		return fmt.Errorf("%w: secret-tool not found", ErrUnavailable)
+	}
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+		return fmt.Errorf("%w: no D-Bus session", ErrUnavailable)
+	}
+	return nil
+}
+
+// Set stores key for provider in e.Backend and records the choice in the
+// credentials file, which is kept at mode 0600. BackendCommand stores
+// nothing and ignores key.
+func Set(provider string, e Entry, key string) error {
+	if e.Backend == "" {
+		e.Backend = BackendFile
+	}
+	key = strings.TrimSpace(key)
+	if key == "" && e.Backend != BackendCommand {
+		return ErrNoKey
+	}
+	fields := map[string]any{"backend": string(e.Backend)}
+	switch e.Backend {
+	case BackendFile:
+		fields["key"] = key
+	case BackendPass:
+		e.Name = e.passEntry(provider)
+		if _, err := runFn([]byte(key+"\n"), "pass", "insert", "-m", "-f", e.Name); err != nil {
+			return fmt.Errorf("pass insert: %w", err)
+		}
+		fields["entry"] = e.Name
+	case BackendCommand:
+		if strings.TrimSpace(e.Command) == "" {
+			return errors.New("--command is required for the command backend")
+		}
+		fields["command"] = e.Command
+	case BackendSecretService:
+		if err := secretServiceAvailable(); err != nil {
+			return err
+		}
+		if _, err := runFn([]byte(key), "secret-tool", "store", "--label=gifgrep "+provider+" API key", "service", "gifgrep", "provider", provider); err != nil {
+			return fmt.Errorf("secret-tool store: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown backend %q", e.Backend)
+	}
+	return write(provider, fields)
+}
+
+func write(provider string, fields map[string]any) error {
+	path := Path()
+	if path == "" {
+		return errors.New("no config directory")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	_ = f.Close()
+	if err := os.Chmod(path, 0o600); err != nil {
+		return err
+	}
+	for _, k := range []string{"backend", "key", "entry", "command"} {
+		var err error
+		if v, ok := fields[k]; ok {
+			err = config.Set(path, provider+"."+k, v)
+		} else {
+			err = config.Unset(path, provider+"."+k)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func run(stdin []byte, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
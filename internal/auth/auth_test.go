@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type call struct {
+	stdin string
+	argv  []string
+}
+
+func stubRun(t *testing.T, out string, err error) *[]call {
+	t.Helper()
+	prev := runFn
+	t.Cleanup(func() { runFn = prev })
+	var calls []call
+	runFn = func(stdin []byte, name string, args ...string) (string, error) {
+		calls = append(calls, call{stdin: string(stdin), argv: append([]string{name}, args...)})
+		return out, err
+	}
+	return &calls
+}
+
+func isolate(t *testing.T) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("GIPHY_API_KEY", "")
+	t.Setenv("TENOR_API_KEY", "")
+}
+
+func TestKeyOrder(t *testing.T) {
+	isolate(t)
+
+	if key, source, err := Key("tenor"); err != nil || key != TenorDemoKey || source != "demo key" {
+		t.Fatalf("expected demo key, got %q %q %v", key, source, err)
+	}
+	if _, _, err := Key("giphy"); !errors.Is(err, ErrNoKey) {
+		t.Fatalf("expected ErrNoKey, got %v", err)
+	}
+	if Configured("giphy") {
+		t.Fatalf("expected giphy unconfigured")
+	}
+
+	t.Setenv("GIPHY_API_KEY", "from-env")
+	if key, source, _ := Key("giphy"); key != "from-env" || source != "env GIPHY_API_KEY" {
+		t.Fatalf("expected env key, got %q %q", key, source)
+	}
+
+	if err := Set("giphy", Entry{}, " stored\n"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if key, source, _ := Key("giphy"); key != "stored" || source != "credentials (file)" {
+		t.Fatalf("expected stored key to win, got %q %q", key, source)
+	}
+	info, err := os.Stat(Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected 0600, got %v", info.Mode().Perm())
+	}
+}
+
+func TestSetTightensMode(t *testing.T) {
+	isolate(t)
+	if err := os.MkdirAll(filepath.Dir(Path()), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(Path(), []byte("# keys\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Set("tenor", Entry{Backend: BackendFile}, "k"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	info, _ := os.Stat(Path())
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected 0600, got %v", info.Mode().Perm())
+	}
+	data, _ := os.ReadFile(Path())
+	if !strings.HasPrefix(string(data), "# keys\n") {
+		t.Fatalf("expected comment kept:\n%s", data)
+	}
+}
+
+func TestPassBackend(t *testing.T) {
+	isolate(t)
+	calls := stubRun(t, "pass-key\nuser: me\n", nil)
+
+	if err := Set("giphy", Entry{}, "old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Set("giphy", Entry{Backend: BackendPass}, "secret"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	want := "pass insert -m -f gifgrep/giphy"
+	if len(*calls) != 1 || strings.Join((*calls)[0].argv, " ") != want || (*calls)[0].stdin != "secret\n" {
+		t.Fatalf("unexpected calls: %#v", *calls)
+	}
+	data, _ := os.ReadFile(Path())
+	if strings.Contains(string(data), "secret") || strings.Contains(string(data), "old") {
+		t.Fatalf("key left in credentials file:\n%s", data)
+	}
+
+	for i := 0; i < 2; i++ {
+		key, source, err := Key("giphy")
+		if err != nil || key != "pass-key" || source != "credentials (pass)" {
+			t.Fatalf("unexpected lookup: %q %q %v", key, source, err)
+		}
+	}
+	if len(*calls) != 2 || strings.Join((*calls)[1].argv, " ") != "pass show gifgrep/giphy" {
+		t.Fatalf("expected one cached pass show, got %#v", *calls)
+	}
+}
+
+func TestCommandBackend(t *testing.T) {
+	isolate(t)
+	calls := stubRun(t, "", errors.New("exit status 1"))
+
+	if err := Set("giphy", Entry{Backend: BackendCommand}, ""); err == nil {
+		t.Fatalf("expected error without a command")
+	}
+	if err := Set("giphy", Entry{Backend: BackendCommand, Command: "op read op://dev/giphy"}, ""); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if !Configured("giphy") {
+		t.Fatalf("expected giphy configured")
+	}
+	t.Setenv("GIPHY_API_KEY", "from-env")
+	if _, _, err := Key("giphy"); err == nil || !strings.Contains(err.Error(), "giphy key from command") {
+		t.Fatalf("expected command error, got %v", err)
+	}
+	if got := strings.Join((*calls)[0].argv, " "); got != "sh -c op read op://dev/giphy" {
+		t.Fatalf("unexpected command: %q", got)
+	}
+}
+
+func TestSecretServiceUnavailable(t *testing.T) {
+	isolate(t)
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "")
+	stubRun(t, "", nil)
+	if err := Set("giphy", Entry{Backend: BackendSecretService}, "k"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable, got %v", err)
+	}
+	if _, err := os.Stat(Path()); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected no credentials file, got %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package blocks
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/png"
+	"strconv"
+
+	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/internal/termcaps"
+)
+
+// alphaThreshold is the cut-off below which a pixel shows the terminal
+// background.
+const alphaThreshold = 128
+
+// Encode draws img into a cols×rows cell rectangle starting at the cursor.
+// The image is stretched to the rectangle; callers size it to the image's
+// aspect ratio. Rows are joined with relative cursor moves, so the output
+// can be written at any position, and every cell is overwritten.
+func Encode(img image.Image, cols, rows int, style termcaps.TextStyle) []byte {
+	if cols <= 0 || rows <= 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	switch style {
+	case termcaps.TextBraille:
+		encodeBraille(&buf, toNRGBA(gifdecode.Scale(img, cols*2, rows*4)), cols, rows)
+	default:
+		encodeHalfBlocks(&buf, toNRGBA(gifdecode.Scale(img, cols, rows*2)), cols, rows, style)
+	}
+	return buf.Bytes()
+}
+
+// EncodeFrame decodes a PNG frame and encodes it like Encode.
+func EncodeFrame(frame gifdecode.Frame, cols, rows int, style termcaps.TextStyle) ([]byte, error) {
+	img, err := png.Decode(bytes.NewReader(frame.PNG))
+	if err != nil {
+		return nil, err
+	}
+	return Encode(img, cols, rows, style), nil
+}
+
+// encodeHalfBlocks draws two pixels per cell: "▀" with the top pixel as
+// foreground and the bottom one as background.
+func encodeHalfBlocks(buf *bytes.Buffer, img *image.NRGBA, cols, rows int, style termcaps.TextStyle) {
+	for row := 0; row < rows; row++ {
+		if row > 0 {
+			nextRow(buf, cols)
+		}
+		var lastFG, lastBG string
+		top := img.Pix[(row*2)*img.Stride:]
+		bottom := img.Pix[(row*2+1)*img.Stride:]
+		for x := 0; x < cols; x++ {
+			t := top[x*4 : x*4+4]
+			b := bottom[x*4 : x*4+4]
+			tOpaque := t[3] >= alphaThreshold
+			bOpaque := b[3] >= alphaThreshold
+
+			ch := "▀"
+			fg, bg := "39", "49"
+			switch {
+			case tOpaque && bOpaque:
+				fg, bg = sgrColor(t, style, 38), sgrColor(b, style, 48)
+			case tOpaque:
+				fg = sgrColor(t, style, 38)
+			case bOpaque:
+				ch = "▄"
+				fg = sgrColor(b, style, 38)
+			default:
+				ch = " "
+			}
+			if fg != lastFG || bg != lastBG {
+				buf.WriteString("\x1b[" + fg + ";" + bg + "m")
+				lastFG, lastBG = fg, bg
+			}
+			buf.WriteString(ch)
+		}
+		buf.WriteString("\x1b[0m")
+	}
+}
+
+// brailleBits maps a dot at (x, y) in a 2×4 cell to its bit in U+2800.
+var brailleBits = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// encodeBraille draws 2×4 dots per cell in the terminal's foreground color.
+// A dot is set where the pixel is brighter than the image's mean, which
+// keeps shapes visible in both dark and bright GIFs.
+func encodeBraille(buf *bytes.Buffer, img *image.NRGBA, cols, rows int) {
+	w, h := cols*2, rows*4
+	lum := make([]int, w*h)
+	total, count := 0, 0
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := img.Pix[y*img.Stride+x*4:]
+			if p[3] < alphaThreshold {
+				lum[y*w+x] = -1
+				continue
+			}
+			l := (299*int(p[0]) + 587*int(p[1]) + 114*int(p[2])) / 1000
+			lum[y*w+x] = l
+			total += l
+			count++
+		}
+	}
+	mean := 128
+	if count > 0 {
+		mean = total / count
+	}
+	for row := 0; row < rows; row++ {
+		if row > 0 {
+			nextRow(buf, cols)
+		}
+		for col := 0; col < cols; col++ {
+			r := rune(0x2800)
+			for dy := 0; dy < 4; dy++ {
+				for dx := 0; dx < 2; dx++ {
+					if l := lum[(row*4+dy)*w+col*2+dx]; l > mean {
+						r |= brailleBits[dy][dx]
+					}
+				}
+			}
+			buf.WriteRune(r)
+		}
+	}
+}
+
+// nextRow moves the cursor to the start of the rectangle's next row.
+func nextRow(buf *bytes.Buffer, cols int) {
+	buf.WriteString("\x1b[" + strconv.Itoa(cols) + "D\x1b[B")
+}
+
+// sgrColor returns the SGR parameters for p as foreground (base 38) or
+// background (base 48).
+func sgrColor(p []byte, style termcaps.TextStyle, base int) string {
+	prefix := strconv.Itoa(base)
+	if style == termcaps.Text256 {
+		return prefix + ";5;" + strconv.Itoa(xterm256(p[0], p[1], p[2]))
+	}
+	return prefix + ";2;" + strconv.Itoa(int(p[0])) + ";" + strconv.Itoa(int(p[1])) + ";" + strconv.Itoa(int(p[2]))
+}
+
+// cubeLevels are the channel values of the xterm 6×6×6 color cube.
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// xterm256 maps an RGB color to the closest entry of the 6×6×6 cube or the
+// 24-step gray ramp.
+func xterm256(r, g, b uint8) int {
+	ri, gi, bi := cubeIndex(int(r)), cubeIndex(int(g)), cubeIndex(int(b))
+	cube := 16 + 36*ri + 6*gi + bi
+	cubeDist := sq(int(r)-cubeLevels[ri]) + sq(int(g)-cubeLevels[gi]) + sq(int(b)-cubeLevels[bi])
+
+	avg := (int(r) + int(g) + int(b)) / 3
+	grayIdx := (avg - 3) / 10
+	if grayIdx < 0 {
+		grayIdx = 0
+	}
+	if grayIdx > 23 {
+		grayIdx = 23
+	}
+	gray := 8 + 10*grayIdx
+	grayDist := sq(int(r)-gray) + sq(int(g)-gray) + sq(int(b)-gray)
+	if grayDist < cubeDist {
+		return 232 + grayIdx
+	}
+	return cube
+}
+
+func cubeIndex(v int) int {
+	if v < 48 {
+		return 0
+	}
+	if v < 115 {
+		return 1
+	}
+	return (v - 35) / 40
+}
+
+func sq(v int) int {
+	return v * v
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(out, out.Rect, img, b.Min, draw.Src)
+	return out
+}
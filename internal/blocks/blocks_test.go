@@ -0,0 +1,85 @@
+package blocks
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/internal/termcaps"
+)
+
+// splitImage is red on top, blue at the bottom: one row of half-blocks.
+func splitImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	img.Set(1, 0, color.NRGBA{R: 255, A: 255})
+	img.Set(0, 1, color.NRGBA{B: 255, A: 255})
+	img.Set(1, 1, color.NRGBA{B: 255, A: 255})
+	return img
+}
+
+func TestEncodeTruecolorHalfBlocks(t *testing.T) {
+	got := string(Encode(splitImage(), 2, 1, termcaps.TextTruecolor))
+	want := "\x1b[38;2;255;0;0;48;2;0;0;255m▀▀\x1b[0m"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestEncode256Color(t *testing.T) {
+	got := string(Encode(splitImage(), 2, 1, termcaps.Text256))
+	if !strings.HasPrefix(got, "\x1b[38;5;196;48;5;21m▀▀") {
+		t.Fatalf("unexpected 256-color output: %q", got)
+	}
+	if xterm256(128, 128, 128) != 244 {
+		t.Fatalf("expected gray ramp for mid gray, got %d", xterm256(128, 128, 128))
+	}
+}
+
+func TestEncodeTransparentAndRows(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 4))
+	img.Set(0, 1, color.NRGBA{G: 255, A: 255})
+	got := string(Encode(img, 2, 2, termcaps.TextTruecolor))
+	if !strings.Contains(got, "▄") {
+		t.Fatalf("expected lower half-block for transparent top: %q", got)
+	}
+	if !strings.Contains(got, "\x1b[2D\x1b[B") {
+		t.Fatalf("expected relative move to the next row: %q", got)
+	}
+	if strings.Count(got, " ") != 3 {
+		t.Fatalf("expected 3 blank cells: %q", got)
+	}
+}
+
+func TestEncodeBraille(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 4))
+	for y := 0; y < 4; y++ {
+		img.Set(0, y, color.NRGBA{A: 255})
+		img.Set(1, y, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+	}
+	got := string(Encode(img, 1, 1, termcaps.TextBraille))
+	if got != "⢸" {
+		t.Fatalf("expected right column of dots, got %q", got)
+	}
+}
+
+func TestEncodeFrame(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, splitImage()); err != nil {
+		t.Fatalf("png encode failed: %v", err)
+	}
+	data, err := EncodeFrame(gifdecode.Frame{PNG: buf.Bytes()}, 4, 2, termcaps.TextTruecolor)
+	if err != nil {
+		t.Fatalf("encode frame failed: %v", err)
+	}
+	if strings.Count(string(data), "\x1b[0m") != 2 {
+		t.Fatalf("expected two rows: %q", data)
+	}
+	if _, err := EncodeFrame(gifdecode.Frame{PNG: []byte("nope")}, 1, 1, termcaps.TextTruecolor); err == nil {
+		t.Fatalf("expected decode error")
+	}
+}
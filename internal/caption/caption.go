@@ -0,0 +1,299 @@
+package caption
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+
+	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/gifencode"
+)
+
+var (
+	ErrNoText   = errors.New("no caption text")
+	ErrNoFrames = errors.New("no frames")
+)
+
+const minSize = 8
+
+type Options struct {
+	Top    string
+	Middle string
+	Bottom string
+	Size   int // font size in px; 0 = fit to the image
+	Fill   color.NRGBA
+	Stroke color.NRGBA
+}
+
+// withDefaults fills unset colors: white text with a black outline.
+func (o Options) withDefaults() Options {
+	if o.Fill == (color.NRGBA{}) {
+		o.Fill = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	}
+	if o.Stroke == (color.NRGBA{}) {
+		o.Stroke = color.NRGBA{A: 255}
+	}
+	return o
+}
+
+func (o Options) empty() bool {
+	return strings.TrimSpace(o.Top) == "" && strings.TrimSpace(o.Middle) == "" && strings.TrimSpace(o.Bottom) == ""
+}
+
+// Render draws the caption onto every frame. The overlay is laid out once
+// and composited per frame, so long GIFs stay cheap.
+func Render(frames []gifencode.Frame, opts Options) ([]gifencode.Frame, error) {
+	if len(frames) == 0 {
+		return nil, ErrNoFrames
+	}
+	b := frames[0].Image.Bounds()
+	overlay, err := Overlay(b.Dx(), b.Dy(), opts)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]gifencode.Frame, 0, len(frames))
+	for _, f := range frames {
+		out = append(out, gifencode.Frame{Image: Apply(f.Image, overlay), Delay: f.Delay})
+	}
+	return out, nil
+}
+
+// Apply returns a copy of img with overlay drawn on top.
+func Apply(img image.Image, overlay *image.NRGBA) *image.NRGBA {
+	b := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Rect, img, b.Min, draw.Src)
+	draw.Draw(dst, dst.Rect, overlay, image.Point{}, draw.Over)
+	return dst
+}
+
+// Overlay renders the caption text for a width×height image onto a
+// transparent canvas.
+func Overlay(width, height int, opts Options) (*image.NRGBA, error) {
+	if opts.empty() {
+		return nil, ErrNoText
+	}
+	opts = opts.withDefaults()
+	f, err := loadFont()
+	if err != nil {
+		return nil, err
+	}
+	size := opts.Size
+	if size <= 0 {
+		size = fitSize(f, width, height, opts)
+	}
+	fc := newFace(f, size)
+	stroke := strokeWidth(size)
+	margin := maxInt(2, height/40) + stroke
+	maxW := float64(width - 2*margin)
+
+	fill := image.NewAlpha(image.Rect(0, 0, width, height))
+	place := func(text string, pos int) {
+		lines := fc.wrap(text, maxW)
+		if len(lines) == 0 {
+			return
+		}
+		block := blockMask(fc, lines, width)
+		ink := inkBounds(block)
+		if ink.Empty() {
+			return
+		}
+		var y int
+		switch pos {
+		case posTop:
+			y = margin - ink.Min.Y
+		case posMiddle:
+			y = (height-ink.Dy())/2 - ink.Min.Y
+		default:
+			y = height - margin - ink.Max.Y
+		}
+		draw.Draw(fill, block.Rect.Add(image.Pt(0, y)), block, image.Point{}, draw.Over)
+	}
+	place(opts.Top, posTop)
+	place(opts.Middle, posMiddle)
+	place(opts.Bottom, posBottom)
+
+	outline := dilate(fill, stroke)
+	return composite(fill, outline, opts.Fill, opts.Stroke), nil
+}
+
+const (
+	posTop = iota
+	posMiddle
+	posBottom
+)
+
+// fitSize starts at 1/8 of the image height and shrinks until every word fits
+// on a line and no block takes more than a third of the height.
+func fitSize(f *font, width, height int, opts Options) int {
+	size := maxInt(minSize, height/8)
+	for ; size > minSize; size-- {
+		fc := newFace(f, size)
+		margin := maxInt(2, height/40) + strokeWidth(size)
+		maxW := float64(width - 2*margin)
+		if fits(fc, opts.Top, maxW, height) && fits(fc, opts.Middle, maxW, height) && fits(fc, opts.Bottom, maxW, height) {
+			break
+		}
+	}
+	return size
+}
+
+func fits(fc *face, text string, maxW float64, height int) bool {
+	for _, word := range strings.Fields(text) {
+		if fc.measure(word) > maxW {
+			return false
+		}
+	}
+	lines := fc.wrap(text, maxW)
+	return float64(len(lines))*fc.lineHeight() <= float64(height)/3
+}
+
+func strokeWidth(size int) int {
+	return maxInt(1, int(math.Round(float64(size)/16)))
+}
+
+// wrap breaks text into lines no wider than maxW. Explicit newlines are kept
+// and words longer than a line are split by character.
+func (fc *face) wrap(text string, maxW float64) []string {
+	var lines []string
+	for _, para := range strings.Split(text, "\n") {
+		var line string
+		for _, word := range strings.Fields(para) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if fc.measure(candidate) <= maxW {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			for fc.measure(word) > maxW && len([]rune(word)) > 1 {
+				runes := []rune(word)
+				n := 1
+				for n < len(runes) && fc.measure(string(runes[:n+1])) <= maxW {
+					n++
+				}
+				lines = append(lines, string(runes[:n]))
+				word = string(runes[n:])
+			}
+			line = word
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// blockMask draws centered lines into a mask whose top is the first line's
+// ascent box.
+func blockMask(fc *face, lines []string, width int) *image.Alpha {
+	lh := fc.lineHeight()
+	h := int(math.Ceil(lh * float64(len(lines))))
+	m := image.NewAlpha(image.Rect(0, 0, width, h))
+	for i, line := range lines {
+		x := (float64(width) - fc.measure(line)) / 2
+		fc.drawLine(m, line, x, fc.ascent()+lh*float64(i))
+	}
+	return m
+}
+
+func inkBounds(m *image.Alpha) image.Rectangle {
+	r := image.Rectangle{}
+	for y := m.Rect.Min.Y; y < m.Rect.Max.Y; y++ {
+		for x := m.Rect.Min.X; x < m.Rect.Max.X; x++ {
+			if m.Pix[m.PixOffset(x, y)] == 0 {
+				continue
+			}
+			r = r.Union(image.Rect(x, y, x+1, y+1))
+		}
+	}
+	return r
+}
+
+// dilate grows the mask by a disc of the given radius, giving the outline.
+func dilate(m *image.Alpha, radius int) *image.Alpha {
+	out := image.NewAlpha(m.Rect)
+	ink := inkBounds(m)
+	if ink.Empty() {
+		return out
+	}
+	type offset struct{ dx, dy int }
+	var disc []offset
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			if dx*dx+dy*dy <= radius*radius+radius {
+				disc = append(disc, offset{dx, dy})
+			}
+		}
+	}
+	area := ink.Inset(-radius).Intersect(m.Rect)
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			var best uint8
+			for _, o := range disc {
+				p := image.Pt(x+o.dx, y+o.dy)
+				if !p.In(m.Rect) {
+					continue
+				}
+				if a := m.Pix[m.PixOffset(p.X, p.Y)]; a > best {
+					best = a
+					if best == 255 {
+						break
+					}
+				}
+			}
+			out.Pix[out.PixOffset(x, y)] = best
+		}
+	}
+	return out
+}
+
+// composite paints fill over stroke using the two coverage masks.
+func composite(fillMask, strokeMask *image.Alpha, fill, stroke color.NRGBA) *image.NRGBA {
+	out := image.NewNRGBA(fillMask.Rect)
+	for i := range fillMask.Pix {
+		af := float64(fillMask.Pix[i]) / 255 * float64(fill.A) / 255
+		as := float64(strokeMask.Pix[i]) / 255 * float64(stroke.A) / 255
+		a := af + as*(1-af)
+		if a == 0 {
+			continue
+		}
+		mix := func(f, s uint8) uint8 {
+			return uint8(math.Round((float64(f)*af + float64(s)*as*(1-af)) / a))
+		}
+		p := i * 4
+		out.Pix[p] = mix(fill.R, stroke.R)
+		out.Pix[p+1] = mix(fill.G, stroke.G)
+		out.Pix[p+2] = mix(fill.B, stroke.B)
+		out.Pix[p+3] = uint8(math.Round(a * 255))
+	}
+	return out
+}
+
+// GIF decodes an animated GIF, captions every frame and encodes it again,
+// keeping the loop count.
+func GIF(data []byte, opts Options) ([]byte, error) {
+	decoded, err := gifdecode.Decode(data, gifencode.DecodeOptions())
+	if err != nil {
+		return nil, err
+	}
+	frames, err := gifencode.FromDecoded(decoded)
+	if err != nil {
+		return nil, err
+	}
+	frames, err = Render(frames, opts)
+	if err != nil {
+		return nil, err
+	}
+	encOpts := gifencode.DefaultOptions()
+	encOpts.LoopCount = decoded.LoopCount
+	return gifencode.EncodeBytes(frames, encOpts)
+}
@@ -0,0 +1,166 @@
+package caption
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/gifencode"
+	"github.com/steipete/gifgrep/internal/testutil"
+)
+
+func TestOverlayPlacesTopAndBottom(t *testing.T) {
+	overlay, err := Overlay(200, 200, Options{Top: "TOP", Bottom: "BOTTOM"})
+	if err != nil {
+		t.Fatalf("overlay failed: %v", err)
+	}
+	ink := alphaBounds(overlay)
+	if ink.Empty() {
+		t.Fatalf("expected visible text")
+	}
+	if ink.Min.Y > 20 || ink.Max.Y < 180 {
+		t.Fatalf("expected text near both edges, got %v", ink)
+	}
+	if rowHasInk(overlay, 100) {
+		t.Fatalf("expected empty middle row")
+	}
+}
+
+func TestOverlayHasOutline(t *testing.T) {
+	overlay, err := Overlay(120, 80, Options{Middle: "I", Size: 40})
+	if err != nil {
+		t.Fatalf("overlay failed: %v", err)
+	}
+	var white, black bool
+	for y := 0; y < 80; y++ {
+		for x := 0; x < 120; x++ {
+			c := overlay.NRGBAAt(x, y)
+			if c.A == 255 && c.R == 255 {
+				white = true
+			}
+			if c.A == 255 && c.R == 0 {
+				black = true
+			}
+		}
+	}
+	if !white || !black {
+		t.Fatalf("expected white fill and black outline (white=%v black=%v)", white, black)
+	}
+}
+
+func TestWrapSplitsLongText(t *testing.T) {
+	f, err := loadFont()
+	if err != nil {
+		t.Fatalf("load font: %v", err)
+	}
+	fc := newFace(f, 20)
+	lines := fc.wrap("one two three four five six", fc.measure("one two three"))
+	if len(lines) != 2 || lines[0] != "one two three" {
+		t.Fatalf("unexpected lines %q", lines)
+	}
+	lines = fc.wrap("abcdefghij", fc.measure("abcd"))
+	if len(lines) < 3 || lines[0] != "abcd" {
+		t.Fatalf("expected long word to break, got %q", lines)
+	}
+	if lines := fc.wrap("a\nb", 1000); len(lines) != 2 {
+		t.Fatalf("expected explicit newline to break, got %q", lines)
+	}
+}
+
+func TestFitSizeShrinksForLongWords(t *testing.T) {
+	f, err := loadFont()
+	if err != nil {
+		t.Fatalf("load font: %v", err)
+	}
+	short := fitSize(f, 100, 200, Options{Top: "HI"})
+	long := fitSize(f, 100, 200, Options{Top: "SUPERCALIFRAGILISTIC"})
+	if short != 25 || long >= short {
+		t.Fatalf("unexpected sizes short=%d long=%d", short, long)
+	}
+}
+
+func TestRenderKeepsFramesAndDelays(t *testing.T) {
+	frames := []gifencode.Frame{
+		{Image: image.NewNRGBA(image.Rect(0, 0, 80, 60)), Delay: 70 * time.Millisecond},
+		{Image: image.NewNRGBA(image.Rect(0, 0, 80, 60)), Delay: 30 * time.Millisecond},
+	}
+	out, err := Render(frames, Options{Bottom: "hello"})
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if len(out) != 2 || out[0].Delay != 70*time.Millisecond || out[1].Delay != 30*time.Millisecond {
+		t.Fatalf("unexpected frames: %+v", out)
+	}
+	captioned, ok := out[1].Image.(*image.NRGBA)
+	if !ok || alphaBounds(captioned).Empty() {
+		t.Fatalf("expected caption on every frame")
+	}
+	src, ok := frames[0].Image.(*image.NRGBA)
+	if !ok || src.NRGBAAt(40, 50) != (color.NRGBA{}) {
+		t.Fatalf("expected input frames untouched")
+	}
+}
+
+func TestCaptionErrors(t *testing.T) {
+	if _, err := Render(nil, Options{Top: "x"}); !errors.Is(err, ErrNoFrames) {
+		t.Fatalf("expected ErrNoFrames, got %v", err)
+	}
+	if _, err := Overlay(10, 10, Options{Top: "  "}); !errors.Is(err, ErrNoText) {
+		t.Fatalf("expected ErrNoText, got %v", err)
+	}
+}
+
+func TestGIFRoundTrip(t *testing.T) {
+	data, err := GIF(testutil.MakeTestGIF(), Options{Top: "x"})
+	if err != nil {
+		t.Fatalf("caption gif failed: %v", err)
+	}
+	decoded, err := gifdecode.Decode(data, gifdecode.DefaultOptions())
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if len(decoded.Frames) != 2 {
+		t.Fatalf("expected 2 frames, got %d", len(decoded.Frames))
+	}
+}
+
+func TestGIFKeepsEveryFrame(t *testing.T) {
+	data, err := GIF(testutil.MakeLongGIF(75), Options{Top: "x"})
+	if err != nil {
+		t.Fatalf("caption gif failed: %v", err)
+	}
+	opts := gifdecode.DefaultOptions()
+	opts.MaxFrames = gifdecode.NoFrameLimit
+	decoded, err := gifdecode.Decode(data, opts)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if len(decoded.Frames) != 75 {
+		t.Fatalf("expected 75 frames, got %d", len(decoded.Frames))
+	}
+}
+
+func alphaBounds(img *image.NRGBA) image.Rectangle {
+	r := image.Rectangle{}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if img.NRGBAAt(x, y).A > 0 {
+				r = r.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return r
+}
+
+func rowHasInk(img *image.NRGBA, y int) bool {
+	for x := 0; x < img.Bounds().Dx(); x++ {
+		if img.NRGBAAt(x, y).A > 0 {
+			return true
+		}
+	}
+	return false
+}
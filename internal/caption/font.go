@@ -0,0 +1,162 @@
+package caption
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+	"sync"
+
+	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/internal/assets"
+)
+
+type glyph struct {
+	X       int `json:"x"`
+	Y       int `json:"y"`
+	W       int `json:"w"`
+	H       int `json:"h"`
+	Left    int `json:"left"`
+	Top     int `json:"top"`
+	Advance int `json:"advance"`
+}
+
+type font struct {
+	Em      int              `json:"em"`
+	Ascent  int              `json:"ascent"`
+	Descent int              `json:"descent"`
+	Glyphs  map[string]glyph `json:"glyphs"`
+
+	atlas  *image.Gray
+	glyphs map[rune]glyph
+}
+
+var loadFont = sync.OnceValues(func() (*font, error) {
+	var f font
+	if err := json.Unmarshal(assets.CaptionFontJSON(), &f); err != nil {
+		return nil, fmt.Errorf("caption font metrics: %w", err)
+	}
+	img, err := png.Decode(bytes.NewReader(assets.CaptionFontPNG()))
+	if err != nil {
+		return nil, fmt.Errorf("caption font atlas: %w", err)
+	}
+	atlas, ok := img.(*image.Gray)
+	if !ok {
+		atlas = image.NewGray(img.Bounds())
+		draw.Draw(atlas, atlas.Rect, img, img.Bounds().Min, draw.Src)
+	}
+	f.atlas = atlas
+	f.glyphs = make(map[rune]glyph, len(f.Glyphs))
+	for k, g := range f.Glyphs {
+		code, err := strconv.Atoi(k)
+		if err != nil {
+			return nil, fmt.Errorf("caption font metrics: bad glyph key %q", k)
+		}
+		f.glyphs[rune(code)] = g
+	}
+	return &f, nil
+})
+
+// face is the font at one pixel size, with scaled glyph masks cached.
+type face struct {
+	font  *font
+	size  int
+	scale float64
+	cache map[rune]*image.Alpha
+}
+
+func newFace(f *font, size int) *face {
+	return &face{font: f, size: size, scale: float64(size) / float64(f.Em), cache: map[rune]*image.Alpha{}}
+}
+
+func (fc *face) glyph(r rune) glyph {
+	if g, ok := fc.font.glyphs[r]; ok {
+		return g
+	}
+	return fc.font.glyphs['?']
+}
+
+func (fc *face) advance(r rune) float64 {
+	return float64(fc.glyph(r).Advance) * fc.scale
+}
+
+func (fc *face) measure(s string) float64 {
+	w := 0.0
+	for _, r := range s {
+		w += fc.advance(r)
+	}
+	return w
+}
+
+func (fc *face) lineHeight() float64 {
+	return float64(fc.font.Ascent+fc.font.Descent) * fc.scale
+}
+
+func (fc *face) ascent() float64 {
+	return float64(fc.font.Ascent) * fc.scale
+}
+
+func (fc *face) mask(r rune) *image.Alpha {
+	if m, ok := fc.cache[r]; ok {
+		return m
+	}
+	g := fc.glyph(r)
+	var m *image.Alpha
+	if g.W == 0 || g.H == 0 {
+		m = image.NewAlpha(image.Rect(0, 0, 0, 0))
+	} else {
+		w := maxInt(1, int(math.Round(float64(g.W)*fc.scale)))
+		h := maxInt(1, int(math.Round(float64(g.H)*fc.scale)))
+		src := fc.font.atlas.SubImage(image.Rect(g.X, g.Y, g.X+g.W, g.Y+g.H))
+		scaled := gifdecode.Scale(src, w, h)
+		m = image.NewAlpha(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				cr, _, _, _ := scaled.At(x, y).RGBA()
+				m.Pix[y*m.Stride+x] = uint8(cr >> 8)
+			}
+		}
+	}
+	fc.cache[r] = m
+	return m
+}
+
+// drawLine adds the glyph coverage of s to dst, with the pen starting at x on
+// the given baseline.
+func (fc *face) drawLine(dst *image.Alpha, s string, x, baseline float64) {
+	for _, r := range s {
+		g := fc.glyph(r)
+		m := fc.mask(r)
+		ox := int(math.Round(x + float64(g.Left)*fc.scale))
+		oy := int(math.Round(baseline - float64(g.Top)*fc.scale))
+		for y := 0; y < m.Rect.Dy(); y++ {
+			dy := oy + y
+			if dy < dst.Rect.Min.Y || dy >= dst.Rect.Max.Y {
+				continue
+			}
+			for gx := 0; gx < m.Rect.Dx(); gx++ {
+				dx := ox + gx
+				if dx < dst.Rect.Min.X || dx >= dst.Rect.Max.X {
+					continue
+				}
+				a := m.Pix[y*m.Stride+gx]
+				i := dst.PixOffset(dx, dy)
+				if a > dst.Pix[i] {
+					dst.Pix[i] = a
+				}
+			}
+		}
+		x += fc.advance(r)
+	}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -1,39 +1,157 @@
 package clipboard
 
 import (
+	"bytes"
+	"encoding/hex"
 	"errors"
 	"io"
+	"os"
 	"os/exec"
 	"runtime"
+	"strings"
 )
 
 var lookPath = exec.LookPath
 
+var ErrNoText = errors.New("nothing to copy as text")
+
+var ErrTextOnly = errors.New("the terminal clipboard (OSC 52) only takes text")
+
+// CopyFile copies the GIF at path with the system tool.
 func CopyFile(path string) error {
 	if path == "" {
 		return errors.New("empty path")
 	}
-	name, args, err := copyCommand(runtime.GOOS, path)
+	return runCommand(systemCommand(runtime.GOOS, Payload{{MIME: "image/gif", Path: path}}))
+}
+
+// CopyText puts text on the clipboard; see Copy.
+func CopyText(text string, tty io.Writer) error {
+	return Copy(Payload{{MIME: "text/plain", Data: []byte(text)}}, tty)
+}
+
+// Copy puts p on the clipboard. macOS gets every item; xclip and wl-copy
+// hold a single type, so elsewhere the first (richest) item is copied.
+// OSC 52 only carries the first text item and is written to tty, or to
+// /dev/tty when tty is nil. A failing system tool (xclip without a
+// display) falls back to OSC 52. When the richest item is an image, OSC 52
+// would drop it, so Copy returns ErrTextOnly instead.
+func Copy(p Payload, tty io.Writer) error {
+	if len(p) == 0 {
+		return errors.New("empty payload")
+	}
+	if DetectBackend(os.Getenv) == BackendSystem {
+		err := runCommand(systemCommand(runtime.GOOS, p))
+		if err == nil || !isText(p[0]) {
+			return err
+		}
+	}
+	if !isText(p[0]) {
+		return ErrTextOnly
+	}
+	text, ok := p.Text()
+	if !ok {
+		return ErrNoText
+	}
+	return writeOSC52TTY(tty, text)
+}
+
+// Tool names the program Copy runs for images, or why there is none.
+func Tool() (string, error) {
+	c, err := systemCommand(runtime.GOOS, Payload{{MIME: "image/gif", Path: "x.gif"}})
+	return c.name, err
+}
+
+type command struct {
+	name      string
+	args      []string
+	stdin     []byte
+	stdinPath string
+}
+
+func runCommand(c command, err error) error {
 	if err != nil {
 		return err
 	}
-	cmd := exec.Command(name, args...)
+	cmd := exec.Command(c.name, c.args...)
+	switch {
+	case c.stdinPath != "":
+		f, err := os.Open(c.stdinPath)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		cmd.Stdin = f
+	case c.stdin != nil:
+		cmd.Stdin = bytes.NewReader(c.stdin)
+	}
 	cmd.Stdout = io.Discard
 	cmd.Stderr = io.Discard
 	return cmd.Run()
 }
 
-func copyCommand(goos string, path string) (string, []string, error) {
+// systemCommand builds the copy command for p. macOS gets one AppleScript
+// record holding every item; xclip and wl-copy serve a single type, so
+// elsewhere it gets the first item.
+func systemCommand(goos string, p Payload) (command, error) {
+	if len(p) == 0 {
+		return command{}, errors.New("empty payload")
+	}
 	switch goos {
 	case "darwin":
-		return "osascript", []string{"-e", `set the clipboard to (POSIX file "` + path + `")`}, nil
+		if len(p) == 1 && p[0].MIME == "text/plain" {
+			return command{name: "pbcopy", stdin: p[0].Data}, nil
+		}
+		return command{name: "osascript", args: []string{"-e", "set the clipboard to " + appleScriptRecord(p)}}, nil
 	default:
+		item := p[0]
+		c := command{stdin: item.Data, stdinPath: item.Path}
+		if c.stdin == nil && c.stdinPath == "" {
+			c.stdin = []byte{}
+		}
 		if _, err := lookPath("xclip"); err == nil {
-			return "xclip", []string{"-selection", "clipboard", "-t", "image/gif", "-i", path}, nil
+			c.name, c.args = "xclip", []string{"-selection", "clipboard", "-t", item.MIME}
+			if item.Path != "" {
+				c.args = append(c.args, "-i", item.Path)
+				c.stdinPath = ""
+			}
+			return c, nil
 		}
 		if _, err := lookPath("wl-copy"); err == nil {
-			return "sh", []string{"-c", "wl-copy --type image/gif < " + path}, nil
+			c.name, c.args = "wl-copy", []string{"--type", item.MIME}
+			return c, nil
 		}
-		return "", nil, errors.New("no clipboard tool found (need xclip or wl-copy)")
+		return command{}, errors.New("no clipboard tool found (need xclip or wl-copy)")
 	}
 }
+
+// appleScriptRecord maps items to pasteboard classes: files by reference
+// (what Finder, Mail and chat apps paste as an attachment), HTML as raw
+// data and plain text as a string.
+func appleScriptRecord(p Payload) string {
+	var fields []string
+	seen := map[string]bool{}
+	add := func(key, value string) {
+		if !seen[key] {
+			seen[key] = true
+			fields = append(fields, key+":"+value)
+		}
+	}
+	for _, item := range p {
+		switch {
+		case item.Path != "":
+			add("«class furl»", "(POSIX file "+appleScriptString(item.Path)+")")
+		case item.MIME == "text/html":
+			add("«class HTML»", "«data HTML"+strings.ToUpper(hex.EncodeToString(item.Data))+"»")
+		case item.MIME == "text/plain":
+			add("string", appleScriptString(string(item.Data)))
+		}
+	}
+	return "{" + strings.Join(fields, ", ") + "}"
+}
+
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
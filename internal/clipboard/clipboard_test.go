@@ -1,7 +1,9 @@
 package clipboard
 
 import (
+	"bytes"
 	"errors"
+	"strings"
 	"testing"
 )
 
@@ -12,15 +14,41 @@ func TestCopyFileEmptyPath(t *testing.T) {
 }
 
 func TestCopyCommandDarwin(t *testing.T) {
-	cmd, args, err := copyCommand("darwin", "/tmp/a.gif")
+	c, err := systemCommand("darwin", Payload{{MIME: "image/gif", Path: "/tmp/a.gif"}})
 	if err != nil {
 		t.Fatalf("unexpected err: %v", err)
 	}
-	if cmd != "osascript" {
-		t.Fatalf("expected osascript, got %q", cmd)
+	if c.name != "osascript" {
+		t.Fatalf("expected osascript, got %q", c.name)
 	}
-	if len(args) != 2 || args[0] != "-e" {
-		t.Fatalf("unexpected args: %#v", args)
+	if len(c.args) != 2 || c.args[0] != "-e" {
+		t.Fatalf("unexpected args: %#v", c.args)
+	}
+	if c.args[1] != `set the clipboard to {«class furl»:(POSIX file "/tmp/a.gif")}` {
+		t.Fatalf("unexpected script: %q", c.args[1])
+	}
+}
+
+func TestCopyCommandDarwinRecord(t *testing.T) {
+	p, err := NewPayload(FormatHTML, Source{URL: `https://example.test/a "b".gif`, Title: "a"})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	c, err := systemCommand("darwin", p)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	script := c.args[1]
+	if !strings.Contains(script, "«class HTML»:«data HTML3C696D67") {
+		t.Fatalf("expected hex HTML data, got %q", script)
+	}
+	if !strings.Contains(script, `string:"https://example.test/a \"b\".gif"`) {
+		t.Fatalf("expected escaped string, got %q", script)
+	}
+
+	c, err = systemCommand("darwin", Payload{{MIME: "text/plain", Data: []byte("hi")}})
+	if err != nil || c.name != "pbcopy" || string(c.stdin) != "hi" {
+		t.Fatalf("expected pbcopy for plain text, got %#v (%v)", c, err)
 	}
 }
 
@@ -34,15 +62,20 @@ func TestCopyCommandLinuxXclip(t *testing.T) {
 	}
 	t.Cleanup(func() { lookPath = prev })
 
-	cmd, args, err := copyCommand("linux", "/tmp/a.gif")
+	c, err := systemCommand("linux", Payload{{MIME: "image/gif", Path: "/tmp/a.gif"}})
 	if err != nil {
 		t.Fatalf("unexpected err: %v", err)
 	}
-	if cmd != "xclip" {
-		t.Fatalf("expected xclip, got %q", cmd)
+	if c.name != "xclip" {
+		t.Fatalf("expected xclip, got %q", c.name)
 	}
-	if len(args) != 6 || args[4] != "-i" {
-		t.Fatalf("unexpected args: %#v", args)
+	if len(c.args) != 6 || c.args[3] != "image/gif" || c.args[4] != "-i" {
+		t.Fatalf("unexpected args: %#v", c.args)
+	}
+
+	c, err = systemCommand("linux", Payload{{MIME: "text/html", Data: []byte("<img>")}})
+	if err != nil || len(c.args) != 4 || c.args[3] != "text/html" || string(c.stdin) != "<img>" {
+		t.Fatalf("expected html target via stdin, got %#v (%v)", c, err)
 	}
 }
 
@@ -56,15 +89,18 @@ func TestCopyCommandLinuxWlCopy(t *testing.T) {
 	}
 	t.Cleanup(func() { lookPath = prev })
 
-	cmd, args, err := copyCommand("linux", "/tmp/a.gif")
+	c, err := systemCommand("linux", Payload{{MIME: "image/gif", Path: "/tmp/my cat.gif"}})
 	if err != nil {
 		t.Fatalf("unexpected err: %v", err)
 	}
-	if cmd != "sh" {
-		t.Fatalf("expected sh, got %q", cmd)
+	if c.name != "wl-copy" {
+		t.Fatalf("expected wl-copy, got %q", c.name)
 	}
-	if len(args) != 2 || args[0] != "-c" {
-		t.Fatalf("unexpected args: %#v", args)
+	if len(c.args) != 2 || c.args[0] != "--type" || c.args[1] != "image/gif" {
+		t.Fatalf("unexpected args: %#v", c.args)
+	}
+	if c.stdinPath != "/tmp/my cat.gif" {
+		t.Fatalf("expected file on stdin, got %q", c.stdinPath)
 	}
 }
 
@@ -73,8 +109,23 @@ func TestCopyCommandLinuxNoTool(t *testing.T) {
 	lookPath = func(string) (string, error) { return "", errors.New("not found") }
 	t.Cleanup(func() { lookPath = prev })
 
-	_, _, err := copyCommand("linux", "/tmp/a.gif")
+	_, err := systemCommand("linux", Payload{{MIME: "image/gif", Path: "/tmp/a.gif"}})
 	if err == nil {
 		t.Fatal("expected error when no tool available")
 	}
 }
+
+func TestCopyRefusesImagesOverOSC52(t *testing.T) {
+	t.Setenv("GIFGREP_CLIPBOARD", "osc52")
+	t.Setenv("TMUX", "")
+	t.Setenv("STY", "")
+	p, _ := NewPayload(FormatGIF, Source{URL: "https://example.test/a.gif", Path: "/tmp/a.gif"})
+	var tty bytes.Buffer
+	if err := Copy(p, &tty); err != ErrTextOnly || tty.Len() != 0 {
+		t.Fatalf("expected ErrTextOnly and nothing written, got %v %q", err, tty.String())
+	}
+	p, _ = NewPayload(FormatHTML, Source{URL: "https://example.test/a.gif"})
+	if err := Copy(p, &tty); err != nil || !strings.Contains(tty.String(), "]52;c;") {
+		t.Fatalf("expected HTML over OSC 52, got %v %q", err, tty.String())
+	}
+}
@@ -0,0 +1,143 @@
+package clipboard
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"mime"
+	"path/filepath"
+	"strings"
+)
+
+// Item is one representation of the clipboard content. Path, when set,
+// names a file with the data; it is read instead of Data.
+type Item struct {
+	MIME string
+	Data []byte
+	Path string
+}
+
+// Payload offers the same content in several types, richest first, so the
+// pasting app can pick the best one it understands.
+type Payload []Item
+
+// Text returns the first text item, which is what OSC 52 carries.
+func (p Payload) Text() (string, bool) {
+	for _, item := range p {
+		if strings.HasPrefix(item.MIME, "text/") && item.MIME != "text/uri-list" && item.Data != nil {
+			return string(item.Data), true
+		}
+	}
+	return "", false
+}
+
+// isText reports whether item is text OSC 52 can carry in its place.
+func isText(item Item) bool {
+	return item.Path == "" && strings.HasPrefix(item.MIME, "text/")
+}
+
+// Format is what a GIF is copied as.
+type Format string
+
+const (
+	FormatURL      Format = "url"
+	FormatMarkdown Format = "md"
+	FormatHTML     Format = "html"
+	FormatGIF      Format = "gif"
+	FormatDataURI  Format = "data"
+)
+
+var Formats = []Format{FormatURL, FormatMarkdown, FormatHTML, FormatGIF, FormatDataURI}
+
+var ErrNoFile = errors.New("GIF not available")
+
+// Source is the GIF being copied. FormatGIF needs Path, FormatDataURI
+// needs Data.
+type Source struct {
+	URL   string
+	Title string
+	Path  string
+	Data  []byte
+}
+
+// NewPayload builds the items for f. Text formats also offer the URL as
+// plain text and text/uri-list; the image format adds the URL and an
+// <img> tag for apps that won't take files.
+func NewPayload(f Format, src Source) (Payload, error) {
+	urlItems := Payload{
+		{MIME: "text/plain", Data: []byte(src.URL)},
+		{MIME: "text/uri-list", Data: []byte(src.URL + "\r\n")},
+	}
+	switch f {
+	case FormatURL:
+		return urlItems, nil
+	case FormatMarkdown:
+		return Payload{{MIME: "text/plain", Data: []byte(Markdown(src.Title, src.URL))}}, nil
+	case FormatHTML:
+		return append(Payload{{MIME: "text/html", Data: []byte(HTML(src.Title, src.URL))}}, urlItems...), nil
+	case FormatGIF:
+		if src.Path == "" {
+			return nil, ErrNoFile
+		}
+		image := Item{MIME: imageMIME(src.Path), Path: src.Path}
+		return append(Payload{image}, append(urlItems, Item{MIME: "text/html", Data: []byte(HTML(src.Title, src.URL))})...), nil
+	case FormatDataURI:
+		if len(src.Data) == 0 {
+			return nil, ErrNoFile
+		}
+		uri := "data:" + imageMIME(src.URL) + ";base64," + base64.StdEncoding.EncodeToString(src.Data)
+		return Payload{{MIME: "text/plain", Data: []byte(uri)}}, nil
+	default:
+		return nil, fmt.Errorf("unknown clipboard format %q", f)
+	}
+}
+
+// NewListPayload is NewPayload for several GIFs, one per line. Only the
+// text formats can hold more than one.
+func NewListPayload(f Format, srcs []Source) (Payload, error) {
+	urls := make([]string, len(srcs))
+	lines := make([]string, len(srcs))
+	for i, src := range srcs {
+		urls[i] = src.URL
+		switch f {
+		case FormatMarkdown:
+			lines[i] = Markdown(src.Title, src.URL)
+		case FormatHTML:
+			lines[i] = HTML(src.Title, src.URL)
+		}
+	}
+	urlItems := Payload{
+		{MIME: "text/plain", Data: []byte(strings.Join(urls, "\n"))},
+		{MIME: "text/uri-list", Data: []byte(strings.Join(urls, "\r\n") + "\r\n")},
+	}
+	switch f {
+	case FormatURL:
+		return urlItems, nil
+	case FormatMarkdown:
+		return Payload{{MIME: "text/plain", Data: []byte(strings.Join(lines, "\n"))}}, nil
+	case FormatHTML:
+		return append(Payload{{MIME: "text/html", Data: []byte(strings.Join(lines, "\n"))}}, urlItems...), nil
+	default:
+		return nil, fmt.Errorf("%s copies one GIF at a time", f)
+	}
+}
+
+func Markdown(title, url string) string {
+	alt := strings.NewReplacer("[", "", "]", "").Replace(title)
+	return "![" + alt + "](" + url + ")"
+}
+
+func HTML(title, url string) string {
+	return `<img src="` + html.EscapeString(url) + `" alt="` + html.EscapeString(title) + `">`
+}
+
+func imageMIME(name string) string {
+	if i := strings.IndexAny(name, "?#"); i >= 0 {
+		name = name[:i]
+	}
+	if t := mime.TypeByExtension(strings.ToLower(filepath.Ext(name))); strings.HasPrefix(t, "image/") || strings.HasPrefix(t, "video/") {
+		return t
+	}
+	return "image/gif"
+}
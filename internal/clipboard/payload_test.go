@@ -0,0 +1,70 @@
+package clipboard
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewPayload(t *testing.T) {
+	src := Source{URL: "https://example.test/cat.gif?x=1", Title: `Cat [dance] "yay"`, Path: "/tmp/cat.gif", Data: []byte("GIF89a")}
+
+	tests := []struct {
+		format Format
+		mimes  []string
+		text   string
+	}{
+		{FormatURL, []string{"text/plain", "text/uri-list"}, src.URL},
+		{FormatMarkdown, []string{"text/plain"}, "![Cat dance \"yay\"](https://example.test/cat.gif?x=1)"},
+		{FormatHTML, []string{"text/html", "text/plain", "text/uri-list"}, `<img src="https://example.test/cat.gif?x=1" alt="Cat [dance] &#34;yay&#34;">`},
+		{FormatGIF, []string{"image/gif", "text/plain", "text/uri-list", "text/html"}, src.URL},
+		{FormatDataURI, []string{"text/plain"}, "data:image/gif;base64,R0lGODlh"},
+	}
+	for _, tt := range tests {
+		p, err := NewPayload(tt.format, src)
+		if err != nil {
+			t.Fatalf("%s: unexpected err: %v", tt.format, err)
+		}
+		if len(p) != len(tt.mimes) {
+			t.Fatalf("%s: expected %d items, got %d", tt.format, len(tt.mimes), len(p))
+		}
+		for i, m := range tt.mimes {
+			if p[i].MIME != m {
+				t.Fatalf("%s: item %d is %q, want %q", tt.format, i, p[i].MIME, m)
+			}
+		}
+		if text, ok := p.Text(); !ok || text != tt.text {
+			t.Fatalf("%s: unexpected text %q", tt.format, text)
+		}
+	}
+
+	if _, err := NewPayload(FormatGIF, Source{URL: src.URL}); !errors.Is(err, ErrNoFile) {
+		t.Fatalf("expected ErrNoFile without a path, got %v", err)
+	}
+	if _, err := NewPayload(Format("png"), src); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+}
+
+func TestNewListPayload(t *testing.T) {
+	srcs := []Source{{URL: "https://example.test/a.gif", Title: "A"}, {URL: "https://example.test/b.gif", Title: "B"}}
+	p, err := NewListPayload(FormatURL, srcs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text, _ := p.Text(); text != "https://example.test/a.gif\nhttps://example.test/b.gif" {
+		t.Fatalf("unexpected URLs %q", text)
+	}
+	if string(p[1].Data) != "https://example.test/a.gif\r\nhttps://example.test/b.gif\r\n" {
+		t.Fatalf("unexpected uri-list %q", p[1].Data)
+	}
+	p, err = NewListPayload(FormatMarkdown, srcs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text, _ := p.Text(); text != "![A](https://example.test/a.gif)\n![B](https://example.test/b.gif)" {
+		t.Fatalf("unexpected Markdown %q", text)
+	}
+	if _, err := NewListPayload(FormatGIF, srcs); err == nil {
+		t.Fatal("expected an error for several GIF files")
+	}
+}
@@ -0,0 +1,97 @@
+package clipboard
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/steipete/gifgrep/internal/termcaps"
+)
+
+// Backend is how text reaches the clipboard.
+type Backend int
+
+const (
+	// BackendSystem runs pbcopy, xclip or wl-copy.
+	BackendSystem Backend = iota
+	// BackendOSC52 asks the terminal to set its clipboard (OSC 52), which
+	// reaches the local machine from an SSH session.
+	BackendOSC52
+)
+
+func (b Backend) String() string {
+	if b == BackendOSC52 {
+		return "osc52"
+	}
+	return "system"
+}
+
+// MaxOSC52 is the largest base64 payload sent via OSC 52; xterm and tmux
+// drop longer ones silently.
+const MaxOSC52 = 100_000
+
+var ErrTooLarge = errors.New("too large for OSC 52")
+
+// DetectBackend honours GIFGREP_CLIPBOARD=system|osc52, else picks OSC 52
+// over SSH or when no clipboard tool is installed.
+func DetectBackend(getenv func(string) string) Backend {
+	return detectBackend(getenv, runtime.GOOS)
+}
+
+func detectBackend(getenv func(string) string, goos string) Backend {
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	switch strings.ToLower(strings.TrimSpace(getenv("GIFGREP_CLIPBOARD"))) {
+	case "system":
+		return BackendSystem
+	case "osc52":
+		return BackendOSC52
+	}
+	for _, key := range []string{"SSH_CONNECTION", "SSH_CLIENT", "SSH_TTY"} {
+		if strings.TrimSpace(getenv(key)) != "" {
+			return BackendOSC52
+		}
+	}
+	if _, err := systemCommand(goos, Payload{{MIME: "text/plain"}}); err != nil {
+		return BackendOSC52
+	}
+	return BackendSystem
+}
+
+func writeOSC52TTY(tty io.Writer, text string) error {
+	if tty == nil {
+		f, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		tty = f
+	}
+	// With tmux's allow-passthrough off, a bare sequence and set-clipboard
+	// on are the only way out.
+	p := termcaps.DetectPassthrough(os.Getenv)
+	if p == termcaps.PassthroughTmux && !tmuxPassthroughFn() {
+		p = termcaps.PassthroughNone
+	}
+	return WriteOSC52(tty, text, p)
+}
+
+var tmuxPassthroughFn = termcaps.TmuxAllowsPassthrough
+
+// WriteOSC52 writes the set-clipboard sequence, wrapped in p's DCS
+// passthrough. tmux ignores a bare OSC 52 from applications unless
+// set-clipboard is on (the default is external); passthrough reaches the
+// outer terminal either way.
+func WriteOSC52(w io.Writer, text string, p termcaps.Passthrough) error {
+	payload := base64.StdEncoding.EncodeToString([]byte(text))
+	if len(payload) > MaxOSC52 {
+		return ErrTooLarge
+	}
+	seq := []byte("\x1b]52;c;" + payload + "\x07")
+	_, err := w.Write(termcaps.WrapPassthrough(seq, p))
+	return err
+}
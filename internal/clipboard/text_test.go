@@ -0,0 +1,82 @@
+package clipboard
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/steipete/gifgrep/internal/termcaps"
+)
+
+func TestWriteOSC52(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteOSC52(&buf, "https://example.test/a.gif", termcaps.PassthroughNone); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if got := buf.String(); got != "\x1b]52;c;aHR0cHM6Ly9leGFtcGxlLnRlc3QvYS5naWY=\x07" {
+		t.Fatalf("unexpected sequence: %q", got)
+	}
+
+	buf.Reset()
+	if err := WriteOSC52(&buf, "x", termcaps.PassthroughScreen); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if got := buf.String(); !strings.HasPrefix(got, "\x1bP\x1b]52;c;") || !strings.HasSuffix(got, "\x07\x1b\\") {
+		t.Fatalf("expected DCS-wrapped sequence, got %q", got)
+	}
+
+	buf.Reset()
+	if err := WriteOSC52(&buf, "x", termcaps.PassthroughTmux); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if got := buf.String(); got != "\x1bPtmux;\x1b\x1b]52;c;eA==\x07\x1b\\" {
+		t.Fatalf("expected tmux passthrough, got %q", got)
+	}
+
+	if err := WriteOSC52(&buf, strings.Repeat("a", MaxOSC52), termcaps.PassthroughNone); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+}
+
+func TestDetectBackend(t *testing.T) {
+	prev := lookPath
+	lookPath = func(string) (string, error) { return "/usr/bin/xclip", nil }
+	t.Cleanup(func() { lookPath = prev })
+
+	env := func(m map[string]string) func(string) string {
+		return func(k string) string { return m[k] }
+	}
+	if got := detectBackend(env(map[string]string{"SSH_TTY": "/dev/pts/1"}), "linux"); got != BackendOSC52 {
+		t.Fatalf("expected osc52 over ssh, got %v", got)
+	}
+	if got := detectBackend(env(map[string]string{"SSH_TTY": "/dev/pts/1", "GIFGREP_CLIPBOARD": "system"}), "linux"); got != BackendSystem {
+		t.Fatalf("expected forced system, got %v", got)
+	}
+	if got := detectBackend(env(nil), "linux"); got != BackendSystem {
+		t.Fatalf("expected system with a local tool, got %v", got)
+	}
+
+	lookPath = func(string) (string, error) { return "", errors.New("not found") }
+	if got := detectBackend(env(nil), "linux"); got != BackendOSC52 {
+		t.Fatalf("expected osc52 without a tool, got %v", got)
+	}
+}
+
+func TestOSC52InTmuxWithoutPassthrough(t *testing.T) {
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	t.Setenv("GIFGREP_CLIPBOARD", "osc52")
+	prev := tmuxPassthroughFn
+	t.Cleanup(func() { tmuxPassthroughFn = prev })
+
+	var buf bytes.Buffer
+	tmuxPassthroughFn = func() bool { return true }
+	if err := CopyText("x", &buf); err != nil || !strings.HasPrefix(buf.String(), "\x1bPtmux;") {
+		t.Fatalf("expected passthrough, got %q (%v)", buf.String(), err)
+	}
+	buf.Reset()
+	tmuxPassthroughFn = func() bool { return false }
+	if err := CopyText("x", &buf); err != nil || buf.String() != "\x1b]52;c;eA==\x07" {
+		t.Fatalf("expected a bare sequence for set-clipboard, got %q (%v)", buf.String(), err)
+	}
+}
@@ -0,0 +1,498 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/kong"
+)
+
+// EnvKeys maps settings that have no flag to the variables they set.
+// Variables already in the environment win.
+var EnvKeys = map[string]string{
+	"inline":             "GIFGREP_INLINE",
+	"text-style":         "GIFGREP_TEXT_STYLE",
+	"software-anim":      "GIFGREP_SOFTWARE_ANIM",
+	"cell-aspect":        "GIFGREP_CELL_ASPECT",
+	"prefetch-max-bytes": "GIFGREP_TUI_PREFETCH_MAX_BYTES",
+	"kitty-transport":    "GIFGREP_KITTY_TRANSPORT",
+	"kitty-placeholders": "GIFGREP_KITTY_PLACEHOLDERS",
+	"clipboard":          "GIFGREP_CLIPBOARD",
+}
+
+// SharedCommands read top-level settings, like the global flags. Every
+// command also reads its own [NAME] table, so `size` can pick a rendition
+// for search and the font size in [caption].
+var SharedCommands = map[string]bool{"search": true, "tui": true}
+
+var ErrNotSet = errors.New("not set")
+
+// Path is $XDG_CONFIG_HOME/gifgrep/config.toml, or ~/.config/gifgrep/config.toml.
+func Path() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "gifgrep", "config.toml")
+}
+
+// File is a parsed config file: top-level settings, optionally overridden
+// per [profile.NAME] table.
+type File struct {
+	values map[string]any
+}
+
+func Parse(r io.Reader) (*File, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	values, err := parseTOML(string(data))
+	if err != nil {
+		return nil, err
+	}
+	return &File{values: values}, nil
+}
+
+// Load reads path; a missing file is empty.
+func Load(path string) (*File, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &File{values: map[string]any{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return Parse(f)
+}
+
+// Get returns the value at a dotted key such as "max" or
+// "profile.work.source".
+func (f *File) Get(key string) (any, bool) {
+	var cur any = f.values
+	for _, part := range strings.Split(key, ".") {
+		table, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = table[part]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func (f *File) Profiles() []string {
+	profiles, _ := f.values["profile"].(map[string]any)
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (f *File) HasProfile(name string) bool {
+	_, ok := f.Get("profile." + name)
+	return ok
+}
+
+// ProfileName picks the active profile: the flag, then GIFGREP_PROFILE,
+// then the file's default-profile.
+func (f *File) ProfileName(flag string, getenv func(string) string) string {
+	if flag != "" {
+		return flag
+	}
+	if env := strings.TrimSpace(getenv("GIFGREP_PROFILE")); env != "" {
+		return env
+	}
+	name, _ := f.values["default-profile"].(string)
+	return name
+}
+
+// Value looks key up for a flag of command ("" for global flags and
+// environment settings): the profile before the top level, and within
+// each the command's [NAME] table before plain keys, which only global
+// flags and SharedCommands read.
+func (f *File) Value(profile, command, key string) (any, bool) {
+	var prefixes []string
+	if profile != "" {
+		prefixes = append(prefixes, "profile."+profile+".")
+	}
+	prefixes = append(prefixes, "")
+	for _, prefix := range prefixes {
+		if command != "" {
+			if v, ok := f.Get(prefix + command + "." + key); ok {
+				return v, true
+			}
+		}
+		if command != "" && !SharedCommands[command] {
+			continue
+		}
+		v, ok := f.Get(prefix + key)
+		if _, table := v.(map[string]any); ok && (!table || key == "keys") {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// ApplyEnv exports the profile's flagless settings (see EnvKeys).
+func (f *File) ApplyEnv(profile string, getenv func(string) string, setenv func(string, string) error) error {
+	for key, env := range EnvKeys {
+		v, ok := f.Value(profile, "", key)
+		if !ok || getenv(env) != "" {
+			continue
+		}
+		s := flagString(v)
+		if b, isBool := v.(bool); isBool {
+			s = "0"
+			if b {
+				s = "1"
+			}
+		}
+		if err := setenv(env, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Resolver feeds settings to flags of the same name (format-pref or
+// format_pref); tables such as [keys] become key=value;… for map flags.
+// Run Check for the file's errors: the resolver skips unknown keys and
+// profiles.
+func (f *File) Resolver() kong.Resolver {
+	return &resolver{file: f}
+}
+
+type resolver struct {
+	file *File
+}
+
+func (r *resolver) Validate(*kong.Application) error {
+	return nil
+}
+
+func (r *resolver) Resolve(ctx *kong.Context, parent *kong.Path, flag *kong.Flag) (any, error) {
+	var flagProfile string
+	for _, fl := range ctx.Flags() {
+		if fl.Name == "profile" && fl != flag {
+			flagProfile, _ = ctx.FlagValue(fl).(string)
+		}
+	}
+	profile := r.file.ProfileName(flagProfile, os.Getenv)
+	command := commandName(parent.Node())
+	for _, key := range []string{flag.Name, strings.ReplaceAll(flag.Name, "-", "_")} {
+		if v, ok := r.file.Value(profile, command, key); ok {
+			return flagString(v), nil
+		}
+	}
+	return nil, nil
+}
+
+// commandName is the top-level command n belongs to, "" for the root.
+func commandName(n *kong.Node) string {
+	if n == nil || n.Type == kong.ApplicationNode {
+		return ""
+	}
+	for n.Parent != nil && n.Parent.Type != kong.ApplicationNode {
+		n = n.Parent
+	}
+	return n.Name
+}
+
+// Check reports unknown settings and an unknown active profile.
+func (f *File) Check(app *kong.Node, profile string) error {
+	if profile != "" && !f.HasProfile(profile) {
+		return fmt.Errorf("unknown profile %q (have %s)", profile, strings.Join(f.Profiles(), ", "))
+	}
+	top := map[string]any{}
+	for k, v := range f.values {
+		if k != "profile" {
+			top[k] = v
+		}
+	}
+	if err := checkTable(app, "", top); err != nil {
+		return err
+	}
+	profiles, _ := f.values["profile"].(map[string]any)
+	for _, name := range f.Profiles() {
+		table, ok := profiles[name].(map[string]any)
+		if !ok {
+			return fmt.Errorf("profile.%s is not a table", name)
+		}
+		if err := checkTable(app, "profile."+name, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkTable checks the top level or a profile: flags of global and shared
+// commands, environment settings and [COMMAND] tables.
+func checkTable(app *kong.Node, where string, table map[string]any) error {
+	in := ""
+	if where != "" {
+		in = " in [" + where + "]"
+	}
+	shared := map[string]bool{}
+	for _, fl := range app.Flags {
+		shared[fl.Name] = true
+	}
+	commands := map[string]*kong.Node{}
+	for _, cmd := range app.Children {
+		commands[cmd.Name] = cmd
+		if SharedCommands[cmd.Name] {
+			addFlags(shared, cmd)
+		}
+	}
+	for key, v := range table {
+		name := strings.ReplaceAll(key, "_", "-")
+		if cmd, ok := commands[name]; ok {
+			if err := checkCommandTable(cmd, strings.TrimPrefix(where+"."+key, "."), v); err != nil {
+				return err
+			}
+			continue
+		}
+		if shared[name] || EnvKeys[name] != "" || (where == "" && name == "default-profile") {
+			continue
+		}
+		var owners []string
+		for _, cmd := range app.Children {
+			flags := map[string]bool{}
+			if addFlags(flags, cmd); flags[name] {
+				owners = append(owners, "["+strings.TrimPrefix(where+"."+cmd.Name, ".")+"]")
+			}
+		}
+		if len(owners) > 0 {
+			return fmt.Errorf("%q%s only applies to some commands; set it in %s", key, in, strings.Join(owners, " or "))
+		}
+		return fmt.Errorf("unknown setting %q%s", key, in)
+	}
+	return nil
+}
+
+func checkCommandTable(cmd *kong.Node, where string, v any) error {
+	table, ok := v.(map[string]any)
+	if !ok {
+		return fmt.Errorf("%s is not a table", where)
+	}
+	flags := map[string]bool{}
+	addFlags(flags, cmd)
+	for key := range table {
+		if !flags[strings.ReplaceAll(key, "_", "-")] {
+			return fmt.Errorf("unknown setting %q in [%s]", key, where)
+		}
+	}
+	return nil
+}
+
+func addFlags(flags map[string]bool, n *kong.Node) {
+	for _, fl := range n.Flags {
+		flags[fl.Name] = true
+	}
+	for _, child := range n.Children {
+		addFlags(flags, child)
+	}
+}
+
+// flagString renders v the way kong parses flag values.
+func flagString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = flagString(item)
+		}
+		return strings.Join(parts, ",")
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = k + "=" + flagString(v[k])
+		}
+		return strings.Join(parts, ";")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Format renders a value for `config get`.
+func Format(v any) string {
+	if table, ok := v.(map[string]any); ok {
+		keys := make([]string, 0, len(table))
+		for k := range table {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var b strings.Builder
+		for _, k := range keys {
+			if _, nested := table[k].(map[string]any); nested {
+				continue
+			}
+			fmt.Fprintf(&b, "%s = %s\n", formatKey(k), formatValue(table[k]))
+		}
+		return strings.TrimSuffix(b.String(), "\n")
+	}
+	return flagString(v)
+}
+
+// Guess types a command-line value: booleans and numbers stay unquoted,
+// and list is split on commas.
+func Guess(raw string, list bool) any {
+	if list {
+		var out []any
+		for _, part := range strings.Split(raw, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+		return out
+	}
+	switch raw {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if i, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(raw, 64); err == nil {
+		return f
+	}
+	return raw
+}
+
+// Set writes key = value into the file at path, keeping comments and
+// order. key is dotted: "max", "keys.download", "profile.work.source".
+func Set(path, key string, value any) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	src := string(data)
+	// A file that doesn't parse may be fixed by this very line; only the
+	// result has to parse.
+	_, srcErr := parseTOML(src)
+	parts := strings.Split(key, ".")
+	table, name := parts[:len(parts)-1], parts[len(parts)-1]
+	line := ""
+	if value != nil {
+		line = formatKey(name) + " = " + formatValue(value)
+	}
+	out := setLine(src, table, name, line)
+	if _, err := parseTOML(out); err != nil {
+		if srcErr != nil {
+			return srcErr
+		}
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(out), 0o644)
+}
+
+// valueEnd is the last line of the key/value pair starting at line i,
+// which runs on while it doesn't parse, e.g. in a multi-line array.
+func valueEnd(lines []string, i int) int {
+	for j := i; j < len(lines); j++ {
+		if _, err := parseTOML(strings.Join(lines[i:j+1], "\n")); err == nil {
+			return j
+		}
+	}
+	return i
+}
+
+// Unset removes key from the file at path, if present.
+func Unset(path, key string) error {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return Set(path, key, nil)
+}
+
+// setLine replaces or adds name's line in table; an empty line deletes it.
+// A multi-line value is replaced as a whole.
+func setLine(src string, table []string, name, line string) string {
+	lines := strings.Split(strings.TrimSuffix(src, "\n"), "\n")
+	if src == "" {
+		lines = nil
+	}
+	want := strings.Join(table, ".")
+	current := ""
+	sectionFound := want == ""
+	insertAt := -1
+	if want == "" {
+		insertAt = 0
+	}
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, "[") {
+			if end := strings.Index(trimmed, "]"); end > 0 {
+				if path, err := splitKey(trimmed[1:end]); err == nil {
+					current = strings.Join(path, ".")
+				}
+			}
+			if current == want {
+				sectionFound = true
+				insertAt = i + 1
+			}
+			continue
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		end := valueEnd(lines, i)
+		if current != want {
+			i = end
+			continue
+		}
+		if k, _, err := parseKey(trimmed); err == nil && k == name {
+			tail := lines[end+1:]
+			if line == "" {
+				lines = append(lines[:i], tail...)
+			} else {
+				lines = append(append(lines[:i], line), tail...)
+			}
+			return strings.Join(lines, "\n") + "\n"
+		}
+		insertAt = end + 1
+		i = end
+	}
+	if line == "" {
+		return src
+	}
+	if !sectionFound {
+		if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+			lines = append(lines, "")
+		}
+		header := make([]string, len(table))
+		for i, part := range table {
+			header[i] = formatKey(part)
+		}
+		lines = append(lines, "["+strings.Join(header, ".")+"]", line)
+		return strings.Join(lines, "\n") + "\n"
+	}
+	lines = append(lines[:insertAt], append([]string{line}, lines[insertAt:]...)...)
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,255 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+type testCLI struct {
+	Profile string `name:"profile"`
+	Search  struct {
+		Max        int               `name:"max" default:"20"`
+		Source     string            `name:"source" default:"auto"`
+		FormatPref []string          `name:"format-pref" default:"gif"`
+		Keys       map[string]string `name:"keys"`
+		Size       string            `name:"size" default:"original"`
+	} `cmd:""`
+	Caption struct {
+		Size   int    `name:"size" default:"48"`
+		Output string `name:"output" default:"caption.gif"`
+	} `cmd:""`
+	Config struct {
+		Path struct{} `cmd:""`
+	} `cmd:""`
+}
+
+func parseWithConfig(t *testing.T, src string, args ...string) (*testCLI, error) {
+	t.Helper()
+	f, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	cli := &testCLI{}
+	parser, err := kong.New(cli, kong.Resolvers(f.Resolver()))
+	if err != nil {
+		return nil, err
+	}
+	ctx, err := parser.Parse(args)
+	if err != nil {
+		return cli, err
+	}
+	return cli, f.Check(ctx.Model.Node, f.ProfileName(cli.Profile, os.Getenv))
+}
+
+func TestResolverProfiles(t *testing.T) {
+	t.Setenv("GIFGREP_PROFILE", "")
+	src := `
+max = 10
+source = "tenor"
+format-pref = ["mp4", "gif"]
+
+[keys]
+download = "D"
+
+[profile.work]
+source = "giphy"
+`
+	cli, err := parseWithConfig(t, src, "search")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if cli.Search.Max != 10 || cli.Search.Source != "tenor" {
+		t.Fatalf("expected top-level settings, got %+v", cli.Search)
+	}
+	if strings.Join(cli.Search.FormatPref, ",") != "mp4,gif" || cli.Search.Keys["download"] != "D" {
+		t.Fatalf("expected list and table settings, got %+v", cli.Search)
+	}
+
+	cli, err = parseWithConfig(t, src, "--profile", "work", "search", "--max", "3")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if cli.Search.Source != "giphy" || cli.Search.Max != 3 {
+		t.Fatalf("expected profile source and flag max, got %+v", cli.Search)
+	}
+
+	t.Setenv("GIFGREP_PROFILE", "work")
+	if cli, err = parseWithConfig(t, src, "search"); err != nil || cli.Search.Source != "giphy" {
+		t.Fatalf("expected GIFGREP_PROFILE to select work, got %+v (%v)", cli, err)
+	}
+
+	if _, err := parseWithConfig(t, src, "--profile", "home", "search"); err == nil || !strings.Contains(err.Error(), "unknown profile") {
+		t.Fatalf("expected unknown profile error, got %v", err)
+	}
+}
+
+func TestResolverRejectsUnknownSettings(t *testing.T) {
+	if _, err := parseWithConfig(t, "colour = \"never\"\n", "search"); err == nil || !strings.Contains(err.Error(), `unknown setting "colour"`) {
+		t.Fatalf("expected unknown setting error, got %v", err)
+	}
+	if _, err := parseWithConfig(t, "[profile.work]\nmaxx = 1\n", "search"); err == nil || !strings.Contains(err.Error(), "[profile.work]") {
+		t.Fatalf("expected unknown profile setting error, got %v", err)
+	}
+	if _, err := parseWithConfig(t, "inline = \"sixel\"\ndefault-profile = \"work\"\n[profile.work]\n", "search"); err != nil {
+		t.Fatalf("expected env and default-profile settings to validate, got %v", err)
+	}
+}
+
+func TestResolverScopesCommandSettings(t *testing.T) {
+	t.Setenv("GIFGREP_PROFILE", "")
+	src := `
+size = "small"
+
+[caption]
+size = 30
+
+[profile.big.caption]
+size = 60
+`
+	cli, err := parseWithConfig(t, src, "caption")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if cli.Caption.Size != 30 {
+		t.Fatalf("expected [caption] size, got %d", cli.Caption.Size)
+	}
+	if cli, err = parseWithConfig(t, src, "search"); err != nil || cli.Search.Size != "small" {
+		t.Fatalf("expected top-level size for search, got %+v (%v)", cli, err)
+	}
+	if cli, err = parseWithConfig(t, src, "--profile", "big", "caption"); err != nil || cli.Caption.Size != 60 {
+		t.Fatalf("expected profile caption size, got %+v (%v)", cli, err)
+	}
+
+	_, err = parseWithConfig(t, "output = \"x.gif\"\n", "search")
+	if err == nil || !strings.Contains(err.Error(), "[caption]") {
+		t.Fatalf("expected a hint to use [caption], got %v", err)
+	}
+	if _, err := parseWithConfig(t, "[caption]\nmax = 1\n", "caption"); err == nil || !strings.Contains(err.Error(), "in [caption]") {
+		t.Fatalf("expected unknown caption setting error, got %v", err)
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	f, err := Parse(strings.NewReader("software-anim = false\ninline = \"sixel\"\n[profile.x]\ninline = \"kitty\"\n"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	env := map[string]string{"GIFGREP_INLINE": ""}
+	set := func(k, v string) error { env[k] = v; return nil }
+	if err := f.ApplyEnv("x", func(k string) string { return env[k] }, set); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if env["GIFGREP_INLINE"] != "kitty" || env["GIFGREP_SOFTWARE_ANIM"] != "0" {
+		t.Fatalf("unexpected env: %v", env)
+	}
+
+	env = map[string]string{"GIFGREP_INLINE": "none"}
+	_ = f.ApplyEnv("", func(k string) string { return env[k] }, set)
+	if env["GIFGREP_INLINE"] != "none" {
+		t.Fatalf("expected environment to win, got %v", env)
+	}
+}
+
+func TestSet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gifgrep", "config.toml")
+	steps := []struct {
+		key   string
+		value any
+	}{
+		{"max", int64(5)},
+		{"profile.work.source", "giphy"},
+		{"source", "tenor"},
+		{"keys.?", "help"},
+		{"max", int64(7)},
+	}
+	for _, s := range steps {
+		if err := Set(path, s.key, s.value); err != nil {
+			t.Fatalf("set %s: %v", s.key, err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	want := "max = 7\nsource = \"tenor\"\n\n[profile.work]\nsource = \"giphy\"\n\n[keys]\n\"?\" = \"help\"\n"
+	if string(data) != want {
+		t.Fatalf("unexpected file:\n%s\nwant:\n%s", data, want)
+	}
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if v, ok := f.Get("profile.work.source"); !ok || v != "giphy" {
+		t.Fatalf("unexpected get: %v", v)
+	}
+	if v, ok := f.Value("work", "", "max"); !ok || v != int64(7) {
+		t.Fatalf("expected top-level fallback, got %v", v)
+	}
+}
+
+func TestSetKeepsComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("# mine\nmax = 1 # few\n\n[profile.work]\n# quiet\nmax = 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Set(path, "profile.work.source", "giphy"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "# mine\nmax = 1 # few\n\n[profile.work]\n# quiet\nmax = 2\nsource = \"giphy\"\n" {
+		t.Fatalf("unexpected file:\n%s", data)
+	}
+}
+
+func TestSetReplacesMultiLineValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	src := "format-pref = [\n  \"mp4\",\n  \"gif\",\n]\nmax = 3\n"
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Set(path, "format-pref", []any{"webp"}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := Set(path, "source", "tenor"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "format-pref = [\"webp\"]\nmax = 3\nsource = \"tenor\"\n" {
+		t.Fatalf("unexpected file:\n%s", data)
+	}
+}
+
+func TestUnset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := Unset(path, "max"); err != nil {
+		t.Fatalf("unset on missing file: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("max = 1\n\n[giphy]\nkey = \"k\"\nbackend = \"file\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Unset(path, "giphy.key"); err != nil {
+		t.Fatalf("unset: %v", err)
+	}
+	if err := Unset(path, "giphy.entry"); err != nil {
+		t.Fatalf("unset absent key: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "max = 1\n\n[giphy]\nbackend = \"file\"\n" {
+		t.Fatalf("unexpected file:\n%s", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected mode kept, got %v", info.Mode().Perm())
+	}
+}
+
+func TestPath(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/cfg")
+	if got := Path(); got != "/cfg/gifgrep/config.toml" {
+		t.Fatalf("unexpected path %q", got)
+	}
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+)
+
+// parseTOML decodes a settings file; tables become nested map[string]any.
+func parseTOML(src string) (map[string]any, error) {
+	values := map[string]any{}
+	if _, err := toml.Decode(src, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// The key scanners below let Set find table headers and keys line by line.
+
+func splitKey(s string) ([]string, error) {
+	var parts []string
+	rest := strings.TrimSpace(s)
+	for {
+		key, r, err := parseKey(rest)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, key)
+		r = strings.TrimSpace(r)
+		if r == "" {
+			return parts, nil
+		}
+		if !strings.HasPrefix(r, ".") {
+			return nil, fmt.Errorf("bad key %q", s)
+		}
+		rest = strings.TrimSpace(r[1:])
+	}
+}
+
+func parseKey(s string) (string, string, error) {
+	if strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'") {
+		v, rest, err := parseString(s)
+		if err != nil {
+			return "", "", err
+		}
+		return v, rest, nil
+	}
+	i := 0
+	for i < len(s) && isBareKeyByte(s[i]) {
+		i++
+	}
+	if i == 0 {
+		return "", "", fmt.Errorf("expected key at %q", s)
+	}
+	return s[:i], s[i:], nil
+}
+
+func isBareKeyByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func parseString(s string) (string, string, error) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if c == quote {
+			return b.String(), s[i+1:], nil
+		}
+		if c != '\\' || quote == '\'' {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		if i >= len(s) {
+			break
+		}
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '"', '\\':
+			b.WriteByte(s[i])
+		case 'u', 'U':
+			n := 4
+			if s[i] == 'U' {
+				n = 8
+			}
+			if i+n >= len(s) {
+				return "", "", fmt.Errorf("bad escape in %s", s)
+			}
+			r, err := strconv.ParseUint(s[i+1:i+1+n], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(r)) {
+				return "", "", fmt.Errorf("bad escape in %s", s)
+			}
+			b.WriteRune(rune(r))
+			i += n
+		default:
+			return "", "", fmt.Errorf("bad escape \\%c", s[i])
+		}
+	}
+	return "", "", fmt.Errorf("unterminated string %s", s)
+}
+
+// formatValue encodes v as a TOML value.
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = formatValue(item)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// formatKey quotes keys that aren't bare, such as "?" in [keys].
+func formatKey(k string) string {
+	for i := 0; i < len(k); i++ {
+		if !isBareKeyByte(k[i]) {
+			return strconv.Quote(k)
+		}
+	}
+	if k == "" {
+		return `""`
+	}
+	return k
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	src := `# gifgrep
+source = "giphy"   # trailing comment
+max = 1_000
+cell-aspect = 0.45
+software-anim = true
+format-pref = [
+  "mp4",
+  'gif', # trailing comma
+]
+title = "tab\there \"quoted\" é"
+
+[keys]
+download = "D"
+"?" = "help"
+
+[profile.work]
+max = 5
+`
+	got, err := parseTOML(src)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := map[string]any{
+		"source":        "giphy",
+		"max":           int64(1000),
+		"cell-aspect":   0.45,
+		"software-anim": true,
+		"format-pref":   []any{"mp4", "gif"},
+		"title":         "tab\there \"quoted\" é",
+		"keys":          map[string]any{"download": "D", "?": "help"},
+		"profile":       map[string]any{"work": map[string]any{"max": int64(5)}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected values:\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	for _, src := range []string{
+		`max 5`,
+		`max = `,
+		`source = "open`,
+		`max = 5 6`,
+		"max = 1\nmax = 2",
+		"source = \"x\"\n[source]",
+		`[keys`,
+		`list = [1, 2`,
+	} {
+		if _, err := parseTOML(src); err == nil {
+			t.Fatalf("expected error for %q", src)
+		}
+	}
+}
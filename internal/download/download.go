@@ -36,7 +36,68 @@ func ToDownloads(item model.Result) (string, error) {
 	return finalPath, nil
 }
 
+// BytesToDownloads saves GIF data (e.g. an edited copy of item) to the
+// downloads directory, naming it after item with suffix before the extension.
+func BytesToDownloads(item model.Result, suffix string, data []byte) (string, error) {
+	dir, err := DefaultDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	filename := filenameForResult(item)
+	filename = strings.TrimSuffix(filename, filepath.Ext(filename)) + suffix + ".gif"
+	finalPath, err := uniqueFilePath(dir, filename)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(finalPath, data, 0o644); err != nil {
+		return "", err
+	}
+	return finalPath, nil
+}
+
+// WriteToDownloads saves data as filename (sanitized, made unique) in the
+// downloads directory.
+func WriteToDownloads(filename string, data []byte) (string, error) {
+	dir, err := DefaultDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	ext := filepath.Ext(filename)
+	finalPath, err := uniqueFilePath(dir, sanitizeFilename(strings.TrimSuffix(filename, ext))+ext)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(finalPath, data, 0o644); err != nil {
+		return "", err
+	}
+	return finalPath, nil
+}
+
+var dirOverride string
+
+// SetDir replaces ~/Downloads as the download directory; "" restores it.
+// A leading ~ is expanded.
+func SetDir(dir string) {
+	dirOverride = dir
+}
+
 func DefaultDir() (string, error) {
+	if dirOverride != "" {
+		if dirOverride == "~" || strings.HasPrefix(dirOverride, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			return filepath.Join(home, dirOverride[1:]), nil
+		}
+		return dirOverride, nil
+	}
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
@@ -57,13 +118,14 @@ func filenameForResult(item model.Result) string {
 		name = "gif"
 	}
 	name = sanitizeFilename(name)
-	if !strings.HasSuffix(strings.ToLower(name), ".gif") {
-		name += ".gif"
+	ext := MediaExt(item.URL)
+	if !strings.HasSuffix(strings.ToLower(name), ext) {
+		name += ext
 	}
 	const maxLen = 80
 	if len(name) > maxLen {
 		base := strings.TrimSuffix(name, filepath.Ext(name))
-		ext := filepath.Ext(name)
+		ext = filepath.Ext(name)
 		if len(ext) > 10 {
 			ext = ".gif"
 		}
@@ -79,6 +141,32 @@ func filenameForResult(item model.Result) string {
 	return name
 }
 
+// MediaExt returns the file extension for a media URL: .mp4, .webm or .webp
+// when the path says so, .gif otherwise.
+func MediaExt(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ".gif"
+	}
+	switch ext := strings.ToLower(path.Ext(parsed.Path)); ext {
+	case ".mp4", ".webm", ".webp":
+		return ext
+	default:
+		return ".gif"
+	}
+}
+
+// IsVideo reports whether path or URL names an MP4 or WebM file, which the
+// GIF decoder cannot read.
+func IsVideo(rawURL string) bool {
+	switch MediaExt(rawURL) {
+	case ".mp4", ".webm":
+		return true
+	default:
+		return false
+	}
+}
+
 func filenameFromURL(rawURL string) string {
 	parsed, err := url.Parse(rawURL)
 	if err != nil {
@@ -162,7 +250,7 @@ func downloadGIFToFile(client *http.Client, gifURL, dest string) error {
 	}
 
 	dir := filepath.Dir(dest)
-	tmp, err := os.CreateTemp(dir, "gifgrep-*.gif")
+	tmp, err := os.CreateTemp(dir, "gifgrep-*"+MediaExt(gifURL))
 	if err != nil {
 		return err
 	}
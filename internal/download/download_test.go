@@ -30,6 +30,12 @@ func TestFilenameForResult(t *testing.T) {
 	if got := filenameForResult(model.Result{URL: "https://example.com/foo/bar.gif?x=1"}); got != "bar.gif" {
 		t.Fatalf("unexpected filename: %q", got)
 	}
+	if got := filenameForResult(model.Result{Title: "Cat", URL: "https://example.com/foo/bar.MP4?x=1"}); got != "Cat.mp4" {
+		t.Fatalf("unexpected filename: %q", got)
+	}
+	if got := filenameForResult(model.Result{URL: "https://example.com/foo/bar.webp"}); got != "bar.webp" {
+		t.Fatalf("unexpected filename: %q", got)
+	}
 
 	long := strings.Repeat("a", 200)
 	got := filenameForResult(model.Result{Title: long})
@@ -108,3 +114,60 @@ func TestToDownloadsUsesHome(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestBytesToDownloadsAddsSuffix(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	res := model.Result{Title: "cat dance", URL: "https://example.com/x.gif"}
+	got, err := BytesToDownloads(res, "-caption", []byte("GIF89a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(got) != "cat_dance-caption.gif" {
+		t.Fatalf("unexpected name %q", filepath.Base(got))
+	}
+	again, err := BytesToDownloads(res, "-caption", []byte("GIF89a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(again) != "cat_dance-caption-1.gif" {
+		t.Fatalf("expected unique name, got %q", filepath.Base(again))
+	}
+}
+
+func TestWriteToDownloads(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	got, err := WriteToDownloads("gifgrep cats & dogs.md", []byte("# cats"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != filepath.Join(home, "Downloads", "gifgrep_cats___dogs.md") {
+		t.Fatalf("unexpected path %q", got)
+	}
+	again, err := WriteToDownloads("gifgrep cats & dogs.md", []byte("# cats"))
+	if err != nil || filepath.Base(again) != "gifgrep_cats___dogs-1.md" {
+		t.Fatalf("expected a unique name, got %q (%v)", again, err)
+	}
+}
+
+func TestSetDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Cleanup(func() { SetDir("") })
+
+	SetDir("~/gifs")
+	if got, err := DefaultDir(); err != nil || got != filepath.Join(home, "gifs") {
+		t.Fatalf("expected expanded dir, got %q (%v)", got, err)
+	}
+	SetDir("/srv/gifs")
+	if got, _ := DefaultDir(); got != "/srv/gifs" {
+		t.Fatalf("expected override, got %q", got)
+	}
+	SetDir("")
+	if got, _ := DefaultDir(); got != filepath.Join(home, "Downloads") {
+		t.Fatalf("expected default, got %q", got)
+	}
+}
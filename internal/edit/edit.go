@@ -0,0 +1,232 @@
+package edit
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"time"
+
+	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/gifencode"
+)
+
+var (
+	ErrNoFrames     = errors.New("no frames")
+	ErrEmptyRange   = errors.New("time range selects no frames")
+	ErrCropBounds   = errors.New("crop is outside the image")
+	ErrInvalidSpeed = errors.New("speed must be > 0")
+	ErrInvalidFPS   = errors.New("fps must be > 0")
+)
+
+// minDelay is the shortest delay browsers honour; anything below 20ms is
+// commonly bumped to 100ms, which would make sped-up GIFs play slower.
+const minDelay = 20 * time.Millisecond
+
+type Options struct {
+	From      time.Duration
+	To        time.Duration // 0 = until the end
+	Crop      image.Rectangle
+	Width     int
+	Height    int
+	Speed     float64 // 0 or 1 = unchanged
+	FPS       float64 // 0 = unchanged
+	Reverse   bool
+	Boomerang bool
+}
+
+// Apply runs the edit pipeline: trim, crop, resize, frame rate, speed,
+// reverse, boomerang.
+func Apply(frames []gifencode.Frame, opts Options) ([]gifencode.Frame, error) {
+	if len(frames) == 0 {
+		return nil, ErrNoFrames
+	}
+	var err error
+	if opts.From > 0 || opts.To > 0 {
+		if frames, err = Trim(frames, opts.From, opts.To); err != nil {
+			return nil, err
+		}
+	}
+	if !opts.Crop.Empty() {
+		if frames, err = Crop(frames, opts.Crop); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Width > 0 || opts.Height > 0 {
+		frames = Resize(frames, opts.Width, opts.Height)
+	}
+	if opts.FPS > 0 {
+		if frames, err = LimitFPS(frames, opts.FPS); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Speed > 0 && opts.Speed != 1 {
+		if frames, err = ChangeSpeed(frames, opts.Speed); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Reverse {
+		frames = Reverse(frames)
+	}
+	if opts.Boomerang {
+		frames = Boomerang(frames)
+	}
+	return frames, nil
+}
+
+// Trim keeps frames that are visible between from and to. The first and last
+// kept frames are shortened so the output lasts exactly to-from.
+func Trim(frames []gifencode.Frame, from, to time.Duration) ([]gifencode.Frame, error) {
+	if from < 0 {
+		from = 0
+	}
+	if to > 0 && to <= from {
+		return nil, fmt.Errorf("%w: --to must be after --from", ErrEmptyRange)
+	}
+	var out []gifencode.Frame
+	start := time.Duration(0)
+	for _, f := range frames {
+		end := start + f.Delay
+		visibleFrom := maxDuration(start, from)
+		visibleTo := end
+		if to > 0 && to < visibleTo {
+			visibleTo = to
+		}
+		if visibleTo > visibleFrom {
+			out = append(out, gifencode.Frame{Image: f.Image, Delay: visibleTo - visibleFrom})
+		}
+		start = end
+		if to > 0 && start >= to {
+			break
+		}
+	}
+	if len(out) == 0 {
+		return nil, ErrEmptyRange
+	}
+	return out, nil
+}
+
+// Crop cuts every frame to r, given in source pixel coordinates.
+func Crop(frames []gifencode.Frame, r image.Rectangle) ([]gifencode.Frame, error) {
+	out := make([]gifencode.Frame, 0, len(frames))
+	for _, f := range frames {
+		b := f.Image.Bounds()
+		abs := r.Add(b.Min)
+		if !abs.In(b) {
+			return nil, fmt.Errorf("%w: %dx%d+%d+%d exceeds %dx%d", ErrCropBounds, r.Dx(), r.Dy(), r.Min.X, r.Min.Y, b.Dx(), b.Dy())
+		}
+		dst := image.NewNRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+		draw.Draw(dst, dst.Rect, f.Image, abs.Min, draw.Src)
+		out = append(out, gifencode.Frame{Image: dst, Delay: f.Delay})
+	}
+	return out, nil
+}
+
+// Resize scales frames to width×height. With only one side given the other
+// follows the aspect ratio.
+func Resize(frames []gifencode.Frame, width, height int) []gifencode.Frame {
+	if len(frames) == 0 {
+		return frames
+	}
+	width, height = resolveSize(frames[0].Image.Bounds(), width, height)
+	out := make([]gifencode.Frame, 0, len(frames))
+	for _, f := range frames {
+		out = append(out, gifencode.Frame{Image: gifdecode.Scale(f.Image, width, height), Delay: f.Delay})
+	}
+	return out
+}
+
+func resolveSize(b image.Rectangle, width, height int) (int, int) {
+	srcW, srcH := b.Dx(), b.Dy()
+	switch {
+	case width > 0 && height > 0:
+		return width, height
+	case width > 0:
+		return width, maxInt(1, int(math.Round(float64(width)*float64(srcH)/float64(srcW))))
+	case height > 0:
+		return maxInt(1, int(math.Round(float64(height)*float64(srcW)/float64(srcH)))), height
+	default:
+		return srcW, srcH
+	}
+}
+
+// LimitFPS drops frames so at most fps frames are shown per second. Dropped
+// frames donate their time to the previous kept frame, so total duration is
+// unchanged.
+func LimitFPS(frames []gifencode.Frame, fps float64) ([]gifencode.Frame, error) {
+	if fps <= 0 {
+		return nil, ErrInvalidFPS
+	}
+	slot := time.Duration(float64(time.Second) / fps)
+	out := make([]gifencode.Frame, 0, len(frames))
+	next := time.Duration(0)
+	at := time.Duration(0)
+	for _, f := range frames {
+		if len(out) == 0 || at >= next {
+			out = append(out, f)
+			next = at + slot
+		} else {
+			out[len(out)-1].Delay += f.Delay
+		}
+		at += f.Delay
+	}
+	return out, nil
+}
+
+// ChangeSpeed divides every delay by factor. When that would push delays
+// under what browsers honour, frames are dropped instead.
+func ChangeSpeed(frames []gifencode.Frame, factor float64) ([]gifencode.Frame, error) {
+	if factor <= 0 {
+		return nil, ErrInvalidSpeed
+	}
+	out := make([]gifencode.Frame, 0, len(frames))
+	tooFast := false
+	for _, f := range frames {
+		d := time.Duration(float64(f.Delay) / factor)
+		if d < minDelay {
+			tooFast = true
+		}
+		out = append(out, gifencode.Frame{Image: f.Image, Delay: d})
+	}
+	if tooFast {
+		return LimitFPS(out, float64(time.Second/minDelay))
+	}
+	return out, nil
+}
+
+func Reverse(frames []gifencode.Frame) []gifencode.Frame {
+	out := make([]gifencode.Frame, len(frames))
+	for i, f := range frames {
+		out[len(frames)-1-i] = f
+	}
+	return out
+}
+
+// Boomerang plays forward then backward. The turning frames aren't repeated,
+// so the loop has no visible stutter.
+func Boomerang(frames []gifencode.Frame) []gifencode.Frame {
+	if len(frames) < 3 {
+		return frames
+	}
+	out := make([]gifencode.Frame, 0, 2*len(frames)-2)
+	out = append(out, frames...)
+	for i := len(frames) - 2; i > 0; i-- {
+		out = append(out, frames[i])
+	}
+	return out
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
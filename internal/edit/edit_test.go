@@ -0,0 +1,185 @@
+package edit
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/steipete/gifgrep/gifencode"
+)
+
+func makeFrames(n int, delay time.Duration) []gifencode.Frame {
+	frames := make([]gifencode.Frame, 0, n)
+	for i := 0; i < n; i++ {
+		img := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+		for y := 0; y < 20; y++ {
+			for x := 0; x < 40; x++ {
+				img.SetNRGBA(x, y, color.NRGBA{R: uint8(i * 20), G: uint8(x * 4), B: uint8(y * 8), A: 255})
+			}
+		}
+		frames = append(frames, gifencode.Frame{Image: img, Delay: delay})
+	}
+	return frames
+}
+
+func totalDelay(frames []gifencode.Frame) time.Duration {
+	var total time.Duration
+	for _, f := range frames {
+		total += f.Delay
+	}
+	return total
+}
+
+func TestTrim(t *testing.T) {
+	frames := makeFrames(5, 100*time.Millisecond)
+	out, err := Trim(frames, 150*time.Millisecond, 350*time.Millisecond)
+	if err != nil {
+		t.Fatalf("trim failed: %v", err)
+	}
+	if len(out) != 3 {
+		t.Fatalf("expected 3 frames, got %d", len(out))
+	}
+	if out[0].Image != frames[1].Image || out[0].Delay != 50*time.Millisecond || out[2].Delay != 50*time.Millisecond {
+		t.Fatalf("unexpected trimmed frames: %+v", out)
+	}
+	if total := totalDelay(out); total != 200*time.Millisecond {
+		t.Fatalf("expected 200ms, got %v", total)
+	}
+
+	if _, err := Trim(frames, time.Second, 0); !errors.Is(err, ErrEmptyRange) {
+		t.Fatalf("expected ErrEmptyRange, got %v", err)
+	}
+	if _, err := Trim(frames, 200*time.Millisecond, 100*time.Millisecond); !errors.Is(err, ErrEmptyRange) {
+		t.Fatalf("expected ErrEmptyRange for inverted range, got %v", err)
+	}
+}
+
+func TestCropAndResize(t *testing.T) {
+	frames := makeFrames(2, 50*time.Millisecond)
+	out, err := Crop(frames, image.Rect(10, 5, 30, 15))
+	if err != nil {
+		t.Fatalf("crop failed: %v", err)
+	}
+	if b := out[0].Image.Bounds(); b.Dx() != 20 || b.Dy() != 10 {
+		t.Fatalf("expected 20x10, got %v", b)
+	}
+	if got, ok := color.NRGBAModel.Convert(out[0].Image.At(0, 0)).(color.NRGBA); !ok || got.G != 40 || got.B != 40 {
+		t.Fatalf("expected crop origin pixel, got %+v", got)
+	}
+	if _, err := Crop(frames, image.Rect(30, 0, 50, 10)); !errors.Is(err, ErrCropBounds) {
+		t.Fatalf("expected ErrCropBounds, got %v", err)
+	}
+
+	resized := Resize(frames, 20, 0)
+	if b := resized[0].Image.Bounds(); b.Dx() != 20 || b.Dy() != 10 {
+		t.Fatalf("expected aspect-preserving 20x10, got %v", b)
+	}
+	resized = Resize(frames, 0, 40)
+	if b := resized[0].Image.Bounds(); b.Dx() != 80 || b.Dy() != 40 {
+		t.Fatalf("expected upscaled 80x40, got %v", b)
+	}
+	resized = Resize(frames, 10, 10)
+	if b := resized[0].Image.Bounds(); b.Dx() != 10 || b.Dy() != 10 {
+		t.Fatalf("expected exact 10x10, got %v", b)
+	}
+}
+
+func TestSpeedAndFPS(t *testing.T) {
+	frames := makeFrames(10, 100*time.Millisecond)
+	fast, err := ChangeSpeed(frames, 2)
+	if err != nil {
+		t.Fatalf("speed failed: %v", err)
+	}
+	if len(fast) != 10 || fast[0].Delay != 50*time.Millisecond {
+		t.Fatalf("expected halved delays, got %d frames %v", len(fast), fast[0].Delay)
+	}
+
+	veryFast, err := ChangeSpeed(frames, 10)
+	if err != nil {
+		t.Fatalf("speed failed: %v", err)
+	}
+	if len(veryFast) >= 10 {
+		t.Fatalf("expected dropped frames at 10x, got %d", len(veryFast))
+	}
+	if total := totalDelay(veryFast); total != 100*time.Millisecond {
+		t.Fatalf("expected total 100ms, got %v", total)
+	}
+
+	limited, err := LimitFPS(frames, 5)
+	if err != nil {
+		t.Fatalf("fps failed: %v", err)
+	}
+	if len(limited) != 5 || limited[0].Delay != 200*time.Millisecond {
+		t.Fatalf("expected 5 frames of 200ms, got %d (%v)", len(limited), limited[0].Delay)
+	}
+
+	if _, err := ChangeSpeed(frames, 0); !errors.Is(err, ErrInvalidSpeed) {
+		t.Fatalf("expected ErrInvalidSpeed, got %v", err)
+	}
+	if _, err := LimitFPS(frames, 0); !errors.Is(err, ErrInvalidFPS) {
+		t.Fatalf("expected ErrInvalidFPS, got %v", err)
+	}
+}
+
+func TestReverseAndBoomerang(t *testing.T) {
+	frames := makeFrames(4, 50*time.Millisecond)
+	rev := Reverse(frames)
+	if rev[0].Image != frames[3].Image || rev[3].Image != frames[0].Image {
+		t.Fatalf("expected reversed order")
+	}
+	boom := Boomerang(frames)
+	if len(boom) != 6 {
+		t.Fatalf("expected 6 frames, got %d", len(boom))
+	}
+	if boom[4].Image != frames[2].Image || boom[5].Image != frames[1].Image {
+		t.Fatalf("expected backward pass without endpoints")
+	}
+}
+
+func TestApplyPipeline(t *testing.T) {
+	frames := makeFrames(6, 100*time.Millisecond)
+	out, err := Apply(frames, Options{
+		From:    100 * time.Millisecond,
+		To:      500 * time.Millisecond,
+		Crop:    image.Rect(0, 0, 20, 20),
+		Width:   10,
+		Speed:   2,
+		Reverse: true,
+	})
+	if err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if len(out) != 4 {
+		t.Fatalf("expected 4 frames, got %d", len(out))
+	}
+	if b := out[0].Image.Bounds(); b.Dx() != 10 || b.Dy() != 10 {
+		t.Fatalf("expected 10x10, got %v", b)
+	}
+	if total := totalDelay(out); total != 200*time.Millisecond {
+		t.Fatalf("expected 200ms total, got %v", total)
+	}
+	if _, err := Apply(nil, Options{}); !errors.Is(err, ErrNoFrames) {
+		t.Fatalf("expected ErrNoFrames, got %v", err)
+	}
+}
+
+func TestEncodeUnder(t *testing.T) {
+	frames := makeFrames(6, 100*time.Millisecond)
+	full, err := EncodeUnder(frames, gifencode.DefaultOptions(), 0)
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	limit := int64(len(full) / 2)
+	small, err := EncodeUnder(frames, gifencode.DefaultOptions(), limit)
+	if err != nil {
+		t.Fatalf("encode under failed: %v", err)
+	}
+	if int64(len(small)) > limit {
+		t.Fatalf("expected <= %d bytes, got %d", limit, len(small))
+	}
+	if _, err := EncodeUnder(frames, gifencode.DefaultOptions(), 10); !errors.Is(err, ErrCannotFit) {
+		t.Fatalf("expected ErrCannotFit, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package edit
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/steipete/gifgrep/gifencode"
+)
+
+var ErrCannotFit = errors.New("cannot reduce GIF under size limit")
+
+// maxFitAttempts bounds how many encode passes EncodeUnder tries.
+const maxFitAttempts = 14
+
+// minFitSide stops downscaling before the GIF becomes unrecognisable.
+const minFitSide = 32
+
+// EncodeUnder encodes frames, then keeps reducing quality until the result is
+// at most maxBytes: fewer colors first, then smaller dimensions, then fewer
+// frames. maxBytes <= 0 disables the limit.
+func EncodeUnder(frames []gifencode.Frame, opts gifencode.Options, maxBytes int64) ([]byte, error) {
+	data, err := gifencode.EncodeBytes(frames, opts)
+	if err != nil || maxBytes <= 0 || int64(len(data)) <= maxBytes {
+		return data, err
+	}
+
+	if opts.MaxColors <= 0 {
+		opts.MaxColors = 256
+	}
+	for attempt := 0; attempt < maxFitAttempts; attempt++ {
+		next, nextOpts, ok := reduce(frames, opts, attempt)
+		if !ok {
+			break
+		}
+		frames, opts = next, nextOpts
+		data, err = gifencode.EncodeBytes(frames, opts)
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(data)) <= maxBytes {
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: best effort was %d bytes, limit %d", ErrCannotFit, len(data), maxBytes)
+}
+
+func reduce(frames []gifencode.Frame, opts gifencode.Options, attempt int) ([]gifencode.Frame, gifencode.Options, bool) {
+	if opts.MaxColors > 64 {
+		opts.MaxColors /= 2
+		return frames, opts, true
+	}
+	b := frames[0].Image.Bounds()
+	// Alternate shrinking and thinning out frames once colors are down.
+	if attempt%2 == 0 && b.Dx() > minFitSide && b.Dy() > minFitSide {
+		w := b.Dx() * 4 / 5
+		h := b.Dy() * 4 / 5
+		return Resize(frames, w, h), opts, true
+	}
+	if len(frames) > 2 {
+		thinned := make([]gifencode.Frame, 0, (len(frames)+1)/2)
+		for i := 0; i < len(frames); i += 2 {
+			f := frames[i]
+			if i+1 < len(frames) {
+				f.Delay += frames[i+1].Delay
+			}
+			thinned = append(thinned, f)
+		}
+		return thinned, opts, true
+	}
+	if b.Dx() > minFitSide && b.Dy() > minFitSide {
+		return Resize(frames, b.Dx()*4/5, b.Dy()*4/5), opts, true
+	}
+	if opts.MaxColors > 16 {
+		opts.MaxColors /= 2
+		return frames, opts, true
+	}
+	return frames, opts, false
+}
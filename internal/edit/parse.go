@@ -0,0 +1,94 @@
+package edit
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+)
+
+// ParseCrop parses ImageMagick-style geometry: WxH+X+Y (offsets optional).
+func ParseCrop(raw string) (image.Rectangle, error) {
+	s := strings.ToLower(strings.TrimSpace(raw))
+	if s == "" {
+		return image.Rectangle{}, errors.New("empty crop")
+	}
+	size, offset := s, ""
+	if i := strings.Index(s, "+"); i >= 0 {
+		size, offset = s[:i], s[i:]
+	}
+	w, h, ok := strings.Cut(size, "x")
+	if !ok {
+		return image.Rectangle{}, fmt.Errorf("invalid crop %q (want WxH+X+Y)", raw)
+	}
+	width, err := strconv.Atoi(w)
+	if err != nil || width <= 0 {
+		return image.Rectangle{}, fmt.Errorf("invalid crop width %q", w)
+	}
+	height, err := strconv.Atoi(h)
+	if err != nil || height <= 0 {
+		return image.Rectangle{}, fmt.Errorf("invalid crop height %q", h)
+	}
+	x, y := 0, 0
+	if offset != "" {
+		parts := strings.Split(strings.TrimPrefix(offset, "+"), "+")
+		if len(parts) != 2 {
+			return image.Rectangle{}, fmt.Errorf("invalid crop offset %q", offset)
+		}
+		if x, err = strconv.Atoi(parts[0]); err != nil || x < 0 {
+			return image.Rectangle{}, fmt.Errorf("invalid crop x %q", parts[0])
+		}
+		if y, err = strconv.Atoi(parts[1]); err != nil || y < 0 {
+			return image.Rectangle{}, fmt.Errorf("invalid crop y %q", parts[1])
+		}
+	}
+	return image.Rect(x, y, x+width, y+height), nil
+}
+
+// ParseSpeed accepts "2", "2x", "0.5x" or "50%".
+func ParseSpeed(raw string) (float64, error) {
+	s := strings.ToLower(strings.TrimSpace(raw))
+	scale := 1.0
+	switch {
+	case strings.HasSuffix(s, "%"):
+		s = strings.TrimSuffix(s, "%")
+		scale = 0.01
+	case strings.HasSuffix(s, "x"):
+		s = strings.TrimSuffix(s, "x")
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid speed %q", raw)
+	}
+	v *= scale
+	if v <= 0 {
+		return 0, ErrInvalidSpeed
+	}
+	return v, nil
+}
+
+// ParseByteSize accepts plain byte counts or KB/MB suffixes (1024-based),
+// e.g. "500k", "8MB", "1.5m".
+func ParseByteSize(raw string) (int64, error) {
+	s := strings.ToLower(strings.TrimSpace(raw))
+	s = strings.TrimSuffix(s, "ib")
+	s = strings.TrimSuffix(s, "b")
+	mult := 1.0
+	switch {
+	case strings.HasSuffix(s, "k"):
+		mult = 1 << 10
+		s = strings.TrimSuffix(s, "k")
+	case strings.HasSuffix(s, "m"):
+		mult = 1 << 20
+		s = strings.TrimSuffix(s, "m")
+	case strings.HasSuffix(s, "g"):
+		mult = 1 << 30
+		s = strings.TrimSuffix(s, "g")
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("invalid size %q", raw)
+	}
+	return int64(v * mult), nil
+}
@@ -0,0 +1,55 @@
+package edit
+
+import (
+	"image"
+	"testing"
+)
+
+func TestParseCrop(t *testing.T) {
+	cases := map[string]image.Rectangle{
+		"100x50+10+20": image.Rect(10, 20, 110, 70),
+		"100X50":       image.Rect(0, 0, 100, 50),
+		" 8x8+0+0 ":    image.Rect(0, 0, 8, 8),
+	}
+	for in, want := range cases {
+		got, err := ParseCrop(in)
+		if err != nil || got != want {
+			t.Fatalf("ParseCrop(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "100", "0x10", "10x10+5", "10x10+-1+2", "axb"} {
+		if _, err := ParseCrop(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestParseSpeed(t *testing.T) {
+	cases := map[string]float64{"2x": 2, "0.5X": 0.5, "150%": 1.5, "3": 3}
+	for in, want := range cases {
+		got, err := ParseSpeed(in)
+		if err != nil || got != want {
+			t.Fatalf("ParseSpeed(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "fast", "0x", "-1"} {
+		if _, err := ParseSpeed(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	cases := map[string]int64{"1024": 1024, "500k": 500 << 10, "8MB": 8 << 20, "1.5m": 3 << 19, "2MiB": 2 << 20}
+	for in, want := range cases {
+		got, err := ParseByteSize(in)
+		if err != nil || got != want {
+			t.Fatalf("ParseByteSize(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "big", "0", "-5k"} {
+		if _, err := ParseByteSize(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Package favorites keeps the results starred in the TUI.
+package favorites
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/steipete/gifgrep/internal/config"
+	"github.com/steipete/gifgrep/internal/model"
+)
+
+// Path is favorites.json next to config.toml.
+func Path() string {
+	p := config.Path()
+	if p == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(p), "favorites.json")
+}
+
+// Load reads the favorites at path, oldest first; a missing file has none.
+func Load(path string) ([]model.Result, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var results []model.Result
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Add appends the results that aren't saved yet (same source and ID, or
+// same URL when there is no ID) and reports how many were new.
+func Add(path string, results ...model.Result) (int, error) {
+	if path == "" {
+		return 0, errors.New("no config directory")
+	}
+	saved, err := Load(path)
+	if err != nil {
+		return 0, err
+	}
+	seen := map[string]bool{}
+	for _, r := range saved {
+		seen[key(r)] = true
+	}
+	added := 0
+	for _, r := range results {
+		if k := key(r); !seen[k] {
+			seen[k] = true
+			saved = append(saved, r)
+			added++
+		}
+	}
+	if added == 0 {
+		return 0, nil
+	}
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return 0, err
+	}
+	return added, nil
+}
+
+func key(r model.Result) string {
+	if r.ID != "" {
+		return "id:" + r.Source + ":" + r.ID
+	}
+	return "url:" + r.URL
+}
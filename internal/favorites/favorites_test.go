@@ -0,0 +1,44 @@
+package favorites
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/steipete/gifgrep/internal/model"
+)
+
+func TestAddSkipsSaved(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	path := Path()
+	if got, err := Load(path); err != nil || got != nil {
+		t.Fatalf("expected no favorites, got %v (%v)", got, err)
+	}
+
+	n, err := Add(path, model.Result{ID: "1", Title: "cat"}, model.Result{URL: "https://example.test/b.gif"})
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 added, got %d (%v)", n, err)
+	}
+	n, err = Add(path, model.Result{ID: "1", Title: "cat again"}, model.Result{ID: "3"}, model.Result{URL: "https://example.test/b.gif"})
+	if err != nil || n != 1 {
+		t.Fatalf("expected only the new result added, got %d (%v)", n, err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[0].Title != "cat" || got[2].ID != "3" {
+		t.Fatalf("unexpected favorites %+v", got)
+	}
+	if filepath.Base(filepath.Dir(path)) != "gifgrep" {
+		t.Fatalf("expected favorites next to config.toml, got %s", path)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("expected no temp file left, got %v", err)
+	}
+
+	n, err = Add(path, model.Result{ID: "1", Source: "giphy", Title: "giphy 1"})
+	if err != nil || n != 1 {
+		t.Fatalf("expected the same ID from another source added, got %d (%v)", n, err)
+	}
+}
@@ -8,8 +8,23 @@ import (
 	"time"
 
 	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/internal/termcaps"
 )
 
+// Sender transmits images to one terminal. The zero value sends them
+// directly; file and shared memory transfers fall back to direct per image
+// when writing fails. Close removes what the terminal didn't read.
+type Sender struct {
+	Transport termcaps.KittyTransport
+	TempDir   string // t=t files; "" is the system temp dir
+	ShmDir    string // t=s objects; "" is /dev/shm
+	// Replies makes the terminal report failed uploads and placements; the
+	// caller has to read them from its input.
+	Replies bool
+
+	files []string
+}
+
 type kittyData struct {
 	Action      string
 	ID          uint32
@@ -19,14 +34,15 @@ type kittyData struct {
 	PlacementID int
 	Delay       time.Duration
 	NoCursor    bool
+	Virtual     bool
 }
 
-func SendAnimation(out *bufio.Writer, id uint32, frames []gifdecode.Frame, cols, rows int) {
+func (s *Sender) SendAnimation(out *bufio.Writer, id uint32, frames []gifdecode.Frame, cols, rows int) {
 	if len(frames) == 0 {
 		return
 	}
 	base := frames[0]
-	sendKittyData(out, kittyData{
+	s.sendData(out, kittyData{
 		Action:      "T",
 		ID:          id,
 		Data:        base.PNG,
@@ -37,7 +53,7 @@ func SendAnimation(out *bufio.Writer, id uint32, frames []gifdecode.Frame, cols,
 	})
 	for i := 1; i < len(frames); i++ {
 		frame := frames[i]
-		sendKittyData(out, kittyData{
+		s.sendData(out, kittyData{
 			Action: "f",
 			ID:     id,
 			Data:   frame.PNG,
@@ -48,8 +64,8 @@ func SendAnimation(out *bufio.Writer, id uint32, frames []gifdecode.Frame, cols,
 	sendKittyAnimStart(out, id)
 }
 
-func SendFrame(out *bufio.Writer, id uint32, frame gifdecode.Frame, cols, rows int) {
-	sendKittyData(out, kittyData{
+func (s *Sender) SendFrame(out *bufio.Writer, id uint32, frame gifdecode.Frame, cols, rows int) {
+	s.sendData(out, kittyData{
 		Action:      "T",
 		ID:          id,
 		Data:        frame.PNG,
@@ -60,7 +76,10 @@ func SendFrame(out *bufio.Writer, id uint32, frame gifdecode.Frame, cols, rows i
 	})
 }
 
-func sendKittyData(out *bufio.Writer, data kittyData) {
+func (s *Sender) sendData(out *bufio.Writer, data kittyData) {
+	if s.Transport != termcaps.KittyDirect && s.sendMedium(out, data) {
+		return
+	}
 	encoded := base64.StdEncoding.EncodeToString(data.Data)
 	const chunkSize = 4096
 	first := true
@@ -75,28 +94,7 @@ func sendKittyData(out *bufio.Writer, data kittyData) {
 			more = 1
 		}
 		if first {
-			params := []string{
-				fmt.Sprintf("a=%s", data.Action),
-				"f=100",
-				fmt.Sprintf("i=%d", data.ID),
-				fmt.Sprintf("m=%d", more),
-				"q=2",
-			}
-			if data.Cols > 0 {
-				params = append(params, fmt.Sprintf("c=%d", data.Cols))
-			}
-			if data.Rows > 0 {
-				params = append(params, fmt.Sprintf("r=%d", data.Rows))
-			}
-			if data.PlacementID > 0 {
-				params = append(params, fmt.Sprintf("p=%d", data.PlacementID))
-			}
-			if data.NoCursor {
-				params = append(params, "C=1")
-			}
-			if data.Action == "f" && data.Delay > 0 {
-				params = append(params, fmt.Sprintf("z=%d", delayMS(data.Delay)))
-			}
+			params := s.params(data, "f=100", fmt.Sprintf("m=%d", more))
 			_, _ = fmt.Fprintf(out, "\x1b_G%s;", strings.Join(params, ","))
 			first = false
 		} else {
@@ -111,6 +109,32 @@ func sendKittyData(out *bufio.Writer, data kittyData) {
 	}
 }
 
+// params returns the control keys of data's first escape, with the
+// transport's format keys after the action.
+func (s *Sender) params(data kittyData, format ...string) []string {
+	params := append([]string{fmt.Sprintf("a=%s", data.Action)}, format...)
+	params = append(params, fmt.Sprintf("i=%d", data.ID), fmt.Sprintf("q=%d", s.quiet()))
+	if data.Cols > 0 {
+		params = append(params, fmt.Sprintf("c=%d", data.Cols))
+	}
+	if data.Rows > 0 {
+		params = append(params, fmt.Sprintf("r=%d", data.Rows))
+	}
+	if data.PlacementID > 0 {
+		params = append(params, fmt.Sprintf("p=%d", data.PlacementID))
+	}
+	if data.NoCursor {
+		params = append(params, "C=1")
+	}
+	if data.Virtual {
+		params = append(params, "U=1")
+	}
+	if data.Action == "f" && data.Delay > 0 {
+		params = append(params, fmt.Sprintf("z=%d", delayMS(data.Delay)))
+	}
+	return params
+}
+
 func sendKittyAnimDelay(out *bufio.Writer, id uint32, delayMS int) {
 	if delayMS <= 0 {
 		return
@@ -122,11 +146,34 @@ func sendKittyAnimStart(out *bufio.Writer, id uint32) {
 	_, _ = fmt.Fprintf(out, "\x1b_Ga=a,i=%d,s=3,v=1,q=2\x1b\\", id)
 }
 
-func PlaceImage(out *bufio.Writer, id uint32, cols, rows int) {
+// StopAnimation freezes image id on its current frame.
+func StopAnimation(out *bufio.Writer, id uint32) {
+	_, _ = fmt.Fprintf(out, "\x1b_Ga=a,i=%d,s=1,q=2\x1b\\", id)
+}
+
+// LoopAnimation resumes a stopped animation.
+func LoopAnimation(out *bufio.Writer, id uint32) {
+	_, _ = fmt.Fprintf(out, "\x1b_Ga=a,i=%d,s=3,q=2\x1b\\", id)
+}
+
+// ShowFrame makes frame n (1-based) of image id current; combined with
+// StopAnimation it steps through a paused animation.
+func ShowFrame(out *bufio.Writer, id uint32, n int) {
+	_, _ = fmt.Fprintf(out, "\x1b_Ga=a,i=%d,c=%d,q=2\x1b\\", id, n)
+}
+
+// SetFrameGap sets how long frame n (1-based) of image id is shown. Unlike
+// frame delays it isn't capped at a second, so slowed-down playback works.
+func SetFrameGap(out *bufio.Writer, id uint32, n int, gap time.Duration) {
+	ms := max(int(gap.Milliseconds()), 10)
+	_, _ = fmt.Fprintf(out, "\x1b_Ga=a,i=%d,r=%d,z=%d,q=2\x1b\\", id, n, ms)
+}
+
+func (s *Sender) PlaceImage(out *bufio.Writer, id uint32, cols, rows int) {
 	if id == 0 {
 		return
 	}
-	_, _ = fmt.Fprintf(out, "\x1b_Ga=p,i=%d,p=1,c=%d,r=%d,C=1,q=2\x1b\\", id, cols, rows)
+	_, _ = fmt.Fprintf(out, "\x1b_Ga=p,i=%d,p=1,c=%d,r=%d,C=1,q=%d\x1b\\", id, cols, rows, s.quiet())
 }
 
 func DeleteImage(out *bufio.Writer, id uint32) {
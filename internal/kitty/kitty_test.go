@@ -13,7 +13,7 @@ import (
 func TestKittySequences(t *testing.T) {
 	var buf bytes.Buffer
 	out := bufio.NewWriter(&buf)
-	sendKittyData(out, kittyData{
+	(&Sender{}).sendData(out, kittyData{
 		Action:      "T",
 		ID:          7,
 		Data:        []byte{1, 2, 3},
@@ -24,7 +24,7 @@ func TestKittySequences(t *testing.T) {
 	})
 	sendKittyAnimDelay(out, 7, 80)
 	sendKittyAnimStart(out, 7)
-	PlaceImage(out, 7, 2, 3)
+	(&Sender{}).PlaceImage(out, 7, 2, 3)
 	DeleteImage(out, 7)
 	_ = out.Flush()
 
@@ -37,7 +37,7 @@ func TestKittySequences(t *testing.T) {
 	}
 
 	buf.Reset()
-	SendAnimation(out, 2, []gifdecode.Frame{
+	(&Sender{}).SendAnimation(out, 2, []gifdecode.Frame{
 		{PNG: []byte{1, 2, 3}, Delay: 80 * time.Millisecond},
 		{PNG: []byte{4, 5, 6}, Delay: 90 * time.Millisecond},
 	}, 5, 4)
@@ -46,9 +46,18 @@ func TestKittySequences(t *testing.T) {
 		t.Fatalf("expected frame data")
 	}
 
+	buf.Reset()
+	ShowFrame(out, 2, 3)
+	SetFrameGap(out, 2, 1, 2*time.Second)
+	SetFrameGap(out, 2, 2, time.Millisecond)
+	_ = out.Flush()
+	if got := buf.String(); got != "\x1b_Ga=a,i=2,c=3,q=2\x1b\\\x1b_Ga=a,i=2,r=1,z=2000,q=2\x1b\\\x1b_Ga=a,i=2,r=2,z=10,q=2\x1b\\" {
+		t.Fatalf("unexpected frame controls %q", got)
+	}
+
 	buf.Reset()
 	sendKittyAnimDelay(out, 7, 0)
-	PlaceImage(out, 0, 2, 3)
+	(&Sender{}).PlaceImage(out, 0, 2, 3)
 	DeleteImage(out, 0)
 	_ = out.Flush()
 	if buf.Len() != 0 {
@@ -60,9 +69,64 @@ func TestKittySequences(t *testing.T) {
 	for i := range large {
 		large[i] = byte(i % 251)
 	}
-	sendKittyData(out, kittyData{Action: "f", ID: 9, Data: large})
+	(&Sender{}).sendData(out, kittyData{Action: "f", ID: 9, Data: large})
 	_ = out.Flush()
 	if !strings.Contains(buf.String(), "a=f") {
 		t.Fatalf("expected chunked frame data")
 	}
 }
+
+func TestVirtualPlacement(t *testing.T) {
+	var buf bytes.Buffer
+	out := bufio.NewWriter(&buf)
+	(&Sender{}).SendVirtualFrame(out, 5, gifdecode.Frame{PNG: []byte{1, 2, 3}}, 3, 2)
+	(&Sender{}).PlaceVirtual(out, 5, 4, 2)
+	_ = out.Flush()
+	if s := buf.String(); strings.Count(s, "U=1") != 2 || strings.Contains(s, "C=1") {
+		t.Fatalf("expected virtual placements: %q", s)
+	}
+
+	buf.Reset()
+	WritePlaceholders(out, 5, 3, 2)
+	_ = out.Flush()
+	want := "\x1b[38;5;5m" +
+		"\U0010EEEE̅̅\U0010EEEE\U0010EEEE" +
+		"\x1b[3D\x1b[B" +
+		"\U0010EEEE̍̅\U0010EEEE\U0010EEEE" +
+		"\x1b[39m"
+	if got := buf.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	WritePlaceholders(out, 0x010203, 1, 1)
+	_ = out.Flush()
+	if !strings.HasPrefix(buf.String(), "\x1b[38;2;1;2;3m") {
+		t.Fatalf("expected truecolor id: %q", buf.String())
+	}
+}
+
+func TestParseReply(t *testing.T) {
+	r, ok := ParseReply("Gi=31;OK")
+	if !ok || !r.OK || r.ID != 31 {
+		t.Fatalf("unexpected OK reply: %+v %v", r, ok)
+	}
+	r, ok = ParseReply("Gi=7,p=1;ENOSPC:image too large")
+	if !ok || r.OK || r.ID != 7 || r.Code != "ENOSPC" || r.Message != "image too large" {
+		t.Fatalf("unexpected error reply: %+v", r)
+	}
+	if _, ok := ParseReply("X;nope"); ok {
+		t.Fatalf("expected non-graphics APC to be rejected")
+	}
+}
+
+func TestRepliesQuietLevel(t *testing.T) {
+	var buf bytes.Buffer
+	out := bufio.NewWriter(&buf)
+	(&Sender{Replies: true}).SendFrame(out, 1, gifdecode.Frame{PNG: []byte{1}}, 1, 1)
+	DeleteImage(out, 1)
+	_ = out.Flush()
+	if s := buf.String(); !strings.Contains(s, "q=1") || !strings.Contains(s, "a=d,d=I,i=1,q=2") {
+		t.Fatalf("expected errors-only uploads and quiet deletes: %q", s)
+	}
+}
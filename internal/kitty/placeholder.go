@@ -0,0 +1,122 @@
+package kitty
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/steipete/gifgrep/gifdecode"
+)
+
+// placeholderRune is the cell Kitty replaces with part of a virtual
+// placement; the cell's foreground color carries the image ID.
+const placeholderRune = '\U0010EEEE'
+
+// rowColumnDiacritics encodes row and column indices of placeholder cells.
+// It is the start of Kitty's rowcolumn-diacritics.txt; rows beyond it are
+// not drawn.
+var rowColumnDiacritics = []rune{
+	0x0305, 0x030D, 0x030E, 0x0310, 0x0312, 0x033D, 0x033E, 0x033F, 0x0346,
+	0x034A, 0x034B, 0x034C, 0x0350, 0x0351, 0x0352, 0x0357, 0x035B, 0x0363,
+	0x0364, 0x0365, 0x0366, 0x0367, 0x0368, 0x0369, 0x036A, 0x036B, 0x036C,
+	0x036D, 0x036E, 0x036F, 0x0483, 0x0484, 0x0485, 0x0486, 0x0487, 0x0592,
+	0x0593, 0x0594, 0x0595, 0x0597, 0x0598, 0x0599, 0x059C, 0x059D, 0x059E,
+	0x059F, 0x05A0, 0x05A1, 0x05A8, 0x05A9, 0x05AB, 0x05AC, 0x05AF, 0x05C4,
+	0x0610, 0x0611, 0x0612, 0x0613, 0x0614, 0x0615, 0x0616, 0x0617, 0x0657,
+	0x0658, 0x0659, 0x065A, 0x065B, 0x065D, 0x065E, 0x06D6, 0x06D7, 0x06D8,
+	0x06D9, 0x06DA, 0x06DB, 0x06DC, 0x06DF, 0x06E0, 0x06E1, 0x06E2, 0x06E4,
+	0x06E7, 0x06E8, 0x06EB, 0x06EC, 0x0730, 0x0732, 0x0733, 0x0735, 0x0736,
+	0x073A, 0x073D, 0x073F, 0x0740, 0x0741, 0x0743, 0x0745, 0x0747, 0x0749,
+	0x074A, 0x07EB, 0x07EC, 0x07ED, 0x07EE, 0x07EF, 0x07F0, 0x07F1, 0x07F3,
+	0x0816, 0x0817, 0x0818, 0x0819, 0x081B, 0x081C, 0x081D, 0x081E, 0x081F,
+	0x0820, 0x0821, 0x0822, 0x0823, 0x0825, 0x0826, 0x0827, 0x0829, 0x082A,
+	0x082B, 0x082C, 0x082D,
+}
+
+// MaxPlaceholderRows is the tallest image WritePlaceholders can address.
+var MaxPlaceholderRows = len(rowColumnDiacritics)
+
+// SendVirtualAnimation is SendAnimation with a virtual placement: nothing is
+// drawn until WritePlaceholders puts the image's cells into the text grid.
+func (s *Sender) SendVirtualAnimation(out *bufio.Writer, id uint32, frames []gifdecode.Frame, cols, rows int) {
+	if len(frames) == 0 {
+		return
+	}
+	base := frames[0]
+	s.sendData(out, kittyData{
+		Action:      "T",
+		ID:          id,
+		Data:        base.PNG,
+		Cols:        cols,
+		Rows:        rows,
+		PlacementID: 1,
+		Virtual:     true,
+	})
+	for i := 1; i < len(frames); i++ {
+		s.sendData(out, kittyData{
+			Action: "f",
+			ID:     id,
+			Data:   frames[i].PNG,
+			Delay:  frames[i].Delay,
+		})
+	}
+	sendKittyAnimDelay(out, id, delayMS(base.Delay))
+	sendKittyAnimStart(out, id)
+}
+
+// SendVirtualFrame transmits a single frame with a virtual placement,
+// replacing any earlier image with the same ID.
+func (s *Sender) SendVirtualFrame(out *bufio.Writer, id uint32, frame gifdecode.Frame, cols, rows int) {
+	s.sendData(out, kittyData{
+		Action:      "T",
+		ID:          id,
+		Data:        frame.PNG,
+		Cols:        cols,
+		Rows:        rows,
+		PlacementID: 1,
+		Virtual:     true,
+	})
+}
+
+// PlaceVirtual resizes the virtual placement of an already transmitted image.
+func (s *Sender) PlaceVirtual(out *bufio.Writer, id uint32, cols, rows int) {
+	if id == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(out, "\x1b_Ga=p,i=%d,p=1,U=1,c=%d,r=%d,q=%d\x1b\\", id, cols, rows, s.quiet())
+}
+
+// WritePlaceholders draws image id as cols×rows placeholder cells starting at
+// the cursor. Only the first cell of a row carries diacritics; the rest
+// inherit row and column from their left neighbour. Rows are joined with
+// relative cursor moves, like the text previews.
+func WritePlaceholders(out *bufio.Writer, id uint32, cols, rows int) {
+	if id == 0 || cols <= 0 || rows <= 0 {
+		return
+	}
+	if rows > MaxPlaceholderRows {
+		rows = MaxPlaceholderRows
+	}
+	_, _ = fmt.Fprintf(out, "\x1b[%sm", placeholderColor(id))
+	rest := strings.Repeat(string(placeholderRune), cols-1)
+	for row := 0; row < rows; row++ {
+		if row > 0 {
+			_, _ = fmt.Fprintf(out, "\x1b[%dD\x1b[B", cols)
+		}
+		_, _ = out.WriteRune(placeholderRune)
+		_, _ = out.WriteRune(rowColumnDiacritics[row])
+		_, _ = out.WriteRune(rowColumnDiacritics[0])
+		_, _ = out.WriteString(rest)
+	}
+	_, _ = out.WriteString("\x1b[39m")
+}
+
+// placeholderColor encodes the low 24 bits of id as a foreground color:
+// 256-color for small IDs, truecolor otherwise.
+func placeholderColor(id uint32) string {
+	if id < 256 {
+		return "38;5;" + strconv.Itoa(int(id))
+	}
+	return fmt.Sprintf("38;2;%d;%d;%d", (id>>16)&0xff, (id>>8)&0xff, id&0xff)
+}
@@ -0,0 +1,49 @@
+package kitty
+
+import (
+	"strconv"
+	"strings"
+)
+
+// quiet is the q= key of uploads and placements: 2 suppresses every reply,
+// 1 only OK replies.
+func (s *Sender) quiet() int {
+	if s.Replies {
+		return 1
+	}
+	return 2
+}
+
+// Reply is the terminal's answer to a graphics command.
+type Reply struct {
+	ID      uint32
+	OK      bool
+	Code    string // ENOENT, EINVAL, ENOSPC, EBADF, ...
+	Message string
+}
+
+// ParseReply parses an APC reply body without its ESC _ and ESC \ framing,
+// e.g. "Gi=31;OK" or "Gi=31;ENOENT:Unknown image".
+func ParseReply(s string) (Reply, bool) {
+	if !strings.HasPrefix(s, "G") {
+		return Reply{}, false
+	}
+	keys, msg, ok := strings.Cut(s[1:], ";")
+	if !ok {
+		return Reply{}, false
+	}
+	var r Reply
+	for _, kv := range strings.Split(keys, ",") {
+		if k, v, _ := strings.Cut(kv, "="); k == "i" {
+			if n, err := strconv.ParseUint(v, 10, 32); err == nil {
+				r.ID = uint32(n)
+			}
+		}
+	}
+	if msg == "OK" {
+		r.OK = true
+		return r, true
+	}
+	r.Code, r.Message, _ = strings.Cut(msg, ":")
+	return r, true
+}
@@ -0,0 +1,122 @@
+package kitty
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/steipete/gifgrep/internal/termcaps"
+)
+
+const defaultShmDir = "/dev/shm"
+
+// pruneFiles is how many sent files are remembered before the ones the
+// terminal already deleted are forgotten.
+const pruneFiles = 64
+
+// sendMedium writes data as zlib-compressed RGBA (o=z) to a temp file or
+// shared memory and sends only its name, so the terminal skips base64 and
+// PNG decoding. It reports false when nothing was sent.
+func (s *Sender) sendMedium(out *bufio.Writer, data kittyData) bool {
+	payload, w, h, err := zlibRGBA(data.Data)
+	if err != nil {
+		return false
+	}
+	medium, dir := "t", s.TempDir
+	if s.Transport == termcaps.KittyShm {
+		dir = s.ShmDir
+		if dir == "" {
+			dir = defaultShmDir
+		}
+		if _, err := os.Stat(dir); err != nil {
+			return false
+		}
+		medium = "s"
+	}
+	path, err := writeTemp(dir, payload)
+	if err != nil {
+		return false
+	}
+	s.remember(path)
+	name := path
+	if medium == "s" {
+		name = filepath.Base(path)
+	}
+	params := s.params(data, "f=32", "o=z", fmt.Sprintf("s=%d", w), fmt.Sprintf("v=%d", h), "t="+medium)
+	_, _ = fmt.Fprintf(out, "\x1b_G%s;%s\x1b\\", strings.Join(params, ","), base64.StdEncoding.EncodeToString([]byte(name)))
+	return true
+}
+
+// zlibRGBA decodes a PNG frame to the straight-alpha RGBA Kitty's f=32 expects.
+func zlibRGBA(data []byte) ([]byte, int, int, error) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	b := img.Bounds()
+	rgba := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Rect, img, b.Min, draw.Src)
+
+	var buf bytes.Buffer
+	zw, err := zlib.NewWriterLevel(&buf, zlib.BestSpeed)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if _, err := zw.Write(rgba.Pix); err != nil {
+		return nil, 0, 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, 0, 0, err
+	}
+	return buf.Bytes(), b.Dx(), b.Dy(), nil
+}
+
+// writeTemp writes payload to a file the terminal deletes after reading.
+func writeTemp(dir string, payload []byte) (string, error) {
+	f, err := os.CreateTemp(dir, termcaps.KittyTempPattern)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(payload); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// remember records a sent file so Close can remove it if the terminal
+// never read it.
+func (s *Sender) remember(path string) {
+	if len(s.files) >= pruneFiles {
+		kept := s.files[:0]
+		for _, f := range s.files {
+			if _, err := os.Stat(f); err == nil {
+				kept = append(kept, f)
+			}
+		}
+		s.files = kept
+	}
+	s.files = append(s.files, path)
+}
+
+// Close removes the files and shared memory objects the terminal hasn't
+// read and deleted yet.
+func (s *Sender) Close() {
+	for _, f := range s.files {
+		_ = os.Remove(f)
+	}
+	s.files = nil
+}
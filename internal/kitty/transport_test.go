@@ -0,0 +1,125 @@
+package kitty
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/internal/termcaps"
+)
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatalf("png encode failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+var payloadRe = regexp.MustCompile(`\x1b_G([^;]*);([^\x1b]*)\x1b\\`)
+
+func TestSendFrameTempFile(t *testing.T) {
+	dir := t.TempDir()
+	s := &Sender{Transport: termcaps.KittyFile, TempDir: dir}
+
+	var buf bytes.Buffer
+	out := bufio.NewWriter(&buf)
+	s.SendFrame(out, 4, gifdecode.Frame{PNG: testPNG(t, 3, 2)}, 5, 4)
+	_ = out.Flush()
+
+	m := payloadRe.FindStringSubmatch(buf.String())
+	if m == nil {
+		t.Fatalf("expected one escape: %q", buf.String())
+	}
+	for _, key := range []string{"a=T", "f=32", "o=z", "s=3", "v=2", "t=t", "i=4", "c=5", "r=4"} {
+		if !strings.Contains(","+m[1]+",", ","+key+",") {
+			t.Fatalf("missing %s in %q", key, m[1])
+		}
+	}
+	path, err := base64.StdEncoding.DecodeString(m[2])
+	if err != nil {
+		t.Fatalf("bad payload: %v", err)
+	}
+	if filepath.Dir(string(path)) != dir || !strings.Contains(string(path), "tty-graphics-protocol") {
+		t.Fatalf("unexpected temp path %q", path)
+	}
+	f, err := os.Open(string(path))
+	if err != nil {
+		t.Fatalf("open temp: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		t.Fatalf("zlib: %v", err)
+	}
+	pix, err := io.ReadAll(zr)
+	if err != nil || len(pix) != 3*2*4 {
+		t.Fatalf("expected 3x2 RGBA, got %d bytes (%v)", len(pix), err)
+	}
+}
+
+func TestSendFrameShmUsesName(t *testing.T) {
+	dir := t.TempDir()
+	s := &Sender{Transport: termcaps.KittyShm, ShmDir: dir}
+
+	var buf bytes.Buffer
+	out := bufio.NewWriter(&buf)
+	s.SendFrame(out, 4, gifdecode.Frame{PNG: testPNG(t, 1, 1)}, 1, 1)
+	_ = out.Flush()
+
+	m := payloadRe.FindStringSubmatch(buf.String())
+	if m == nil || !strings.Contains(m[1], "t=s") {
+		t.Fatalf("expected shared memory transfer: %q", buf.String())
+	}
+	name, _ := base64.StdEncoding.DecodeString(m[2])
+	if strings.Contains(string(name), "/") {
+		t.Fatalf("expected bare shm name, got %q", name)
+	}
+	if _, err := os.Stat(filepath.Join(dir, string(name))); err != nil {
+		t.Fatalf("expected shm object: %v", err)
+	}
+}
+
+func TestSendFrameFallsBackToDirect(t *testing.T) {
+	s := &Sender{Transport: termcaps.KittyFile, TempDir: filepath.Join(t.TempDir(), "missing")}
+
+	var buf bytes.Buffer
+	out := bufio.NewWriter(&buf)
+	s.SendFrame(out, 4, gifdecode.Frame{PNG: testPNG(t, 1, 1)}, 1, 1)
+	_ = out.Flush()
+	if s := buf.String(); !strings.Contains(s, "f=100") || strings.Contains(s, "t=t") {
+		t.Fatalf("expected direct transfer: %q", s)
+	}
+}
+
+func TestCloseRemovesUnreadFiles(t *testing.T) {
+	dir := t.TempDir()
+	s := &Sender{Transport: termcaps.KittyFile, TempDir: dir}
+
+	out := bufio.NewWriter(io.Discard)
+	for i := range pruneFiles + 1 {
+		s.SendFrame(out, uint32(i+1), gifdecode.Frame{PNG: testPNG(t, 1, 1)}, 1, 1)
+		if i == 0 {
+			// The terminal deletes files it has read.
+			_ = os.Remove(s.files[0])
+		}
+	}
+	if len(s.files) != pruneFiles {
+		t.Fatalf("expected read files to be forgotten, tracking %d", len(s.files))
+	}
+	s.Close()
+	if left, _ := os.ReadDir(dir); len(left) != 0 {
+		t.Fatalf("expected Close to remove unread files, %d left", len(left))
+	}
+}
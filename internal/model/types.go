@@ -9,13 +9,26 @@ const Tagline = "Grep the GIF. Stick the landing."
 var Version = "0.2.3"
 
 type Result struct {
-	ID         string   `json:"id"`
-	Title      string   `json:"title"`
-	URL        string   `json:"url"`
-	PreviewURL string   `json:"preview_url"`
-	Tags       []string `json:"tags,omitempty"`
-	Width      int      `json:"width,omitempty"`
-	Height     int      `json:"height,omitempty"`
+	ID         string      `json:"id"`
+	Source     string      `json:"source,omitempty"` // tenor or giphy
+	Title      string      `json:"title"`
+	URL        string      `json:"url"`
+	PreviewURL string      `json:"preview_url"`
+	Tags       []string    `json:"tags,omitempty"`
+	Width      int         `json:"width,omitempty"`
+	Height     int         `json:"height,omitempty"`
+	Renditions []Rendition `json:"renditions,omitempty"`
+}
+
+// Rendition is one encoding of a result offered by the provider.
+type Rendition struct {
+	Name   string `json:"name"`   // provider label, e.g. "fixed_width" or "tinygif"
+	Format string `json:"format"` // gif, mp4, webp, webm
+	Size   string `json:"size"`   // small, medium, original
+	URL    string `json:"url"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+	Bytes  int64  `json:"bytes,omitempty"`
 }
 
 type Options struct {
@@ -24,14 +37,27 @@ type Options struct {
 	Quiet    bool
 	Reveal   bool
 	Download bool
+	Copy     bool
+	CopyAs   string // clipboard.Format
 	Format   string
 	Thumbs   string
 
+	Rating       string // g, pg, pg-13, r; "" keeps provider defaults
+	DownloadDir  string
+	Theme        string
+	Keymap       string            // TUI key preset: default, vim, emacs
+	Mouse        bool              // TUI: SGR mouse reporting
+	Keys         map[string]string // TUI action → keys
+	KittyReplies bool              // TUI: read Kitty's error replies, retry failed previews
+
 	JSON   bool
 	Number bool
 	Limit  int
 	Source string
 
+	FormatPref []string // rendition formats in order of preference
+	MediaSize  string   // small, medium, original
+
 	GifInput      string
 	StillAt       time.Duration
 	StillSet      bool
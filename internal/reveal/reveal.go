@@ -31,6 +31,12 @@ func Reveal(path string) error {
 	return c.Run()
 }
 
+// Tool names the program Reveal runs, or why there is none.
+func Tool() (string, error) {
+	name, _, err := commandForReveal(runtime.GOOS, ".")
+	return name, err
+}
+
 func commandForReveal(goos string, path string) (string, []string, error) {
 	switch goos {
 	case "darwin":
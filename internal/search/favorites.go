@@ -0,0 +1,44 @@
+package search
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/steipete/gifgrep/internal/favorites"
+	"github.com/steipete/gifgrep/internal/model"
+)
+
+// searchFavorites lists the saved favorites, newest first, whose title or
+// tags contain every query word; an empty query lists them all.
+func searchFavorites(query string, opts model.Options) ([]model.Result, error) {
+	saved, err := favorites.Load(favorites.Path())
+	if err != nil {
+		return nil, err
+	}
+	words := strings.Fields(strings.ToLower(query))
+	var out []model.Result
+	for _, r := range slices.Backward(saved) {
+		if opts.Limit > 0 && len(out) == opts.Limit {
+			break
+		}
+		if matchesWords(r, words) {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func matchesWords(r model.Result, words []string) bool {
+	text := strings.ToLower(r.Title + " " + strings.Join(r.Tags, " "))
+	for _, w := range words {
+		if !strings.Contains(text, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// AllowsEmptyQuery reports whether source can run without a query.
+func AllowsEmptyQuery(source string) bool {
+	return ResolveSource(source) == "favorites"
+}
@@ -2,11 +2,9 @@ package search
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
-	"os"
 	"strconv"
 	"time"
 
@@ -15,32 +13,27 @@ import (
 
 type giphySearchResponse struct {
 	Data []struct {
-		ID     string `json:"id"`
-		Title  string `json:"title"`
-		Images struct {
-			Original struct {
-				URL    string `json:"url"`
-				Width  string `json:"width"`
-				Height string `json:"height"`
-			} `json:"original"`
-			FixedWidthSmall struct {
-				URL    string `json:"url"`
-				Width  string `json:"width"`
-				Height string `json:"height"`
-			} `json:"fixed_width_small"`
-			PreviewGIF struct {
-				URL    string `json:"url"`
-				Width  string `json:"width"`
-				Height string `json:"height"`
-			} `json:"preview_gif"`
-		} `json:"images"`
+		ID     string                `json:"id"`
+		Title  string                `json:"title"`
+		Images map[string]giphyImage `json:"images"`
 	} `json:"data"`
 }
 
+type giphyImage struct {
+	URL      string `json:"url"`
+	Width    string `json:"width"`
+	Height   string `json:"height"`
+	Size     string `json:"size"`
+	MP4      string `json:"mp4"`
+	MP4Size  string `json:"mp4_size"`
+	WebP     string `json:"webp"`
+	WebPSize string `json:"webp_size"`
+}
+
 func fetchGiphyV1(query string, opts model.Options) ([]model.Result, error) {
-	apiKey := os.Getenv("GIPHY_API_KEY")
-	if apiKey == "" {
-		return nil, errors.New("missing GIPHY_API_KEY")
+	apiKey, _, err := apiKeyFn("giphy")
+	if err != nil {
+		return nil, err
 	}
 
 	limit := opts.Limit
@@ -52,7 +45,7 @@ func fetchGiphyV1(query string, opts model.Options) ([]model.Result, error) {
 	params.Set("q", query)
 	params.Set("api_key", apiKey)
 	params.Set("limit", fmt.Sprintf("%d", limit))
-	params.Set("rating", "g")
+	params.Set("rating", giphyRating(opts.Rating))
 
 	reqURL := "https://api.giphy.com/v1/gifs/search?" + params.Encode()
 	client := &http.Client{Timeout: 10 * time.Second}
@@ -78,10 +71,11 @@ func fetchGiphyV1(query string, opts model.Options) ([]model.Result, error) {
 
 	out := make([]model.Result, 0, len(parsed.Data))
 	for _, item := range parsed.Data {
-		gifURL := item.Images.Original.URL
-		preview := item.Images.FixedWidthSmall.URL
+		original := item.Images["original"]
+		gifURL := original.URL
+		preview := item.Images["fixed_width_small"].URL
 		if preview == "" {
-			preview = item.Images.PreviewGIF.URL
+			preview = item.Images["preview_gif"].URL
 		}
 		if preview == "" {
 			preview = gifURL
@@ -90,8 +84,8 @@ func fetchGiphyV1(query string, opts model.Options) ([]model.Result, error) {
 			continue
 		}
 
-		width := parseMaybeInt(item.Images.Original.Width)
-		height := parseMaybeInt(item.Images.Original.Height)
+		width := parseMaybeInt(original.Width)
+		height := parseMaybeInt(original.Height)
 
 		title := item.Title
 		if title == "" {
@@ -100,11 +94,13 @@ func fetchGiphyV1(query string, opts model.Options) ([]model.Result, error) {
 
 		out = append(out, model.Result{
 			ID:         item.ID,
+			Source:     "giphy",
 			Title:      title,
 			URL:        gifURL,
 			PreviewURL: preview,
 			Width:      width,
 			Height:     height,
+			Renditions: giphyRenditions(item.Images),
 		})
 	}
 
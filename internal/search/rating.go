@@ -0,0 +1,24 @@
+package search
+
+// Ratings use Giphy's scale; Tenor's contentfilter levels map onto it. An
+// empty rating keeps each provider's default (g on Giphy, low on Tenor).
+
+func giphyRating(rating string) string {
+	if rating == "" {
+		return "g"
+	}
+	return rating
+}
+
+func tenorContentFilter(rating string) string {
+	switch rating {
+	case "g":
+		return "high"
+	case "pg":
+		return "medium"
+	case "r":
+		return "off"
+	default:
+		return "low"
+	}
+}
@@ -0,0 +1,144 @@
+package search
+
+import (
+	"strings"
+
+	"github.com/steipete/gifgrep/internal/model"
+)
+
+const (
+	SizeSmall    = "small"
+	SizeMedium   = "medium"
+	SizeOriginal = "original"
+)
+
+// tenorMediaKeys lists Tenor v1 media keys in the order renditions are
+// reported; the "preview" PNG is a still and is skipped.
+var tenorMediaKeys = []struct {
+	key, format, size string
+}{
+	{"gif", "gif", SizeOriginal},
+	{"mediumgif", "gif", SizeMedium},
+	{"tinygif", "gif", SizeSmall},
+	{"nanogif", "gif", SizeSmall},
+	{"mp4", "mp4", SizeOriginal},
+	{"loopedmp4", "mp4", SizeOriginal},
+	{"tinymp4", "mp4", SizeSmall},
+	{"nanomp4", "mp4", SizeSmall},
+	{"webm", "webm", SizeOriginal},
+	{"tinywebm", "webm", SizeSmall},
+	{"nanowebm", "webm", SizeSmall},
+}
+
+// giphyImageKeys lists Giphy image variants in the order renditions are
+// reported. Each variant can carry a GIF, MP4 and WebP URL.
+var giphyImageKeys = []struct {
+	key, size string
+}{
+	{"original", SizeOriginal},
+	{"downsized_large", SizeOriginal},
+	{"downsized_medium", SizeMedium},
+	{"downsized", SizeMedium},
+	{"fixed_width", SizeMedium},
+	{"fixed_height", SizeMedium},
+	{"fixed_width_small", SizeSmall},
+	{"fixed_height_small", SizeSmall},
+	{"fixed_width_downsampled", SizeSmall},
+	{"preview_gif", SizeSmall},
+	{"preview_webp", SizeSmall},
+}
+
+func tenorRenditions(media map[string]mediaV1) []model.Rendition {
+	var out []model.Rendition
+	for _, k := range tenorMediaKeys {
+		m, ok := media[k.key]
+		if !ok || m.URL == "" {
+			continue
+		}
+		r := model.Rendition{Name: k.key, Format: k.format, Size: k.size, URL: m.URL, Bytes: m.Size}
+		if len(m.Dims) == 2 {
+			r.Width, r.Height = m.Dims[0], m.Dims[1]
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+func giphyRenditions(images map[string]giphyImage) []model.Rendition {
+	var out []model.Rendition
+	for _, k := range giphyImageKeys {
+		img, ok := images[k.key]
+		if !ok {
+			continue
+		}
+		w, h := parseMaybeInt(img.Width), parseMaybeInt(img.Height)
+		add := func(format, url, size string) {
+			if url == "" {
+				return
+			}
+			out = append(out, model.Rendition{
+				Name:   k.key,
+				Format: format,
+				Size:   k.size,
+				URL:    url,
+				Width:  w,
+				Height: h,
+				Bytes:  int64(parseMaybeInt(size)),
+			})
+		}
+		add("gif", img.URL, img.Size)
+		add("mp4", img.MP4, img.MP4Size)
+		add("webp", img.WebP, img.WebPSize)
+	}
+	return out
+}
+
+// sizeFallbacks is the order tiers are tried for a requested size.
+var sizeFallbacks = map[string][]string{
+	SizeSmall:    {SizeSmall, SizeMedium, SizeOriginal},
+	SizeMedium:   {SizeMedium, SizeSmall, SizeOriginal},
+	SizeOriginal: {SizeOriginal, SizeMedium, SizeSmall},
+}
+
+// SelectRendition picks the best rendition for the preferred formats (in
+// order) and size. Format wins over size: an mp4 of the wrong size beats a
+// gif of the right one when mp4 comes first.
+func SelectRendition(renditions []model.Rendition, formats []string, size string) (model.Rendition, bool) {
+	tiers, ok := sizeFallbacks[size]
+	if !ok {
+		tiers = sizeFallbacks[SizeOriginal]
+	}
+	for _, format := range formats {
+		format = strings.ToLower(strings.TrimSpace(format))
+		for _, tier := range tiers {
+			for _, r := range renditions {
+				if r.Format == format && r.Size == tier {
+					return r, true
+				}
+			}
+		}
+	}
+	return model.Rendition{}, false
+}
+
+// applyRenditionPrefs points URL and dimensions at the selected rendition.
+// Results without a matching rendition keep their default URL.
+func applyRenditionPrefs(results []model.Result, opts model.Options) {
+	if len(opts.FormatPref) == 0 && opts.MediaSize == "" {
+		return
+	}
+	formats := opts.FormatPref
+	if len(formats) == 0 {
+		formats = []string{"gif"}
+	}
+	for i := range results {
+		r, ok := SelectRendition(results[i].Renditions, formats, opts.MediaSize)
+		if !ok {
+			continue
+		}
+		results[i].URL = r.URL
+		if r.Width > 0 && r.Height > 0 {
+			results[i].Width, results[i].Height = r.Width, r.Height
+		}
+	}
+}
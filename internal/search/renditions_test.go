@@ -0,0 +1,106 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/steipete/gifgrep/internal/model"
+	"github.com/steipete/gifgrep/internal/testutil"
+)
+
+func TestTenorRenditions(t *testing.T) {
+	testutil.WithTransport(t, &testutil.FakeTransport{}, func() {
+		out, err := fetchTenorV1("cats", model.Options{Limit: 1})
+		if err != nil {
+			t.Fatalf("fetchTenorV1 failed: %v", err)
+		}
+		if len(out) != 1 {
+			t.Fatalf("expected 1 result")
+		}
+		got := out[0].Renditions
+		if len(got) != 4 {
+			t.Fatalf("expected 4 renditions, got %+v", got)
+		}
+		if got[0].Name != "gif" || got[0].Format != "gif" || got[0].Size != SizeOriginal || got[0].Width != 200 {
+			t.Fatalf("unexpected first rendition: %+v", got[0])
+		}
+		if got[2].Format != "mp4" || got[2].Bytes != 4096 {
+			t.Fatalf("unexpected mp4 rendition: %+v", got[2])
+		}
+	})
+}
+
+func TestGiphyRenditionsSkipStills(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("GIPHY_API_KEY", "test-key")
+	testutil.WithTransport(t, &testutil.FakeTransport{}, func() {
+		out, err := fetchGiphyV1("cats", model.Options{Limit: 1})
+		if err != nil {
+			t.Fatalf("fetchGiphyV1 failed: %v", err)
+		}
+		got := out[0].Renditions
+		if len(got) != 5 {
+			t.Fatalf("expected 5 renditions, got %+v", got)
+		}
+		for _, r := range got {
+			if r.Name == "original_still" {
+				t.Fatalf("still rendition leaked: %+v", r)
+			}
+		}
+		if got[1].Format != "mp4" || got[1].Bytes != 4096 || got[1].Width != 200 {
+			t.Fatalf("unexpected mp4 rendition: %+v", got[1])
+		}
+	})
+}
+
+func TestSelectRendition(t *testing.T) {
+	renditions := []model.Rendition{
+		{Format: "gif", Size: SizeOriginal, URL: "gif-orig"},
+		{Format: "gif", Size: SizeSmall, URL: "gif-small"},
+		{Format: "mp4", Size: SizeOriginal, URL: "mp4-orig"},
+		{Format: "webp", Size: SizeMedium, URL: "webp-medium"},
+	}
+	cases := []struct {
+		formats []string
+		size    string
+		want    string
+	}{
+		{[]string{"gif"}, SizeOriginal, "gif-orig"},
+		{[]string{"gif"}, SizeSmall, "gif-small"},
+		{[]string{"gif"}, SizeMedium, "gif-small"},
+		{[]string{"mp4", "gif"}, SizeSmall, "mp4-orig"},
+		{[]string{"webp", "gif"}, SizeOriginal, "webp-medium"},
+		{[]string{"webm", "GIF"}, "", "gif-orig"},
+	}
+	for _, tc := range cases {
+		r, ok := SelectRendition(renditions, tc.formats, tc.size)
+		if !ok || r.URL != tc.want {
+			t.Fatalf("%v/%s: got %q ok=%v, want %q", tc.formats, tc.size, r.URL, ok, tc.want)
+		}
+	}
+	if _, ok := SelectRendition(renditions, []string{"webm"}, SizeSmall); ok {
+		t.Fatalf("expected no match")
+	}
+}
+
+func TestSearchAppliesRenditionPrefs(t *testing.T) {
+	testutil.WithTransport(t, &testutil.FakeTransport{}, func() {
+		out, err := Search("cats", model.Options{Limit: 1, Source: "tenor", FormatPref: []string{"mp4"}, MediaSize: SizeSmall})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if out[0].URL != "https://example.test/preview.mp4" || out[0].Width != 50 {
+			t.Fatalf("unexpected selection: %+v", out[0])
+		}
+		if out[0].PreviewURL != "https://example.test/preview.gif" {
+			t.Fatalf("preview should stay a gif: %q", out[0].PreviewURL)
+		}
+
+		out, err = Search("cats", model.Options{Limit: 1, Source: "tenor", FormatPref: []string{"webm"}})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if out[0].URL != "https://example.test/full.gif" {
+			t.Fatalf("expected default URL when nothing matches, got %q", out[0].URL)
+		}
+	})
+}
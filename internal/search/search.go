@@ -2,13 +2,12 @@ package search
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
-	"os"
 	"time"
 
+	"github.com/steipete/gifgrep/internal/auth"
 	"github.com/steipete/gifgrep/internal/model"
 )
 
@@ -25,26 +24,35 @@ type tenorV1Response struct {
 type mediaV1 struct {
 	URL  string `json:"url"`
 	Dims []int  `json:"dims"`
+	Size int64  `json:"size"`
 }
 
+var apiKeyFn = auth.Key
+
 func Search(query string, opts model.Options) ([]model.Result, error) {
+	var results []model.Result
+	var err error
 	switch ResolveSource(opts.Source) {
 	case "tenor":
-		return fetchTenorV1(query, opts)
+		results, err = fetchTenorV1(query, opts)
 	case "giphy":
-		return fetchGiphyV1(query, opts)
+		results, err = fetchGiphyV1(query, opts)
+	case "favorites":
+		results, err = searchFavorites(query, opts)
 	default:
 		return nil, fmt.Errorf("unknown source: %s", opts.Source)
 	}
+	if err != nil {
+		return nil, err
+	}
+	applyRenditionPrefs(results, opts)
+	return results, nil
 }
 
 func fetchTenorV1(query string, opts model.Options) ([]model.Result, error) {
-	apiKey := os.Getenv("TENOR_API_KEY")
-	if apiKey == "" {
-		apiKey = "LIVDSRZULELA"
-	}
-	if apiKey == "" {
-		return nil, errors.New("missing TENOR_API_KEY")
+	apiKey, _, err := apiKeyFn("tenor")
+	if err != nil {
+		return nil, err
 	}
 	limit := opts.Limit
 	if limit <= 0 {
@@ -55,7 +63,7 @@ func fetchTenorV1(query string, opts model.Options) ([]model.Result, error) {
 	params.Set("q", query)
 	params.Set("key", apiKey)
 	params.Set("limit", fmt.Sprintf("%d", limit))
-	params.Set("contentfilter", "low")
+	params.Set("contentfilter", tenorContentFilter(opts.Rating))
 
 	reqURL := "https://api.tenor.com/v1/search?" + params.Encode()
 	client := &http.Client{Timeout: 10 * time.Second}
@@ -94,6 +102,7 @@ func fetchTenorV1(query string, opts model.Options) ([]model.Result, error) {
 		preview := ""
 		width := 0
 		height := 0
+		var renditions []model.Rendition
 		if len(r.Media) > 0 {
 			media := r.Media[0]
 			if m, ok := media["gif"]; ok {
@@ -114,18 +123,21 @@ func fetchTenorV1(query string, opts model.Options) ([]model.Result, error) {
 			if preview == "" {
 				preview = gifURL
 			}
+			renditions = tenorRenditions(media)
 		}
 		if gifURL == "" {
 			continue
 		}
 		out = append(out, model.Result{
 			ID:         r.ID,
+			Source:     "tenor",
 			Title:      title,
 			URL:        gifURL,
 			PreviewURL: preview,
 			Tags:       r.Tags,
 			Width:      width,
 			Height:     height,
+			Renditions: renditions,
 		})
 	}
 	return out, nil
@@ -1,11 +1,13 @@
 package search
 
 import (
+	"errors"
 	"io"
 	"net/http"
 	"strings"
 	"testing"
 
+	"github.com/steipete/gifgrep/internal/favorites"
 	"github.com/steipete/gifgrep/internal/model"
 	"github.com/steipete/gifgrep/internal/testutil"
 )
@@ -30,6 +32,7 @@ func TestFetchTenorAndGIF(t *testing.T) {
 }
 
 func TestFetchGiphy(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
 	t.Setenv("GIPHY_API_KEY", "test-key")
 	gifData := testutil.MakeTestGIF()
 	testutil.WithTransport(t, &testutil.FakeTransport{GIFData: gifData}, func() {
@@ -109,3 +112,52 @@ func TestFetchTenorMediaFallbacks(t *testing.T) {
 		}
 	})
 }
+
+func TestResolveSourceAuto(t *testing.T) {
+	prev := configuredFn
+	t.Cleanup(func() { configuredFn = prev })
+	configured := false
+	configuredFn = func(provider string) bool { return provider == "giphy" && configured }
+
+	if got := ResolveSource("auto"); got != "tenor" {
+		t.Fatalf("expected tenor without a giphy key, got %q", got)
+	}
+	configured = true
+	if got := ResolveSource(""); got != "giphy" {
+		t.Fatalf("expected giphy with a stored key, got %q", got)
+	}
+	if got := ResolveSource(" Tenor "); got != "tenor" {
+		t.Fatalf("expected explicit source kept, got %q", got)
+	}
+}
+
+func TestFetchGiphyKeyError(t *testing.T) {
+	prev := apiKeyFn
+	t.Cleanup(func() { apiKeyFn = prev })
+	apiKeyFn = func(string) (string, string, error) { return "", "", errors.New("pass: locked") }
+	if _, err := fetchGiphyV1("cats", model.Options{}); err == nil || !strings.Contains(err.Error(), "locked") {
+		t.Fatalf("expected key error, got %v", err)
+	}
+}
+
+func TestSearchFavorites(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if _, err := favorites.Add(favorites.Path(),
+		model.Result{ID: "1", Source: "tenor", Title: "Cat nap", URL: "a.gif"},
+		model.Result{ID: "2", Source: "giphy", Title: "Dog", Tags: []string{"cat"}, URL: "b.gif"},
+		model.Result{ID: "3", Source: "giphy", Title: "Cow", URL: "c.gif"},
+	); err != nil {
+		t.Fatal(err)
+	}
+	out, err := Search("CAT", model.Options{Source: "favorites"})
+	if err != nil || len(out) != 2 || out[0].ID != "2" || out[1].ID != "1" {
+		t.Fatalf("expected matching favorites newest first, got %+v (%v)", out, err)
+	}
+	out, err = Search("", model.Options{Source: "favorites", Limit: 2})
+	if err != nil || len(out) != 2 || out[0].ID != "3" {
+		t.Fatalf("expected the newest two, got %+v (%v)", out, err)
+	}
+	if !AllowsEmptyQuery("favorites") || AllowsEmptyQuery("tenor") {
+		t.Fatal("expected only favorites to run without a query")
+	}
+}
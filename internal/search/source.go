@@ -1,14 +1,19 @@
 package search
 
 import (
-	"os"
 	"strings"
+
+	"github.com/steipete/gifgrep/internal/auth"
 )
 
+var configuredFn = auth.Configured
+
+// ResolveSource maps "auto" to giphy when a Giphy key is stored or set,
+// else tenor.
 func ResolveSource(source string) string {
 	source = strings.ToLower(strings.TrimSpace(source))
 	if source == "" || source == "auto" {
-		if os.Getenv("GIPHY_API_KEY") != "" {
+		if configuredFn("giphy") {
 			return "giphy"
 		}
 		return "tenor"
@@ -0,0 +1,140 @@
+package sixel
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"strconv"
+
+	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/gifencode"
+)
+
+// maxColors is the palette size every sixel terminal we target supports.
+const maxColors = 256
+
+// Encode writes img as a DECSIXEL sequence with its own palette of up to 256
+// colors. Transparent pixels are left unpainted, so whatever is underneath
+// shows through.
+func Encode(w io.Writer, img image.Image) error {
+	p, err := gifencode.Quantize(img, gifencode.Options{MaxColors: maxColors, Dither: true})
+	if err != nil {
+		return err
+	}
+	width, height := p.Rect.Dx(), p.Rect.Dy()
+	transparent := -1
+	var buf bytes.Buffer
+	// P2=1: pixels without a sixel keep their current color.
+	buf.WriteString("\x1bP0;1;0q")
+	_, _ = fmt.Fprintf(&buf, "\"1;1;%d;%d", width, height)
+	for i, c := range p.Palette {
+		r, g, b, a := c.RGBA()
+		if a == 0 {
+			transparent = i
+			continue
+		}
+		_, _ = fmt.Fprintf(&buf, "#%d;2;%d;%d;%d", i, percent(r), percent(g), percent(b))
+	}
+
+	bits := make([][]byte, len(p.Palette))
+	inBand := make([]bool, len(p.Palette))
+	for y0 := 0; y0 < height; y0 += 6 {
+		if y0 > 0 {
+			buf.WriteByte('-')
+		}
+		var used []int
+		for dy := 0; dy < 6 && y0+dy < height; dy++ {
+			row := p.Pix[(y0+dy)*p.Stride:]
+			for x := 0; x < width; x++ {
+				idx := int(row[x])
+				if idx == transparent {
+					continue
+				}
+				if bits[idx] == nil {
+					bits[idx] = make([]byte, width)
+				}
+				if !inBand[idx] {
+					inBand[idx] = true
+					used = append(used, idx)
+				}
+				bits[idx][x] |= 1 << dy
+			}
+		}
+		for i, idx := range used {
+			if i > 0 {
+				buf.WriteByte('$')
+			}
+			buf.WriteByte('#')
+			buf.WriteString(strconv.Itoa(idx))
+			writeRuns(&buf, bits[idx])
+			clear(bits[idx])
+			inBand[idx] = false
+		}
+	}
+	buf.WriteString("\x1b\\")
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// EncodeFrame decodes a PNG frame, scales it to fit width×height pixels
+// (keeping its aspect ratio) and encodes it.
+func EncodeFrame(frame gifdecode.Frame, width, height int) ([]byte, error) {
+	img, err := png.Decode(bytes.NewReader(frame.PNG))
+	if err != nil {
+		return nil, err
+	}
+	if w, h := fitBox(img.Bounds().Dx(), img.Bounds().Dy(), width, height); w > 0 && h > 0 {
+		img = gifdecode.Scale(img, w, h)
+	}
+	var buf bytes.Buffer
+	if err := Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// fitBox scales w×h up or down to the largest size inside maxW×maxH.
+// Terminals draw sixels at native size, so small previews must be upscaled
+// here.
+func fitBox(w, h, maxW, maxH int) (int, int) {
+	if w <= 0 || h <= 0 || maxW <= 0 || maxH <= 0 {
+		return 0, 0
+	}
+	if w*maxH > h*maxW {
+		return maxW, max(1, h*maxW/w)
+	}
+	return max(1, w*maxH/h), maxH
+}
+
+// writeRuns writes one color's sixel row, run-length encoding repeats and
+// dropping trailing blanks.
+func writeRuns(buf *bytes.Buffer, row []byte) {
+	end := len(row)
+	for end > 0 && row[end-1] == 0 {
+		end--
+	}
+	for i := 0; i < end; {
+		j := i + 1
+		for j < end && row[j] == row[i] {
+			j++
+		}
+		ch := byte('?' + row[i])
+		if n := j - i; n > 3 {
+			buf.WriteByte('!')
+			buf.WriteString(strconv.Itoa(n))
+			buf.WriteByte(ch)
+		} else {
+			for k := 0; k < n; k++ {
+				buf.WriteByte(ch)
+			}
+		}
+		i = j
+	}
+}
+
+// percent converts a 16-bit color channel to the 0..100 range sixel uses.
+func percent(v uint32) int {
+	return int((v*100 + 0x7fff) / 0xffff)
+}
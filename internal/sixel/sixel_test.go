@@ -0,0 +1,77 @@
+package sixel
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/steipete/gifgrep/gifdecode"
+)
+
+func TestEncodeTwoBands(t *testing.T) {
+	// 8×7: red on top, blue in the last row, which spills into a second band.
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 7))
+	for y := 0; y < 7; y++ {
+		for x := 0; x < 8; x++ {
+			c := color.NRGBA{R: 255, A: 255}
+			if y == 6 {
+				c = color.NRGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := Encode(&buf, img); err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	s := buf.String()
+	if !strings.HasPrefix(s, "\x1bP0;1;0q\"1;1;8;7") || !strings.HasSuffix(s, "\x1b\\") {
+		t.Fatalf("unexpected framing: %q", s)
+	}
+	if !strings.Contains(s, ";2;100;0;0") || !strings.Contains(s, ";2;0;0;100") {
+		t.Fatalf("missing palette entries: %q", s)
+	}
+	// Full band of red is "~" (all six bits) repeated 8 times; the second
+	// band has one row of blue ("@").
+	if !strings.Contains(s, "!8~-#") || !strings.Contains(s, "!8@") {
+		t.Fatalf("unexpected pixel data: %q", s)
+	}
+}
+
+func TestEncodeSkipsTransparentPixels(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 1))
+	img.Set(1, 0, color.NRGBA{G: 255, A: 255})
+	var buf bytes.Buffer
+	if err := Encode(&buf, img); err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	s := buf.String()
+	if strings.Count(s, ";2;") != 1 {
+		t.Fatalf("expected one opaque palette entry: %q", s)
+	}
+	// Leading blank, one pixel, trailing blank trimmed.
+	if !strings.HasSuffix(s, "?@\x1b\\") {
+		t.Fatalf("unexpected pixel data: %q", s)
+	}
+}
+
+func TestEncodeFrameFitsBox(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	var pngBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, img); err != nil {
+		t.Fatalf("png encode failed: %v", err)
+	}
+	data, err := EncodeFrame(gifdecode.Frame{PNG: pngBuf.Bytes()}, 40, 40)
+	if err != nil {
+		t.Fatalf("encode frame failed: %v", err)
+	}
+	if !bytes.Contains(data, []byte("\"1;1;40;20")) {
+		t.Fatalf("expected 40x20 raster, got %q", data[:24])
+	}
+	if _, err := EncodeFrame(gifdecode.Frame{PNG: []byte("nope")}, 10, 10); err == nil {
+		t.Fatalf("expected decode error")
+	}
+}
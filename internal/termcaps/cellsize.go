@@ -0,0 +1,139 @@
+package termcaps
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// CellSize is the pixel size of a single terminal character cell.
+type CellSize struct {
+	Width  int
+	Height int
+}
+
+// DefaultCellSize is used when the terminal doesn't report its cell size.
+// It errs on the large side (typical HiDPI cells) so downscaled previews stay
+// sharp; terminals scale images to the requested cell box anyway.
+var DefaultCellSize = CellSize{Width: 16, Height: 32}
+
+// Box returns the pixel size of a cols×rows cell area.
+func (c CellSize) Box(cols, rows int) (int, int) {
+	if cols <= 0 || rows <= 0 || c.Width <= 0 || c.Height <= 0 {
+		return 0, 0
+	}
+	return cols * c.Width, rows * c.Height
+}
+
+// Aspect is the cell's width divided by its height.
+func (c CellSize) Aspect() float64 {
+	if c.Width <= 0 || c.Height <= 0 {
+		return 0
+	}
+	return float64(c.Width) / float64(c.Height)
+}
+
+// SixelCellSize is the cell size assumed when drawing sixels. Terminals draw
+// sixel images at their native pixel size, so this guesses small: an image a
+// bit smaller than its cell box looks fine, a larger one spills over text.
+var SixelCellSize = CellSize{Width: 8, Height: 16}
+
+var (
+	cellMu   sync.Mutex
+	detected CellSize
+)
+
+// SetCellSize records the terminal's cell size, e.g. from a CSI 16 t reply
+// read elsewhere. A zero size forgets it; sizes that can't be real are
+// ignored.
+func SetCellSize(c CellSize) {
+	if c != (CellSize{}) && (c.Width <= 0 || c.Height <= 0 || c.Width > 512 || c.Height > 512) {
+		return
+	}
+	cellMu.Lock()
+	detected = c
+	cellMu.Unlock()
+}
+
+// DetectedCellSize returns the last detected cell size.
+func DetectedCellSize() (CellSize, bool) {
+	cellMu.Lock()
+	defer cellMu.Unlock()
+	return detected, detected.Width > 0
+}
+
+// CellSizeOr returns the detected cell size, or fallback if there is none.
+func CellSizeOr(fallback CellSize) CellSize {
+	if c, ok := DetectedCellSize(); ok {
+		return c
+	}
+	return fallback
+}
+
+// DetectCellSize asks the controlling terminal for its cell size: the pixel
+// fields of TIOCGWINSZ, then CSI 16 t, then CSI 14 t divided by the window's
+// cells. The result is cached for CellSizeOr.
+func DetectCellSize() (CellSize, bool) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return DetectedCellSize()
+	}
+	defer func() { _ = tty.Close() }()
+	if refreshCellSize(tty) {
+		return DetectedCellSize()
+	}
+	cols, rows, _, _, _ := winsize(tty)
+	if c, ok := parseCellReplies(queryTTY(tty, "\x1b[16t\x1b[14t", 150*time.Millisecond), cols, rows); ok {
+		SetCellSize(c)
+	}
+	return DetectedCellSize()
+}
+
+// RefreshCellSize re-reads the cell size from TIOCGWINSZ, e.g. after a
+// resize. It writes nothing, so it is safe while something else reads the
+// terminal's input. It reports false when the terminal leaves the pixel
+// fields empty; CSI 16 t is the caller's fallback then.
+func RefreshCellSize() bool {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = tty.Close() }()
+	return refreshCellSize(tty)
+}
+
+func refreshCellSize(tty *os.File) bool {
+	cols, rows, xpixel, ypixel, ok := winsize(tty)
+	if !ok || cols <= 0 || rows <= 0 || xpixel <= 0 || ypixel <= 0 {
+		return false
+	}
+	SetCellSize(CellSize{Width: xpixel / cols, Height: ypixel / rows})
+	return true
+}
+
+var cellReplyRe = regexp.MustCompile(`\x1b\[([46]);(\d+);(\d+)t`)
+
+// parseCellReplies reads CSI 6 ; h ; w t (cell) or CSI 4 ; h ; w t (window,
+// divided by cols×rows) from a probe reply.
+func parseCellReplies(b []byte, cols, rows int) (CellSize, bool) {
+	var window CellSize
+	for _, m := range cellReplyRe.FindAllSubmatch(b, -1) {
+		h, _ := strconv.Atoi(string(m[2]))
+		w, _ := strconv.Atoi(string(m[3]))
+		if string(m[1]) == "6" && w > 0 && h > 0 {
+			return CellSize{Width: w, Height: h}, true
+		}
+		window = CellSize{Width: w, Height: h}
+	}
+	if window.Width > 0 && window.Height > 0 && cols > 0 && rows > 0 {
+		return CellSize{Width: window.Width / cols, Height: window.Height / rows}, true
+	}
+	return CellSize{}, false
+}
+
+// ParseCellSizeReply parses the parameters of a CSI 16 t reply ("6;h;w").
+func ParseCellSizeReply(params string) (CellSize, bool) {
+	return parseCellReplies([]byte("\x1b["+params+"t"), 0, 0)
+}
@@ -0,0 +1,37 @@
+package termcaps
+
+import "testing"
+
+func TestParseCellReplies(t *testing.T) {
+	c, ok := parseCellReplies([]byte("\x1b[6;20;9t\x1b[4;800;900t\x1b[?62c"), 100, 40)
+	if !ok || c != (CellSize{Width: 9, Height: 20}) {
+		t.Fatalf("expected cell reply to win, got %+v %v", c, ok)
+	}
+	c, ok = parseCellReplies([]byte("\x1b[4;800;900t"), 100, 40)
+	if !ok || c != (CellSize{Width: 9, Height: 20}) {
+		t.Fatalf("expected window size divided by cells, got %+v %v", c, ok)
+	}
+	if _, ok := parseCellReplies([]byte("\x1b[4;800;900t"), 0, 0); ok {
+		t.Fatalf("expected no size without the window's cells")
+	}
+	if c, ok := ParseCellSizeReply("6;32;16"); !ok || c != (CellSize{Width: 16, Height: 32}) {
+		t.Fatalf("unexpected CSI 16 t parse: %+v %v", c, ok)
+	}
+}
+
+func TestCellSizeCache(t *testing.T) {
+	t.Cleanup(func() { SetCellSize(CellSize{}) })
+	SetCellSize(CellSize{})
+	if got := CellSizeOr(SixelCellSize); got != SixelCellSize {
+		t.Fatalf("expected fallback, got %+v", got)
+	}
+	SetCellSize(CellSize{Width: 0, Height: 20})
+	SetCellSize(CellSize{Width: 10, Height: 4000})
+	if _, ok := DetectedCellSize(); ok {
+		t.Fatalf("expected bogus sizes to be ignored")
+	}
+	SetCellSize(CellSize{Width: 10, Height: 20})
+	if got := CellSizeOr(DefaultCellSize); got != (CellSize{Width: 10, Height: 20}) || got.Aspect() != 0.5 {
+		t.Fatalf("expected detected size, got %+v", got)
+	}
+}
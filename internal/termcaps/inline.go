@@ -4,6 +4,8 @@ import (
 	"os"
 	"strings"
 	"time"
+
+	"golang.org/x/term"
 )
 
 type InlineProtocol int
@@ -12,6 +14,10 @@ const (
 	InlineNone InlineProtocol = iota
 	InlineKitty
 	InlineIterm
+	InlineSixel
+	// InlineBlocks draws previews with Unicode characters; it needs no image
+	// protocol and is the fallback when none is detected.
+	InlineBlocks
 )
 
 func (p InlineProtocol) String() string {
@@ -22,6 +28,10 @@ func (p InlineProtocol) String() string {
 		return "kitty"
 	case InlineIterm:
 		return "iterm"
+	case InlineSixel:
+		return "sixel"
+	case InlineBlocks:
+		return "blocks"
 	default:
 		return "none"
 	}
@@ -37,6 +47,10 @@ func DetectInline(getenv func(string) string) InlineProtocol {
 		return InlineKitty
 	case "iterm", "iterm2":
 		return InlineIterm
+	case "sixel":
+		return InlineSixel
+	case "blocks", "text":
+		return InlineBlocks
 	case "none", "off", "false", "0":
 		return InlineNone
 	case "", "auto":
@@ -64,9 +78,23 @@ func DetectInline(getenv func(string) string) InlineProtocol {
 		return InlineKitty
 	}
 
+	if strings.Contains(termProgram, "wezterm") || strings.TrimSpace(getenv("WT_SESSION")) != "" ||
+		strings.HasPrefix(termEnv, "foot") || strings.HasPrefix(termEnv, "mlterm") {
+		return InlineSixel
+	}
+
 	return InlineNone
 }
 
+func inlineOverride(getenv func(string) string) bool {
+	switch strings.ToLower(strings.TrimSpace(getenv("GIFGREP_INLINE"))) {
+	case "", "auto":
+		return false
+	default:
+		return true
+	}
+}
+
 type kittyProbeResult int
 
 const (
@@ -75,23 +103,62 @@ const (
 	kittyProbeNotSupported
 )
 
+// graphicsProbe is what one query round-trip tells us: whether the Kitty
+// graphics query was answered, and whether DA1 lists sixel (attribute 4).
+type graphicsProbe struct {
+	kitty kittyProbeResult
+	sixel bool
+}
+
 func DetectInlineRobust(getenv func(string) string) InlineProtocol {
-	return detectInlineRobust(getenv, func() kittyProbeResult {
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	if pt := DetectPassthrough(getenv); pt != PassthroughNone && !inlineOverride(getenv) {
+		return detectInlineMultiplexed(getenv, pt)
+	}
+	return detectInlineRobust(getenv, func() graphicsProbe {
+		// Output that isn't a terminal shows no images, and the query would
+		// only leave replies in the terminal's input.
+		if !stdoutIsTerminal() {
+			return graphicsProbe{}
+		}
+		return probeTTYFn()
+	})
+}
+
+var (
+	stdoutIsTerminal = func() bool { return term.IsTerminal(int(os.Stdout.Fd())) }
+	probeTTYFn       = func() graphicsProbe {
 		tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
 		if err != nil {
-			return kittyProbeUnknown
+			return graphicsProbe{}
 		}
 		defer func() { _ = tty.Close() }()
-		return probeKittyGraphics(tty, 150*time.Millisecond)
-	})
-}
+		return probeGraphics(tty, 150*time.Millisecond)
+	}
+)
 
-func detectInlineRobust(getenv func(string) string, probeKitty func() kittyProbeResult) InlineProtocol {
+func detectInlineRobust(getenv func(string) string, probe func() graphicsProbe) InlineProtocol {
 	if getenv == nil {
 		getenv = os.Getenv
 	}
 	p := DetectInline(getenv)
-	if p != InlineKitty {
+	if inlineOverride(getenv) {
+		return p
+	}
+	switch p {
+	case InlineKitty:
+	case InlineNone:
+		// Unknown terminal: sixel support is only visible in DA1.
+		if strings.Contains(strings.ToLower(getenv("TERM_PROGRAM")), "apple_terminal") {
+			return InlineNone
+		}
+		if probe().sixel {
+			return InlineSixel
+		}
+		return InlineNone
+	default:
 		return p
 	}
 
@@ -104,10 +171,14 @@ func detectInlineRobust(getenv func(string) string, probeKitty func() kittyProbe
 		return InlineKitty
 	}
 
-	switch probeKitty() {
+	res := probe()
+	switch res.kitty {
 	case kittyProbeSupported:
 		return InlineKitty
 	case kittyProbeNotSupported:
+		if res.sixel {
+			return InlineSixel
+		}
 		return InlineNone
 	case kittyProbeUnknown:
 		return InlineKitty
@@ -115,3 +186,22 @@ func detectInlineRobust(getenv func(string) string, probeKitty func() kittyProbe
 		return InlineKitty
 	}
 }
+
+// detectInlineMultiplexed detects the terminal outside tmux or screen. The
+// multiplexer answers queries itself, so nothing is probed, and sixel isn't
+// tunnelled.
+func detectInlineMultiplexed(getenv func(string) string, pt Passthrough) InlineProtocol {
+	if pt == PassthroughTmux {
+		env, ok := tmuxEnv(getenv)
+		if !ok {
+			return InlineNone
+		}
+		getenv = env
+	}
+	switch p := DetectInline(getenv); p {
+	case InlineKitty, InlineIterm:
+		return p
+	default:
+		return InlineNone
+	}
+}
@@ -55,7 +55,7 @@ func TestDetectInlineRobustProbeNegative(t *testing.T) {
 			return ""
 		}
 	}
-	got := detectInlineRobust(getenv, func() kittyProbeResult { return kittyProbeNotSupported })
+	got := detectInlineRobust(getenv, func() graphicsProbe { return graphicsProbe{kitty: kittyProbeNotSupported} })
 	if got != InlineNone {
 		t.Fatalf("expected none, got %v", got)
 	}
@@ -70,8 +70,108 @@ func TestDetectInlineRobustProbeUnknownKeepsKitty(t *testing.T) {
 			return ""
 		}
 	}
-	got := detectInlineRobust(getenv, func() kittyProbeResult { return kittyProbeUnknown })
+	got := detectInlineRobust(getenv, func() graphicsProbe { return graphicsProbe{} })
 	if got != InlineKitty {
 		t.Fatalf("expected kitty, got %v", got)
 	}
 }
+
+func TestDetectInlineRobustSixelFromDA1(t *testing.T) {
+	getenv := func(k string) string {
+		switch k {
+		case "TERM":
+			return "xterm-256color"
+		default:
+			return ""
+		}
+	}
+	got := detectInlineRobust(getenv, func() graphicsProbe { return graphicsProbe{kitty: kittyProbeNotSupported, sixel: true} })
+	if got != InlineSixel {
+		t.Fatalf("expected sixel, got %v", got)
+	}
+	got = detectInlineRobust(getenv, func() graphicsProbe { return graphicsProbe{kitty: kittyProbeNotSupported} })
+	if got != InlineNone {
+		t.Fatalf("expected none, got %v", got)
+	}
+}
+
+func TestDetectInlineRobustOverrideSkipsProbe(t *testing.T) {
+	getenv := func(k string) string {
+		switch k {
+		case "GIFGREP_INLINE":
+			return "none"
+		default:
+			return ""
+		}
+	}
+	got := detectInlineRobust(getenv, func() graphicsProbe {
+		t.Fatalf("probe should not run")
+		return graphicsProbe{}
+	})
+	if got != InlineNone {
+		t.Fatalf("expected none, got %v", got)
+	}
+}
+
+func TestDetectInlineRobustSkipsProbeWithoutTerminal(t *testing.T) {
+	prevTerminal, prevProbe := stdoutIsTerminal, probeTTYFn
+	t.Cleanup(func() { stdoutIsTerminal, probeTTYFn = prevTerminal, prevProbe })
+	stdoutIsTerminal = func() bool { return false }
+	probeTTYFn = func() graphicsProbe {
+		t.Fatalf("probe should not run")
+		return graphicsProbe{}
+	}
+	getenv := func(k string) string {
+		if k == "TERM" {
+			return "xterm-256color"
+		}
+		return ""
+	}
+	if got := DetectInlineRobust(getenv); got != InlineNone {
+		t.Fatalf("expected none, got %v", got)
+	}
+}
+
+func TestDetectInlineSixelEnv(t *testing.T) {
+	for _, env := range []map[string]string{
+		{"TERM": "foot"},
+		{"TERM": "mlterm-256color"},
+		{"TERM_PROGRAM": "WezTerm"},
+		{"WT_SESSION": "abc"},
+		{"GIFGREP_INLINE": "sixel"},
+	} {
+		got := DetectInline(func(k string) string { return env[k] })
+		if got != InlineSixel {
+			t.Fatalf("%v: expected sixel, got %v", env, got)
+		}
+	}
+}
+
+func TestParseDA1(t *testing.T) {
+	attrs, ok := parseDA1([]byte("\x1b_Gi=31;OK\x1b\\\x1b[?62;4;22c"))
+	if !ok || len(attrs) != 3 || attrs[1] != 4 {
+		t.Fatalf("unexpected DA1 parse: %v %v", attrs, ok)
+	}
+	if _, ok := parseDA1([]byte("\x1b[?62;4")); ok {
+		t.Fatalf("expected incomplete DA1 to be rejected")
+	}
+}
+
+func TestDetectTextStyle(t *testing.T) {
+	cases := []struct {
+		env  map[string]string
+		want TextStyle
+	}{
+		{map[string]string{"COLORTERM": "truecolor", "TERM": "xterm-256color"}, TextTruecolor},
+		{map[string]string{"TERM_PROGRAM": "vscode"}, TextTruecolor},
+		{map[string]string{"TERM": "screen-256color"}, Text256},
+		{map[string]string{"TERM": "linux"}, TextBraille},
+		{map[string]string{"NO_COLOR": "1", "COLORTERM": "truecolor"}, TextBraille},
+		{map[string]string{"GIFGREP_TEXT_STYLE": "256", "COLORTERM": "truecolor"}, Text256},
+	}
+	for _, tc := range cases {
+		if got := DetectTextStyle(func(k string) string { return tc.env[k] }); got != tc.want {
+			t.Fatalf("%v: expected %v, got %v", tc.env, tc.want, got)
+		}
+	}
+}
@@ -3,53 +3,105 @@ package termcaps
 import (
 	"bytes"
 	"os"
+	"strconv"
 	"time"
+
+	"golang.org/x/term"
 )
 
-// probeKittyGraphics implements the kitty docs recommendation:
+// probeGraphics implements the kitty docs recommendation:
 // send a graphics protocol query (a=q) followed by primary device attributes (DA1).
 // If DA1 is answered but the graphics query is not, kitty graphics are not supported.
-func probeKittyGraphics(tty *os.File, timeout time.Duration) kittyProbeResult {
+// The DA1 reply also lists sixel support as attribute 4.
+func probeGraphics(tty *os.File, timeout time.Duration) graphicsProbe {
 	if tty == nil {
-		return kittyProbeUnknown
+		return graphicsProbe{}
 	}
-
 	// Example from kitty docs:
 	// <ESC>_Gi=31,s=1,v=1,a=q,t=d,f=24;AAAA<ESC>\<ESC>[c
-	_, _ = tty.Write([]byte("\x1b_Gi=31,s=1,v=1,a=q,t=d,f=24;AAAA\x1b\\\x1b[c"))
+	acc := queryTTY(tty, "\x1b_Gi=31,s=1,v=1,a=q,t=d,f=24;AAAA\x1b\\", timeout)
+
+	res := graphicsProbe{}
+	if bytes.Contains(acc, []byte("\x1b_Gi=31;")) || bytes.Contains(acc, []byte("\x1b_Gi=31,")) {
+		res.kitty = kittyProbeSupported
+	}
+	if attrs, ok := parseDA1(acc); ok {
+		// DA1 was sent last, so any graphics reply has arrived by now.
+		if res.kitty == kittyProbeUnknown {
+			res.kitty = kittyProbeNotSupported
+		}
+		for _, a := range attrs {
+			if a == 4 {
+				res.sixel = true
+			}
+		}
+	}
+	return res
+}
+
+// queryTTY writes query followed by DA1 and returns what the terminal sends
+// back, up to the DA1 reply or the timeout.
+func queryTTY(tty *os.File, query string, timeout time.Duration) []byte {
+	defer makeRaw(tty)()
+
+	_, _ = tty.Write([]byte(query + "\x1b[c"))
 
 	deadline := time.Now().Add(timeout)
 	_ = tty.SetReadDeadline(deadline)
 
 	var buf [1024]byte
-	var acc []byte
-	acc = make([]byte, 0, 2048)
-	daSeen := false
-
+	acc := make([]byte, 0, 2048)
 	for time.Now().Before(deadline) {
 		n, err := tty.Read(buf[:])
 		if n > 0 {
 			acc = append(acc, buf[:n]...)
-			if bytes.Contains(acc, []byte("\x1b_Gi=31;")) || bytes.Contains(acc, []byte("\x1b_Gi=31,")) {
-				return kittyProbeSupported
-			}
-			if hasDA1Response(acc) {
-				daSeen = true
+			if _, ok := parseDA1(acc); ok {
+				return acc
 			}
 		}
 		if err != nil {
 			break
 		}
 	}
+	return acc
+}
 
-	if daSeen {
-		return kittyProbeNotSupported
+// AwaitTTY returns once the terminal has answered a DA1 query, and so has
+// processed everything written before it, or after timeout.
+func AwaitTTY(timeout time.Duration) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return
 	}
-	return kittyProbeUnknown
+	defer func() { _ = tty.Close() }()
+	_ = queryTTY(tty, "", timeout)
 }
 
-func hasDA1Response(b []byte) bool {
-	// Typical primary device attributes response: ESC [ ? ... c
+// makeRaw puts tty in raw mode for the probe so replies arrive without a
+// newline and aren't echoed. It goes through SyscallConn because tty.Fd()
+// would switch the file to blocking mode and break the read deadline.
+func makeRaw(tty *os.File) func() {
+	conn, err := tty.SyscallConn()
+	if err != nil {
+		return func() {}
+	}
+	var state *term.State
+	_ = conn.Control(func(fd uintptr) {
+		state, _ = term.MakeRaw(int(fd))
+	})
+	if state == nil {
+		return func() {}
+	}
+	return func() {
+		_ = conn.Control(func(fd uintptr) {
+			_ = term.Restore(int(fd), state)
+		})
+	}
+}
+
+// parseDA1 finds a primary device attributes response (ESC [ ? 62 ; 4 c)
+// and returns its numeric attributes.
+func parseDA1(b []byte) ([]int, bool) {
 	for i := 0; i+3 < len(b); i++ {
 		if b[i] != 0x1b || b[i+1] != '[' {
 			continue
@@ -58,10 +110,11 @@ func hasDA1Response(b []byte) bool {
 		if j < len(b) && b[j] == '?' {
 			j++
 		}
+		start := j
 		for j < len(b) && j-i < 64 {
 			ch := b[j]
 			if ch == 'c' {
-				return true
+				return parseAttrs(b[start:j]), true
 			}
 			if (ch >= '0' && ch <= '9') || ch == ';' {
 				j++
@@ -70,5 +123,15 @@ func hasDA1Response(b []byte) bool {
 			break
 		}
 	}
-	return false
+	return nil, false
+}
+
+func parseAttrs(b []byte) []int {
+	var out []int
+	for _, part := range bytes.Split(b, []byte(";")) {
+		if v, err := strconv.Atoi(string(part)); err == nil {
+			out = append(out, v)
+		}
+	}
+	return out
 }
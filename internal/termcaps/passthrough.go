@@ -0,0 +1,227 @@
+package termcaps
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Passthrough is the multiplexer graphics escapes have to be tunnelled
+// through to reach the outer terminal.
+type Passthrough int
+
+const (
+	PassthroughNone Passthrough = iota
+	PassthroughTmux
+	PassthroughScreen
+)
+
+func (p Passthrough) String() string {
+	switch p {
+	case PassthroughTmux:
+		return "tmux"
+	case PassthroughScreen:
+		return "screen"
+	default:
+		return "none"
+	}
+}
+
+func DetectPassthrough(getenv func(string) string) Passthrough {
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	if strings.TrimSpace(getenv("TMUX")) != "" {
+		return PassthroughTmux
+	}
+	if strings.TrimSpace(getenv("STY")) != "" {
+		return PassthroughScreen
+	}
+	return PassthroughNone
+}
+
+// screenChunk is the largest DCS string GNU screen passes through.
+const screenChunk = 768
+
+// passthroughWriter wraps Kitty graphics (APC) and iTerm2 (OSC 1337)
+// sequences for the multiplexer and passes everything else unchanged.
+// Sequences may span Write calls.
+type passthroughWriter struct {
+	w    io.Writer
+	mode Passthrough
+	seq  []byte // graphics sequence being collected
+	esc  bool   // last byte outside a sequence was ESC
+}
+
+// NewPassthroughWriter returns w unchanged when p is PassthroughNone.
+func NewPassthroughWriter(w io.Writer, p Passthrough) io.Writer {
+	if p == PassthroughNone {
+		return w
+	}
+	return &passthroughWriter{w: w, mode: p}
+}
+
+// NewGraphicsWriter tunnels the image escapes of protocol through the
+// multiplexer gifgrep runs in, if any; only Kitty and iTerm2 are wrapped.
+func NewGraphicsWriter(w io.Writer, protocol InlineProtocol) io.Writer {
+	if protocol != InlineKitty && protocol != InlineIterm {
+		return w
+	}
+	return NewPassthroughWriter(w, DetectPassthrough(os.Getenv))
+}
+
+func (p *passthroughWriter) Write(b []byte) (int, error) {
+	var out bytes.Buffer
+	for _, c := range b {
+		if p.seq != nil {
+			p.seq = append(p.seq, c)
+			if p.seqDone() {
+				writePassthrough(&out, p.seq, p.mode)
+				p.seq = nil
+			} else if !p.graphicsPrefix() {
+				// Not ours after all: emit as-is.
+				out.Write(p.seq)
+				p.seq = nil
+			}
+			continue
+		}
+		if p.esc {
+			p.esc = false
+			if c == '_' || c == ']' {
+				p.seq = []byte{0x1b, c}
+				continue
+			}
+			out.WriteByte(0x1b)
+		}
+		if c == 0x1b {
+			p.esc = true
+			continue
+		}
+		out.WriteByte(c)
+	}
+	if _, err := p.w.Write(out.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+const osc1337 = "\x1b]1337;"
+
+// graphicsPrefix reports whether the collected bytes can still be an APC or
+// OSC 1337 sequence; other OSCs (hyperlinks, titles) pass through unwrapped.
+func (p *passthroughWriter) graphicsPrefix() bool {
+	if p.seq[1] == '_' || len(p.seq) > len(osc1337) {
+		return true
+	}
+	return strings.HasPrefix(osc1337, string(p.seq))
+}
+
+// seqDone reports whether seq ends with ST (ESC \) or, for OSC, BEL.
+func (p *passthroughWriter) seqDone() bool {
+	n := len(p.seq)
+	if n >= 4 && p.seq[n-2] == 0x1b && p.seq[n-1] == '\\' {
+		return true
+	}
+	return p.seq[1] == ']' && p.seq[n-1] == 0x07
+}
+
+// WrapPassthrough tunnels one escape sequence through multiplexer p.
+func WrapPassthrough(seq []byte, p Passthrough) []byte {
+	var out bytes.Buffer
+	writePassthrough(&out, seq, p)
+	return out.Bytes()
+}
+
+func writePassthrough(out *bytes.Buffer, seq []byte, p Passthrough) {
+	switch p {
+	case PassthroughTmux:
+		out.WriteString("\x1bPtmux;")
+		out.Write(bytes.ReplaceAll(seq, []byte{0x1b}, []byte{0x1b, 0x1b}))
+		out.WriteString("\x1b\\")
+	case PassthroughScreen:
+		// screen ends a DCS at the first ESC \, so the inner terminator is
+		// split across chunks.
+		for len(seq) > 0 {
+			n := min(len(seq), screenChunk)
+			if i := bytes.Index(seq[:n], []byte("\x1b\\")); i >= 0 {
+				n = i + 1
+			}
+			out.WriteString("\x1bP")
+			out.Write(seq[:n])
+			out.WriteString("\x1b\\")
+			seq = seq[n:]
+		}
+	default:
+		out.Write(seq)
+	}
+}
+
+var tmuxCommandFn = func(args ...string) (string, error) {
+	out, err := exec.Command("tmux", args...).Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// TmuxAllowsPassthrough reports whether tmux passes DCS-wrapped sequences
+// to the outer terminal (allow-passthrough, tmux 3.3+; always before).
+func TmuxAllowsPassthrough() bool {
+	out, err := tmuxCommandFn("show-options", "-gv", "allow-passthrough")
+	if err != nil {
+		return true
+	}
+	switch strings.ToLower(out) {
+	case "on", "all":
+		return true
+	}
+	return false
+}
+
+// tmuxEnv describes the terminal tmux is attached to: TERM and TERM_PROGRAM
+// inside tmux name tmux itself. ok is false when tmux blocks passthrough
+// (allow-passthrough off); tmux before 3.3 has no such option and always
+// passes it through.
+func tmuxEnv(getenv func(string) string) (func(string) string, bool) {
+	if !TmuxAllowsPassthrough() {
+		return getenv, false
+	}
+	out, err := tmuxCommandFn("display-message", "-p", "#{client_termname}\t#{client_termtype}")
+	if err != nil {
+		return getenv, true
+	}
+	termName, termType, _ := strings.Cut(out, "\t")
+	return func(key string) string {
+		switch key {
+		case "TERM":
+			if termName != "" {
+				return termName
+			}
+		case "TERM_PROGRAM":
+			if termType != "" {
+				return termType
+			}
+			if lc := getenv("LC_TERMINAL"); lc != "" {
+				return lc
+			}
+		}
+		return getenv(key)
+	}, true
+}
+
+// KittyPlaceholders reports whether Kitty images are drawn as Unicode
+// placeholder cells. They are the default; GIFGREP_KITTY_PLACEHOLDERS=0
+// restores cursor placements for terminals that predate them, except in
+// tmux, where only placeholders stay in place.
+func KittyPlaceholders(getenv func(string) string) bool {
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	if DetectPassthrough(getenv) == PassthroughTmux {
+		return true
+	}
+	switch strings.ToLower(strings.TrimSpace(getenv("GIFGREP_KITTY_PLACEHOLDERS"))) {
+	case "0", "false", "off", "no":
+		return false
+	}
+	return true
+}
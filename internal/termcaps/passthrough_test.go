@@ -0,0 +1,146 @@
+package termcaps
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDetectPassthrough(t *testing.T) {
+	env := func(m map[string]string) func(string) string {
+		return func(k string) string { return m[k] }
+	}
+	if got := DetectPassthrough(env(map[string]string{"TMUX": "/tmp/tmux-1/default,1,0"})); got != PassthroughTmux {
+		t.Fatalf("expected tmux, got %v", got)
+	}
+	if got := DetectPassthrough(env(map[string]string{"STY": "123.pts-0.host"})); got != PassthroughScreen {
+		t.Fatalf("expected screen, got %v", got)
+	}
+	if got := DetectPassthrough(env(nil)); got != PassthroughNone {
+		t.Fatalf("expected none, got %v", got)
+	}
+}
+
+func TestPassthroughWriterTmux(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewPassthroughWriter(&buf, PassthroughTmux)
+	// Split mid-sequence to check state carries across writes.
+	_, _ = w.Write([]byte("hi\x1b[2J\x1b_Ga=T;AA"))
+	_, _ = w.Write([]byte("AA\x1b\\\x1b]8;;https://x\x1b\\\x1b]1337;File=:QQ==\x07"))
+	want := "hi\x1b[2J" +
+		"\x1bPtmux;\x1b\x1b_Ga=T;AAAA\x1b\x1b\\\x1b\\" +
+		"\x1b]8;;https://x\x1b\\" +
+		"\x1bPtmux;\x1b\x1b]1337;File=:QQ==\x07\x1b\\"
+	if got := buf.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestNewGraphicsWriter(t *testing.T) {
+	t.Setenv("TMUX", "/tmp/tmux-1/default,1,0")
+	t.Setenv("STY", "")
+	var buf bytes.Buffer
+	if w := NewGraphicsWriter(&buf, InlineSixel); w != &buf {
+		t.Fatalf("expected sixel output unwrapped")
+	}
+	if _, ok := NewGraphicsWriter(&buf, InlineKitty).(*passthroughWriter); !ok {
+		t.Fatalf("expected kitty output wrapped for tmux")
+	}
+}
+
+func TestPassthroughWriterScreen(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewPassthroughWriter(&buf, PassthroughScreen)
+	payload := strings.Repeat("A", 1000)
+	_, _ = w.Write([]byte("\x1b_G" + payload + "\x1b\\"))
+	got := buf.String()
+	if strings.Count(got, "\x1bP") != 3 {
+		t.Fatalf("expected 3 chunks, got %q", got)
+	}
+	if !strings.HasSuffix(got, "\x1b\x1b\\\x1bP\\\x1b\\") {
+		t.Fatalf("expected inner terminator split across chunks: %q", got[len(got)-16:])
+	}
+	if w := NewPassthroughWriter(&buf, PassthroughNone); w != &buf {
+		t.Fatalf("expected writer unchanged without a multiplexer")
+	}
+}
+
+func TestDetectInlineTmux(t *testing.T) {
+	orig := tmuxCommandFn
+	t.Cleanup(func() { tmuxCommandFn = orig })
+	getenv := func(k string) string {
+		return map[string]string{"TMUX": "/tmp/tmux", "TERM": "tmux-256color", "TERM_PROGRAM": "tmux"}[k]
+	}
+
+	tmuxCommandFn = func(args ...string) (string, error) {
+		if args[0] == "show-options" {
+			return "on", nil
+		}
+		return "xterm-kitty\tkitty(0.35.2)", nil
+	}
+	if got := DetectInlineRobust(getenv); got != InlineKitty {
+		t.Fatalf("expected kitty, got %v", got)
+	}
+
+	tmuxCommandFn = func(args ...string) (string, error) {
+		if args[0] == "show-options" {
+			return "", errors.New("invalid option: allow-passthrough")
+		}
+		return "xterm-256color\tiTerm2 3.5.0", nil
+	}
+	if got := DetectInlineRobust(getenv); got != InlineIterm {
+		t.Fatalf("expected iterm on tmux without the option, got %v", got)
+	}
+
+	tmuxCommandFn = func(args ...string) (string, error) {
+		if args[0] == "show-options" {
+			return "off", nil
+		}
+		return "xterm-kitty\t", nil
+	}
+	if got := DetectInlineRobust(getenv); got != InlineNone {
+		t.Fatalf("expected none with passthrough off, got %v", got)
+	}
+
+	tmuxCommandFn = func(args ...string) (string, error) {
+		if args[0] == "show-options" {
+			return "on", nil
+		}
+		return "xterm-256color\tWezTerm 20240203", nil
+	}
+	if got := DetectInlineRobust(getenv); got != InlineNone {
+		t.Fatalf("expected no sixel through tmux, got %v", got)
+	}
+}
+
+func TestKittyPlaceholders(t *testing.T) {
+	env := func(m map[string]string) func(string) string {
+		return func(k string) string { return m[k] }
+	}
+	if !KittyPlaceholders(env(nil)) {
+		t.Fatalf("expected placeholders by default")
+	}
+	if KittyPlaceholders(env(map[string]string{"GIFGREP_KITTY_PLACEHOLDERS": "0"})) {
+		t.Fatalf("expected opt-out")
+	}
+	if !KittyPlaceholders(env(map[string]string{"GIFGREP_KITTY_PLACEHOLDERS": "off", "TMUX": "/tmp/tmux"})) {
+		t.Fatalf("expected placeholders in tmux regardless")
+	}
+}
+
+func TestWrapPassthrough(t *testing.T) {
+	seq := []byte("\x1b]52;c;eA==\x07")
+	if got := string(WrapPassthrough(seq, PassthroughTmux)); got != "\x1bPtmux;\x1b\x1b]52;c;eA==\x07\x1b\\" {
+		t.Fatalf("unexpected tmux wrapping %q", got)
+	}
+	if got := string(WrapPassthrough(seq, PassthroughNone)); got != string(seq) {
+		t.Fatalf("expected no wrapping, got %q", got)
+	}
+	prev := tmuxCommandFn
+	t.Cleanup(func() { tmuxCommandFn = prev })
+	tmuxCommandFn = func(...string) (string, error) { return "off", nil }
+	if TmuxAllowsPassthrough() {
+		t.Fatal("expected allow-passthrough off to block")
+	}
+}
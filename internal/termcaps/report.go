@@ -0,0 +1,65 @@
+package termcaps
+
+import (
+	"os"
+	"regexp"
+	"time"
+)
+
+// RawProbe holds the terminal's verbatim replies to the detection queries,
+// for bug reports. Empty fields weren't answered.
+type RawProbe struct {
+	Kitty     string `json:"kitty"`
+	DA1       string `json:"da1"`
+	DA2       string `json:"da2"`
+	XTVersion string `json:"xtversion"`
+	CellSize  string `json:"cell_size"`
+	WinSize   string `json:"window_size"`
+}
+
+var (
+	kittyReplyRe = regexp.MustCompile(`\x1b_G[^\x1b]*\x1b\\`)
+	da1Re        = regexp.MustCompile(`\x1b\[\?[0-9;]*c`)
+	da2Re        = regexp.MustCompile(`\x1b\[>[0-9;]*c`)
+	xtversionRe  = regexp.MustCompile(`\x1bP>\|[^\x1b]*\x1b\\`)
+	cellRe       = regexp.MustCompile(`\x1b\[6;[0-9;]*t`)
+	winRe        = regexp.MustCompile(`\x1b\[4;[0-9;]*t`)
+)
+
+// ProbeRaw sends the Kitty graphics query, XTVERSION, DA2, CSI 16 t and
+// CSI 14 t, then DA1, and returns the replies. Inside tmux or screen, the
+// multiplexer answers most of them itself.
+func ProbeRaw() RawProbe {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return RawProbe{}
+	}
+	defer func() { _ = tty.Close() }()
+	acc := queryTTY(tty, "\x1b_Gi=31,s=1,v=1,a=q,t=d,f=24;AAAA\x1b\\\x1b[>0q\x1b[>c\x1b[16t\x1b[14t", 300*time.Millisecond)
+	return parseRawProbe(acc)
+}
+
+func parseRawProbe(acc []byte) RawProbe {
+	find := func(re *regexp.Regexp) string {
+		return string(re.Find(acc))
+	}
+	return RawProbe{
+		Kitty:     find(kittyReplyRe),
+		DA1:       find(da1Re),
+		DA2:       find(da2Re),
+		XTVersion: find(xtversionRe),
+		CellSize:  find(cellRe),
+		WinSize:   find(winRe),
+	}
+}
+
+// TmuxAllowPassthrough returns tmux's allow-passthrough option, or "" when
+// tmux doesn't know it (before 3.3, where passthrough is always allowed)
+// or isn't reachable.
+func TmuxAllowPassthrough() string {
+	out, err := tmuxCommandFn("show-options", "-gv", "allow-passthrough")
+	if err != nil {
+		return ""
+	}
+	return out
+}
@@ -0,0 +1,22 @@
+package termcaps
+
+import "testing"
+
+func TestParseRawProbe(t *testing.T) {
+	acc := []byte("\x1b_Gi=31;OK\x1b\\\x1bP>|kitty(0.35.2)\x1b\\\x1b[>1;4000;29c\x1b[6;20;10t\x1b[4;800;1200t\x1b[?62;c")
+	got := parseRawProbe(acc)
+	want := RawProbe{
+		Kitty:     "\x1b_Gi=31;OK\x1b\\",
+		DA1:       "\x1b[?62;c",
+		DA2:       "\x1b[>1;4000;29c",
+		XTVersion: "\x1bP>|kitty(0.35.2)\x1b\\",
+		CellSize:  "\x1b[6;20;10t",
+		WinSize:   "\x1b[4;800;1200t",
+	}
+	if got != want {
+		t.Fatalf("unexpected probe: %#v", got)
+	}
+	if got := parseRawProbe([]byte("\x1b[?1;2c")); got.Kitty != "" || got.DA1 != "\x1b[?1;2c" {
+		t.Fatalf("expected DA1 only, got %#v", got)
+	}
+}
@@ -0,0 +1,61 @@
+package termcaps
+
+import (
+	"os"
+	"strings"
+)
+
+// TextStyle is how the text-mode preview (InlineBlocks) draws pixels.
+type TextStyle int
+
+const (
+	TextTruecolor TextStyle = iota // ▀ half-blocks with 24-bit colors
+	Text256                        // ▀ half-blocks with the xterm 256-color palette
+	TextBraille                    // monochrome braille dots, 2×4 per cell
+)
+
+func (s TextStyle) String() string {
+	switch s {
+	case TextTruecolor:
+		return "truecolor"
+	case Text256:
+		return "256"
+	case TextBraille:
+		return "braille"
+	default:
+		return "truecolor"
+	}
+}
+
+// DetectTextStyle picks the richest text style the terminal advertises.
+// GIFGREP_TEXT_STYLE=truecolor|256|braille overrides it.
+func DetectTextStyle(getenv func(string) string) TextStyle {
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	switch strings.ToLower(strings.TrimSpace(getenv("GIFGREP_TEXT_STYLE"))) {
+	case "truecolor", "24bit":
+		return TextTruecolor
+	case "256":
+		return Text256
+	case "braille", "mono":
+		return TextBraille
+	}
+
+	if getenv("NO_COLOR") != "" {
+		return TextBraille
+	}
+	colorTerm := strings.ToLower(getenv("COLORTERM"))
+	if colorTerm == "truecolor" || colorTerm == "24bit" {
+		return TextTruecolor
+	}
+	termProgram := strings.ToLower(getenv("TERM_PROGRAM"))
+	if termProgram == "vscode" || strings.Contains(termProgram, "wezterm") || strings.Contains(termProgram, "iterm") {
+		return TextTruecolor
+	}
+	termEnv := strings.ToLower(getenv("TERM"))
+	if termEnv == "" || termEnv == "dumb" || termEnv == "linux" || termEnv == "vt100" {
+		return TextBraille
+	}
+	return Text256
+}
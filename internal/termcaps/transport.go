@@ -0,0 +1,94 @@
+package termcaps
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"strings"
+	"time"
+)
+
+// KittyTransport is how Kitty image data reaches the terminal.
+type KittyTransport int
+
+const (
+	// KittyDirect base64-encodes data into the escape sequences; it works
+	// over SSH and through multiplexers.
+	KittyDirect KittyTransport = iota
+	// KittyFile writes a temp file the terminal reads and deletes (t=t).
+	KittyFile
+	// KittyShm writes POSIX shared memory (t=s); Linux only.
+	KittyShm
+)
+
+func (t KittyTransport) String() string {
+	switch t {
+	case KittyFile:
+		return "file"
+	case KittyShm:
+		return "shm"
+	default:
+		return "direct"
+	}
+}
+
+// KittyTempPattern names probe and transfer files; Kitty only deletes t=t
+// files whose path contains "tty-graphics-protocol".
+const KittyTempPattern = "gifgrep-tty-graphics-protocol-*"
+
+func DetectKittyTransport(getenv func(string) string) KittyTransport {
+	return detectKittyTransport(getenv, func() bool {
+		tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+		if err != nil {
+			return false
+		}
+		defer func() { _ = tty.Close() }()
+		return probeKittyFile(tty, 150*time.Millisecond)
+	})
+}
+
+func detectKittyTransport(getenv func(string) string, probe func() bool) KittyTransport {
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	switch strings.ToLower(strings.TrimSpace(getenv("GIFGREP_KITTY_TRANSPORT"))) {
+	case "direct":
+		return KittyDirect
+	case "file":
+		return KittyFile
+	case "shm":
+		return KittyShm
+	}
+	// A remote terminal can't see our files, and multiplexers keep the
+	// probe's reply to themselves.
+	for _, key := range []string{"SSH_CONNECTION", "SSH_CLIENT", "SSH_TTY"} {
+		if strings.TrimSpace(getenv(key)) != "" {
+			return KittyDirect
+		}
+	}
+	if DetectPassthrough(getenv) != PassthroughNone {
+		return KittyDirect
+	}
+	if probe() {
+		return KittyFile
+	}
+	return KittyDirect
+}
+
+// probeKittyFile asks the terminal to load a 1×1 image from a temp file
+// (a=q only checks, nothing is stored). An OK reply means it shares our
+// filesystem.
+func probeKittyFile(tty *os.File, timeout time.Duration) bool {
+	f, err := os.CreateTemp("", KittyTempPattern)
+	if err != nil {
+		return false
+	}
+	path := f.Name()
+	defer func() { _ = os.Remove(path) }()
+	_, err = f.Write([]byte{0, 0, 0})
+	if cerr := f.Close(); err != nil || cerr != nil {
+		return false
+	}
+	query := "\x1b_Gi=32,s=1,v=1,a=q,t=t,f=24;" + base64.StdEncoding.EncodeToString([]byte(path)) + "\x1b\\"
+	return bytes.Contains(queryTTY(tty, query, timeout), []byte("\x1b_Gi=32;OK"))
+}
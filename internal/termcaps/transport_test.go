@@ -0,0 +1,27 @@
+package termcaps
+
+import "testing"
+
+func TestDetectKittyTransport(t *testing.T) {
+	env := func(m map[string]string) func(string) string {
+		return func(k string) string { return m[k] }
+	}
+	probed := false
+	probe := func() bool { probed = true; return true }
+
+	if got := detectKittyTransport(env(map[string]string{"GIFGREP_KITTY_TRANSPORT": "shm"}), probe); got != KittyShm || probed {
+		t.Fatalf("expected forced shm without probing, got %v", got)
+	}
+	if got := detectKittyTransport(env(map[string]string{"SSH_CONNECTION": "1.2.3.4 5 6.7.8.9 22"}), probe); got != KittyDirect || probed {
+		t.Fatalf("expected direct over ssh, got %v", got)
+	}
+	if got := detectKittyTransport(env(map[string]string{"TMUX": "/tmp/tmux"}), probe); got != KittyDirect || probed {
+		t.Fatalf("expected direct in tmux, got %v", got)
+	}
+	if got := detectKittyTransport(env(nil), probe); got != KittyFile || !probed {
+		t.Fatalf("expected file after a successful probe, got %v", got)
+	}
+	if got := detectKittyTransport(env(nil), func() bool { return false }); got != KittyDirect {
+		t.Fatalf("expected direct when the probe fails, got %v", got)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package termcaps
+
+import "os"
+
+func winsize(_ *os.File) (cols, rows, xpixel, ypixel int, ok bool) {
+	return 0, 0, 0, 0, false
+}
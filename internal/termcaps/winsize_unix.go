@@ -0,0 +1,26 @@
+//go:build unix
+
+package termcaps
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// winsize reads TIOCGWINSZ. It goes through SyscallConn so the tty stays
+// non-blocking (see makeRaw).
+func winsize(tty *os.File) (cols, rows, xpixel, ypixel int, ok bool) {
+	conn, err := tty.SyscallConn()
+	if err != nil {
+		return 0, 0, 0, 0, false
+	}
+	var ws *unix.Winsize
+	_ = conn.Control(func(fd uintptr) {
+		ws, err = unix.IoctlGetWinsize(int(fd), unix.TIOCGWINSZ)
+	})
+	if err != nil || ws == nil {
+		return 0, 0, 0, 0, false
+	}
+	return int(ws.Col), int(ws.Row), int(ws.Xpixel), int(ws.Ypixel), true
+}
@@ -19,14 +19,14 @@ type FakeTransport struct {
 func (t *FakeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	switch req.URL.Host {
 	case "api.tenor.com":
-		body := `{"results":[{"id":"1","title":"Cat One","content_description":"","tags":["cat","fun"],"media":[{"gif":{"url":"https://example.test/full.gif","dims":[200,100]},"tinygif":{"url":"https://example.test/preview.gif","dims":[50,25]}}]}]}`
+		body := `{"results":[{"id":"1","title":"Cat One","content_description":"","tags":["cat","fun"],"media":[{"gif":{"url":"https://example.test/full.gif","dims":[200,100]},"tinygif":{"url":"https://example.test/preview.gif","dims":[50,25]},"mp4":{"url":"https://example.test/full.mp4","dims":[200,100],"size":4096},"tinymp4":{"url":"https://example.test/preview.mp4","dims":[50,25],"size":512}}]}]}`
 		return &http.Response{
 			StatusCode: http.StatusOK,
 			Header:     http.Header{"Content-Type": []string{"application/json"}},
 			Body:       io.NopCloser(strings.NewReader(body)),
 		}, nil
 	case "api.giphy.com":
-		body := `{"data":[{"id":"g1","title":"Cat One","images":{"original":{"url":"https://example.test/full.gif","width":"200","height":"100"},"fixed_width_small":{"url":"https://example.test/preview.gif","width":"50","height":"25"}}}]}`
+		body := `{"data":[{"id":"g1","title":"Cat One","images":{"original":{"url":"https://example.test/full.gif","width":"200","height":"100","size":"8192","mp4":"https://example.test/full.mp4","mp4_size":"4096","webp":"https://example.test/full.webp","webp_size":"2048"},"fixed_width_small":{"url":"https://example.test/preview.gif","width":"50","height":"25","webp":"https://example.test/preview.webp"},"original_still":{"url":"https://example.test/still.gif","width":"200","height":"100"}}}]}`
 		return &http.Response{
 			StatusCode: http.StatusOK,
 			Header:     http.Header{"Content-Type": []string{"application/json"}},
@@ -80,3 +80,19 @@ func MakeTestGIF() []byte {
 	_ = gif.EncodeAll(&buf, g)
 	return buf.Bytes()
 }
+
+// MakeLongGIF is a 2x2 GIF with n frames of 50ms each, for checks past
+// the decoder's default frame limit.
+func MakeLongGIF(n int) []byte {
+	pal := color.Palette{color.Black, color.White}
+	g := &gif.GIF{Config: image.Config{Width: 2, Height: 2, ColorModel: pal}}
+	for i := 0; i < n; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, 2, 2), pal)
+		frame.SetColorIndex(i%2, i/2%2, 1)
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 5)
+	}
+	var buf bytes.Buffer
+	_ = gif.EncodeAll(&buf, g)
+	return buf.Bytes()
+}
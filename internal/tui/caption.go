@@ -0,0 +1,108 @@
+package tui
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/steipete/gifgrep/internal/caption"
+	"github.com/steipete/gifgrep/internal/download"
+)
+
+var (
+	captionGIFFn       = caption.GIF
+	bytesToDownloadsFn = download.BytesToDownloads
+)
+
+func handleCaptionInput(state *appState, ev inputEvent, out *bufio.Writer) bool {
+	switch ev.kind {
+	case keyRune:
+		state.captionText += string(ev.ch)
+		state.renderDirty = true
+	case keyBackspace:
+		if len(state.captionText) > 0 {
+			runes := []rune(state.captionText)
+			state.captionText = string(runes[:len(runes)-1])
+			state.renderDirty = true
+		}
+	case keyEnter:
+		state.mode = modeBrowse
+		state.status = ""
+		captionSelected(state, out)
+		state.captionText = ""
+	case keyEsc:
+		state.mode = modeBrowse
+		state.status = ""
+		state.captionText = ""
+		state.renderDirty = true
+	case keyCtrlC:
+		return true
+	case keyUp, keyDown, keyUnknown:
+		// ignore
+	}
+	return false
+}
+
+// parseCaptionText splits "top | bottom"; text without a bar goes to the
+// bottom, the usual spot for reaction captions.
+func parseCaptionText(text string) caption.Options {
+	top, bottom, ok := strings.Cut(text, "|")
+	if !ok {
+		return caption.Options{Bottom: strings.TrimSpace(text)}
+	}
+	return caption.Options{Top: strings.TrimSpace(top), Bottom: strings.TrimSpace(bottom)}
+}
+
+func captionSelected(state *appState, out *bufio.Writer) {
+	if state.selected < 0 || state.selected >= len(state.results) {
+		flashHeader(state, "No selection")
+		state.renderDirty = true
+		return
+	}
+	opts := parseCaptionText(state.captionText)
+	if opts.Top == "" && opts.Bottom == "" {
+		flashHeader(state, "Empty caption")
+		state.renderDirty = true
+		return
+	}
+	item := state.results[state.selected]
+	flashHeader(state, "Captioning…")
+	state.renderDirty = true
+	render(state, out, state.lastRows, state.lastCols)
+	_ = out.Flush()
+
+	filePath := gifPathForResult(state, item)
+	if filePath == "" {
+		flashHeader(state, "GIF not available")
+		state.renderDirty = true
+		return
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		flashHeader(state, "Caption failed: "+err.Error())
+		state.renderDirty = true
+		return
+	}
+	output, err := captionGIFFn(data, opts)
+	if err != nil {
+		flashHeader(state, "Caption failed: "+err.Error())
+		state.renderDirty = true
+		return
+	}
+	savedPath, err := bytesToDownloadsFn(item, "-caption", output)
+	if err != nil {
+		flashHeader(state, "Save failed: "+err.Error())
+		state.renderDirty = true
+		return
+	}
+	state.lastSavedPath = savedPath
+	if state.opts.Reveal {
+		if err := revealFn(savedPath); err != nil {
+			flashHeader(state, "Captioned (reveal failed)")
+			state.renderDirty = true
+			return
+		}
+	}
+	flashHeader(state, "Captioned")
+	state.renderDirty = true
+}
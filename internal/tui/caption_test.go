@@ -0,0 +1,87 @@
+package tui
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/steipete/gifgrep/internal/caption"
+	"github.com/steipete/gifgrep/internal/model"
+)
+
+func TestParseCaptionText(t *testing.T) {
+	if got := parseCaptionText(" top | bottom "); got.Top != "top" || got.Bottom != "bottom" {
+		t.Fatalf("unexpected split: %+v", got)
+	}
+	if got := parseCaptionText("just this"); got.Top != "" || got.Bottom != "just this" {
+		t.Fatalf("expected bottom-only caption: %+v", got)
+	}
+}
+
+func TestCaptionModeSavesCaptionedCopy(t *testing.T) {
+	tmp, err := os.CreateTemp(t.TempDir(), "gifgrep-*.gif")
+	if err != nil {
+		t.Fatalf("CreateTemp: %v", err)
+	}
+	_, _ = tmp.WriteString("GIF89a")
+	_ = tmp.Close()
+
+	origCaption, origSave := captionGIFFn, bytesToDownloadsFn
+	t.Cleanup(func() { captionGIFFn, bytesToDownloadsFn = origCaption, origSave })
+
+	var gotOpts caption.Options
+	captionGIFFn = func(data []byte, opts caption.Options) ([]byte, error) {
+		gotOpts = opts
+		return append([]byte("captioned:"), data...), nil
+	}
+	var saved []byte
+	bytesToDownloadsFn = func(_ model.Result, suffix string, data []byte) (string, error) {
+		saved = data
+		return "/tmp/one" + suffix + ".gif", nil
+	}
+
+	state := &appState{
+		mode:      modeBrowse,
+		results:   []model.Result{{ID: "1", URL: "https://example.test/1.gif", Title: "one"}},
+		lastRows:  24,
+		lastCols:  80,
+		tempPaths: map[string]string{"id:1": tmp.Name()},
+		cache:     map[string]*gifCacheEntry{},
+	}
+	out := bufio.NewWriter(bytes.NewBuffer(nil))
+	handleInput(state, inputEvent{kind: keyRune, ch: 't'}, out, nil)
+	if state.mode != modeCaption {
+		t.Fatalf("expected caption mode")
+	}
+	for _, r := range "quit | now" {
+		if handleInput(state, inputEvent{kind: keyRune, ch: r}, out, nil) {
+			t.Fatalf("typing %q should not quit", r)
+		}
+	}
+	handleInput(state, inputEvent{kind: keyEnter}, out, nil)
+
+	if gotOpts.Top != "quit" || gotOpts.Bottom != "now" {
+		t.Fatalf("unexpected caption options: %+v", gotOpts)
+	}
+	if string(saved) != "captioned:GIF89a" {
+		t.Fatalf("unexpected saved data %q", saved)
+	}
+	if state.mode != modeBrowse || state.headerFlash != "Captioned" || state.lastSavedPath != "/tmp/one-caption.gif" {
+		t.Fatalf("unexpected state: mode=%v flash=%q path=%q", state.mode, state.headerFlash, state.lastSavedPath)
+	}
+	if state.status != "" || state.captionText != "" {
+		t.Fatalf("expected the prompt cleared, got status=%q text=%q", state.status, state.captionText)
+	}
+
+	state.captionText = "stale"
+	handleInput(state, inputEvent{kind: keyRune, ch: 't'}, out, nil)
+	if state.captionText != "" {
+		t.Fatalf("expected a fresh caption, got %q", state.captionText)
+	}
+	handleInput(state, inputEvent{kind: keyRune, ch: 'x'}, out, nil)
+	handleInput(state, inputEvent{kind: keyEsc}, out, nil)
+	if state.mode != modeBrowse || state.status != "" || state.captionText != "" {
+		t.Fatalf("expected Esc to clear the prompt, got status=%q text=%q", state.status, state.captionText)
+	}
+}
@@ -0,0 +1,65 @@
+package tui
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/steipete/gifgrep/internal/termcaps"
+)
+
+func TestCellSizeReplyUpdatesPreviewAspect(t *testing.T) {
+	t.Cleanup(func() { termcaps.SetCellSize(termcaps.CellSize{}) })
+	t.Setenv("GIFGREP_CELL_ASPECT", "")
+
+	r := bytes.NewReader([]byte("\x1b[6;20;10t\x1b[1;5A"))
+	ch := make(chan inputEvent, 4)
+	readInput(r, ch, make(chan struct{}))
+	close(ch)
+	ev := <-ch
+	if ev.kind != keyCellSize || ev.text != "6;20;10" {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+	if next := <-ch; next.kind != keyUnknown {
+		t.Fatalf("expected modified arrow to stay unknown, got %+v", next)
+	}
+
+	state := &appState{}
+	handleInput(state, ev, nil, nil)
+	if !state.previewDirty || cellAspectRatio() != 0.5 {
+		t.Fatalf("expected 10x20 cells to apply")
+	}
+	// 10x20 cells: a square GIF 20 cols wide is 10 rows tall.
+	if cols, rows := fitPreviewSize(20, 40, &gifAnimation{Width: 100, Height: 100}); cols != 20 || rows != 10 {
+		t.Fatalf("unexpected fit %dx%d", cols, rows)
+	}
+	termcaps.SetCellSize(termcaps.CellSize{Width: 10, Height: 10})
+	if cols, rows := fitPreviewSize(20, 40, &gifAnimation{Width: 100, Height: 100}); cols != 20 || rows != 20 {
+		t.Fatalf("unexpected fit with square cells %dx%d", cols, rows)
+	}
+}
+
+func TestResizeRequeriesCellSize(t *testing.T) {
+	prev := refreshCellSizeFn
+	t.Cleanup(func() { refreshCellSizeFn = prev })
+
+	refreshed := false
+	refreshCellSizeFn = func() bool { refreshed = true; return false }
+	env := Env{FD: 0, GetSize: func(int) (int, int, error) { return 100, 40, nil }}
+	state := &appState{lastCols: 80, lastRows: 24}
+	var buf bytes.Buffer
+	out := bufio.NewWriter(&buf)
+	updateSizeIfNeeded(state, env, out)
+	_ = out.Flush()
+	if !refreshed || buf.String() != "\x1b[16t" {
+		t.Fatalf("expected TIOCGWINSZ then CSI 16 t fallback, got %q", buf.String())
+	}
+
+	buf.Reset()
+	refreshed = false
+	updateSizeIfNeeded(state, env, out)
+	_ = out.Flush()
+	if refreshed || buf.Len() != 0 {
+		t.Fatalf("expected no query without a resize")
+	}
+}
@@ -2,15 +2,36 @@ package tui
 
 import (
 	"bufio"
+	"errors"
 	"os"
 
 	"github.com/steipete/gifgrep/internal/clipboard"
 	"github.com/steipete/gifgrep/internal/model"
 )
 
-var copyToClipboardFn = clipboard.CopyFile
+var copyPayloadFn = clipboard.Copy
+
+var copyFlash = map[clipboard.Format]string{
+	clipboard.FormatURL:      "Copied URL",
+	clipboard.FormatMarkdown: "Copied Markdown",
+	clipboard.FormatHTML:     "Copied HTML",
+	clipboard.FormatGIF:      "Copied to clipboard",
+	clipboard.FormatDataURI:  "Copied data URI",
+}
 
 func copySelected(state *appState, out *bufio.Writer) {
+	copySelectedAs(state, out, clipboard.FormatGIF)
+}
+
+// copySelectedAs copies the selected result, or every marked one. Text
+// formats work over SSH through OSC 52, which is written to out; the GIF
+// needs a local file and the system clipboard, and falls back to its URL
+// when only OSC 52 is there.
+func copySelectedAs(state *appState, out *bufio.Writer, format clipboard.Format) {
+	if items := markedResults(state); len(items) > 0 {
+		copyMarkedAs(state, out, format, items)
+		return
+	}
 	if state.selected < 0 || state.selected >= len(state.results) {
 		flashHeader(state, "No selection")
 		state.renderDirty = true
@@ -22,24 +43,48 @@ func copySelected(state *appState, out *bufio.Writer) {
 		state.renderDirty = true
 		return
 	}
-	flashHeader(state, "Copying…")
-	state.renderDirty = true
-	render(state, out, state.lastRows, state.lastCols)
-	_ = out.Flush()
-
-	filePath := gifPathForResult(state, item)
-	if filePath == "" {
-		flashHeader(state, "GIF not available")
+	src := clipboard.Source{URL: item.URL, Title: item.Title}
+	if format == clipboard.FormatGIF || format == clipboard.FormatDataURI {
+		flashHeader(state, "Copying…")
 		state.renderDirty = true
-		return
+		render(state, out, state.lastRows, state.lastCols)
+		_ = out.Flush()
+
+		src.Path = gifPathForResult(state, item)
+		if src.Path == "" {
+			flashHeader(state, "GIF not available")
+			state.renderDirty = true
+			return
+		}
+		if format == clipboard.FormatDataURI {
+			data, err := os.ReadFile(src.Path)
+			if err != nil {
+				flashHeader(state, "Copy failed: "+err.Error())
+				state.renderDirty = true
+				return
+			}
+			src.Data = data
+		}
 	}
 
-	if err := copyToClipboardFn(filePath); err != nil {
+	flash := copyFlash[format]
+	p, err := clipboard.NewPayload(format, src)
+	if err == nil {
+		err = copyPayloadFn(p, out)
+	}
+	if errors.Is(err, clipboard.ErrTextOnly) {
+		flash = "Copied URL · the terminal clipboard only takes text"
+		p, err = clipboard.NewPayload(clipboard.FormatURL, src)
+		if err == nil {
+			err = copyPayloadFn(p, out)
+		}
+	}
+	if err != nil {
 		flashHeader(state, "Copy failed: "+err.Error())
 		state.renderDirty = true
 		return
 	}
-	flashHeader(state, "Copied to clipboard")
+	flashHeader(state, flash)
 	state.renderDirty = true
 }
 
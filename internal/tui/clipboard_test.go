@@ -4,9 +4,12 @@ import (
 	"bufio"
 	"bytes"
 	"errors"
+	"io"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/steipete/gifgrep/internal/clipboard"
 	"github.com/steipete/gifgrep/internal/model"
 )
 
@@ -17,12 +20,12 @@ func TestCopySelectedUsesTempPath(t *testing.T) {
 	}
 	_ = tmp.Close()
 
-	orig := copyToClipboardFn
-	t.Cleanup(func() { copyToClipboardFn = orig })
+	orig := copyPayloadFn
+	t.Cleanup(func() { copyPayloadFn = orig })
 
 	var copied string
-	copyToClipboardFn = func(path string) error {
-		copied = path
+	copyPayloadFn = func(p clipboard.Payload, _ io.Writer) error {
+		copied = p[0].Path
 		return nil
 	}
 
@@ -53,12 +56,12 @@ func TestCopySelectedUsesSavedPath(t *testing.T) {
 	}
 	_ = tmp.Close()
 
-	orig := copyToClipboardFn
-	t.Cleanup(func() { copyToClipboardFn = orig })
+	orig := copyPayloadFn
+	t.Cleanup(func() { copyPayloadFn = orig })
 
 	var copied string
-	copyToClipboardFn = func(path string) error {
-		copied = path
+	copyPayloadFn = func(p clipboard.Payload, _ io.Writer) error {
+		copied = p[0].Path
 		return nil
 	}
 
@@ -81,12 +84,12 @@ func TestCopySelectedUsesSavedPath(t *testing.T) {
 }
 
 func TestCopySelectedWritesCacheToTemp(t *testing.T) {
-	orig := copyToClipboardFn
-	t.Cleanup(func() { copyToClipboardFn = orig })
+	orig := copyPayloadFn
+	t.Cleanup(func() { copyPayloadFn = orig })
 
 	var copied string
-	copyToClipboardFn = func(path string) error {
-		copied = path
+	copyPayloadFn = func(p clipboard.Payload, _ io.Writer) error {
+		copied = p[0].Path
 		return nil
 	}
 
@@ -128,10 +131,10 @@ func TestCopySelectedHandlesError(t *testing.T) {
 	}
 	_ = tmp.Close()
 
-	orig := copyToClipboardFn
-	t.Cleanup(func() { copyToClipboardFn = orig })
+	orig := copyPayloadFn
+	t.Cleanup(func() { copyPayloadFn = orig })
 
-	copyToClipboardFn = func(string) error {
+	copyPayloadFn = func(clipboard.Payload, io.Writer) error {
 		return errors.New("clipboard broken")
 	}
 
@@ -204,3 +207,84 @@ func TestCopySelectedNoAvailablePath(t *testing.T) {
 		t.Fatalf("expected 'GIF not available' flash, got %q", state.headerFlash)
 	}
 }
+
+func TestCopySelectedAsText(t *testing.T) {
+	orig := copyPayloadFn
+	t.Cleanup(func() { copyPayloadFn = orig })
+
+	var copied clipboard.Payload
+	var tty io.Writer
+	copyPayloadFn = func(p clipboard.Payload, w io.Writer) error {
+		copied, tty = p, w
+		return nil
+	}
+
+	state := &appState{
+		results:  []model.Result{{ID: "1", URL: "https://example.test/1.gif", Title: "one"}},
+		selected: 0,
+		cache:    map[string]*gifCacheEntry{},
+	}
+	out := bufio.NewWriter(bytes.NewBuffer(nil))
+
+	copySelectedAs(state, out, clipboard.FormatURL)
+	if text, _ := copied.Text(); text != "https://example.test/1.gif" {
+		t.Fatalf("unexpected copy %q", text)
+	}
+	if tty != out {
+		t.Fatalf("expected OSC 52 to go through the TUI writer")
+	}
+	if state.headerFlash != "Copied URL" {
+		t.Fatalf("expected flash 'Copied URL', got %q", state.headerFlash)
+	}
+
+	copySelectedAs(state, out, clipboard.FormatMarkdown)
+	if text, _ := copied.Text(); text != "![one](https://example.test/1.gif)" {
+		t.Fatalf("unexpected markdown %q", text)
+	}
+
+	copySelectedAs(state, out, clipboard.FormatHTML)
+	if copied[0].MIME != "text/html" || state.headerFlash != "Copied HTML" {
+		t.Fatalf("expected html first, got %#v (%q)", copied, state.headerFlash)
+	}
+}
+
+func TestCopySelectedFallsBackToURLOverOSC52(t *testing.T) {
+	tmp := filepath.Join(t.TempDir(), "a.gif")
+	if err := os.WriteFile(tmp, []byte("GIF89a"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	orig := copyPayloadFn
+	t.Cleanup(func() { copyPayloadFn = orig })
+	var copied clipboard.Payload
+	copyPayloadFn = func(p clipboard.Payload, _ io.Writer) error {
+		if p[0].Path != "" {
+			return clipboard.ErrTextOnly
+		}
+		copied = p
+		return nil
+	}
+
+	state := &appState{
+		results:   []model.Result{{ID: "1", URL: "https://example.test/1.gif", Title: "one"}},
+		lastRows:  24,
+		lastCols:  80,
+		tempPaths: map[string]string{"id:1": tmp},
+		cache:     map[string]*gifCacheEntry{},
+	}
+	out := bufio.NewWriter(bytes.NewBuffer(nil))
+	copySelected(state, out)
+	if text, _ := copied.Text(); text != "https://example.test/1.gif" {
+		t.Fatalf("expected the URL copied instead, got %q", text)
+	}
+	if state.headerFlash != "Copied URL · the terminal clipboard only takes text" {
+		t.Fatalf("unexpected flash %q", state.headerFlash)
+	}
+
+	copySelectedAs(state, out, clipboard.FormatDataURI)
+	if text, _ := copied.Text(); text != "data:image/gif;base64,R0lGODlh" {
+		t.Fatalf("expected a data URI, got %q", text)
+	}
+	if state.headerFlash != "Copied data URI" {
+		t.Fatalf("unexpected flash %q", state.headerFlash)
+	}
+}
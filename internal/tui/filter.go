@@ -0,0 +1,317 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/internal/model"
+)
+
+// squareSlack is how far from 1:1 an aspect ratio may be and still count
+// as square.
+const squareSlack = 1.1
+
+// resultFilter narrows and orders the search results locally. It is
+// parsed from the filter prompt: words match titles and tags, wide, tall
+// and square match the aspect ratio, WxH (320x, x200) sets minimum
+// dimensions, frames>=N and frames<=N bound the frame count, and
+// sort:size|dims|duration orders the list (sort:-size reverses).
+type resultFilter struct {
+	words      []string
+	aspect     string
+	minW, minH int
+	minFrames  int
+	maxFrames  int
+	sortBy     string
+	desc       bool
+}
+
+func parseFilter(text string) (resultFilter, error) {
+	var f resultFilter
+	for _, tok := range strings.Fields(strings.ToLower(text)) {
+		switch {
+		case tok == "wide" || tok == "tall" || tok == "square":
+			f.aspect = tok
+		case strings.HasPrefix(tok, "sort:"):
+			by := strings.TrimPrefix(tok, "sort:")
+			f.desc = strings.HasPrefix(by, "-")
+			by = strings.TrimPrefix(by, "-")
+			switch by {
+			case "size", "dims", "duration":
+				f.sortBy = by
+			default:
+				return resultFilter{}, fmt.Errorf("sort by size, dims or duration, not %q", by)
+			}
+		case strings.HasPrefix(tok, "frames>="):
+			n, err := strconv.Atoi(strings.TrimPrefix(tok, "frames>="))
+			if err != nil || n < 0 {
+				return resultFilter{}, fmt.Errorf("bad frame count %q", tok)
+			}
+			f.minFrames = n
+		case strings.HasPrefix(tok, "frames<="):
+			n, err := strconv.Atoi(strings.TrimPrefix(tok, "frames<="))
+			if err != nil || n < 1 {
+				return resultFilter{}, fmt.Errorf("bad frame count %q", tok)
+			}
+			f.maxFrames = n
+		default:
+			if w, h, ok := parseMinDims(tok); ok {
+				f.minW, f.minH = w, h
+				continue
+			}
+			f.words = append(f.words, tok)
+		}
+	}
+	return f, nil
+}
+
+// parseMinDims reads WxH, Wx or xH.
+func parseMinDims(tok string) (w, h int, ok bool) {
+	ws, hs, found := strings.Cut(tok, "x")
+	if !found || (ws == "" && hs == "") {
+		return 0, 0, false
+	}
+	var err error
+	if ws != "" {
+		if w, err = strconv.Atoi(ws); err != nil || w < 0 {
+			return 0, 0, false
+		}
+	}
+	if hs != "" {
+		if h, err = strconv.Atoi(hs); err != nil || h < 0 {
+			return 0, 0, false
+		}
+	}
+	return w, h, true
+}
+
+// needsFrames reports whether the filter uses frame counts or durations,
+// which are only known once a result's preview is decoded.
+func (f resultFilter) needsFrames() bool {
+	return f.minFrames > 0 || f.maxFrames > 0 || f.sortBy == "duration"
+}
+
+func (f resultFilter) active() bool {
+	return len(f.words) > 0 || f.aspect != "" || f.minW > 0 || f.minH > 0 || f.minFrames > 0 || f.maxFrames > 0
+}
+
+func (f resultFilter) match(state *appState, r model.Result) bool {
+	for _, w := range f.words {
+		if !resultContains(r, w) {
+			return false
+		}
+	}
+	if f.aspect != "" && aspectOf(r) != f.aspect {
+		return false
+	}
+	if r.Width < f.minW || r.Height < f.minH {
+		return false
+	}
+	if f.minFrames > 0 || f.maxFrames > 0 {
+		n, _, ok := frameStats(state, r)
+		if !ok || n < f.minFrames || (f.maxFrames > 0 && n > f.maxFrames) {
+			return false
+		}
+	}
+	return true
+}
+
+func resultContains(r model.Result, needle string) bool {
+	if strings.Contains(strings.ToLower(r.Title), needle) {
+		return true
+	}
+	for _, tag := range r.Tags {
+		if strings.Contains(strings.ToLower(tag), needle) {
+			return true
+		}
+	}
+	return false
+}
+
+func aspectOf(r model.Result) string {
+	if r.Width <= 0 || r.Height <= 0 {
+		return ""
+	}
+	ratio := float64(r.Width) / float64(r.Height)
+	switch {
+	case ratio > squareSlack:
+		return "wide"
+	case ratio < 1/squareSlack:
+		return "tall"
+	}
+	return "square"
+}
+
+// resultBytes is the size of the rendition r.URL points at, 0 when the
+// provider didn't say.
+func resultBytes(r model.Result) int64 {
+	for _, rend := range r.Renditions {
+		if rend.URL == r.URL {
+			return rend.Bytes
+		}
+	}
+	return 0
+}
+
+// frameStats are the frame count and total duration of r's preview, read
+// from the whole file: decoded frames stop at the decode limit.
+func frameStats(state *appState, r model.Result) (int, time.Duration, bool) {
+	entry, ok := state.cache[r.PreviewURL]
+	if !ok || len(entry.RawGIF) == 0 {
+		return 0, 0, false
+	}
+	if entry.FrameCount == 0 {
+		entry.FrameCount, entry.Duration = frameTiming(entry.RawGIF)
+	}
+	return entry.FrameCount, entry.Duration, entry.FrameCount > 0
+}
+
+// frameTiming is gifdecode.Timing with -1 frames for unreadable data.
+func frameTiming(data []byte) (int, time.Duration) {
+	n, d, err := gifdecode.Timing(data, gifdecode.DefaultOptions())
+	if err != nil {
+		return -1, 0
+	}
+	return n, d
+}
+
+// sortValue is what sort:by compares; ok is false when it isn't known,
+// and those results go last.
+func sortValue(state *appState, r model.Result, by string) (int64, bool) {
+	switch by {
+	case "size":
+		n := resultBytes(r)
+		return n, n > 0
+	case "dims":
+		n := int64(r.Width) * int64(r.Height)
+		return n, n > 0
+	case "duration":
+		_, d, ok := frameStats(state, r)
+		return int64(d), ok
+	}
+	return 0, false
+}
+
+// applyFilter rebuilds state.results from the search results, keeping the
+// selection on the same result when it is still listed.
+func applyFilter(state *appState) {
+	f, err := parseFilter(state.filterText)
+	if err != nil {
+		state.status = "Filter: " + err.Error()
+		state.renderDirty = true
+		return
+	}
+	var selKey string
+	if state.selected >= 0 && state.selected < len(state.results) {
+		selKey = resultKey(state.results[state.selected])
+	}
+	results := make([]model.Result, 0, len(state.allResults))
+	for _, r := range state.allResults {
+		if f.match(state, r) {
+			results = append(results, r)
+		}
+	}
+	if f.sortBy != "" {
+		sort.SliceStable(results, func(i, j int) bool {
+			a, aok := sortValue(state, results[i], f.sortBy)
+			b, bok := sortValue(state, results[j], f.sortBy)
+			if aok != bok {
+				return aok
+			}
+			if f.desc {
+				return a > b
+			}
+			return a < b
+		})
+	}
+	state.results = results
+	state.selected = 0
+	for i, r := range results {
+		if resultKey(r) == selKey {
+			state.selected = i
+			break
+		}
+	}
+	ensureVisible(state)
+	if len(results) == 0 || resultKey(results[state.selected]) != selKey {
+		loadSelectedImage(state)
+	}
+	if f.needsFrames() {
+		loadFrameStats(state)
+	}
+	state.status = filterStatus(state, f)
+	state.renderDirty = true
+}
+
+func filterStatus(state *appState, f resultFilter) string {
+	if !f.active() && f.sortBy == "" {
+		return fmt.Sprintf("%d results", len(state.results))
+	}
+	status := fmt.Sprintf("%d of %d results", len(state.results), len(state.allResults))
+	if len(state.results) == 0 {
+		status = fmt.Sprintf("0 of %d results match · %s to change the filter", len(state.allResults), displayKey(firstKey(state.keymap(), actFilter)))
+	}
+	if f.needsFrames() && len(state.thumbLoading) > 0 {
+		status += " · reading frames…"
+	}
+	return status
+}
+
+// loadFrameStats starts loading the preview of every result, so frame
+// counts and durations become known; applyFilter runs again as they arrive.
+func loadFrameStats(state *appState) {
+	opts := thumbDecodeOptions(layout{tileCols: thumbCols, tileRows: thumbRows})
+	for _, r := range state.allResults {
+		requestThumb(state, r, opts)
+	}
+}
+
+// refilterForFrames reapplies a filter that depends on frame data once
+// more of it has arrived.
+func refilterForFrames(state *appState) {
+	if filterNeedsFrames(state) {
+		applyFilter(state)
+	}
+}
+
+func filterNeedsFrames(state *appState) bool {
+	if state.filterText == "" {
+		return false
+	}
+	f, err := parseFilter(state.filterText)
+	return err == nil && f.needsFrames()
+}
+
+func handleFilterInput(state *appState, ev inputEvent, _ *bufio.Writer) bool {
+	switch ev.kind {
+	case keyRune:
+		state.filterInput += string(ev.ch)
+		state.renderDirty = true
+	case keyBackspace:
+		if runes := []rune(state.filterInput); len(runes) > 0 {
+			state.filterInput = string(runes[:len(runes)-1])
+			state.renderDirty = true
+		}
+	case keyEnter:
+		if _, err := parseFilter(state.filterInput); err != nil {
+			state.status = "Filter: " + err.Error()
+			state.renderDirty = true
+			return false
+		}
+		state.mode = modeBrowse
+		state.filterText = strings.TrimSpace(state.filterInput)
+		applyFilter(state)
+	case keyEsc:
+		state.mode = modeBrowse
+		state.status = ""
+		state.renderDirty = true
+	case keyCtrlC:
+		return true
+	}
+	return false
+}
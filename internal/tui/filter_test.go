@@ -0,0 +1,182 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steipete/gifgrep/internal/model"
+	"github.com/steipete/gifgrep/internal/termcaps"
+	"github.com/steipete/gifgrep/internal/testutil"
+)
+
+func TestParseFilter(t *testing.T) {
+	f, err := parseFilter("Cat wide 320x frames>=5 frames<=40 sort:-size")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.words) != 1 || f.words[0] != "cat" || f.aspect != "wide" || f.minW != 320 || f.minH != 0 ||
+		f.minFrames != 5 || f.maxFrames != 40 || f.sortBy != "size" || !f.desc {
+		t.Fatalf("unexpected filter %+v", f)
+	}
+	if f, _ := parseFilter("x200 xmas"); f.minH != 200 || len(f.words) != 1 || f.words[0] != "xmas" {
+		t.Fatalf("expected x200 as a minimum height and xmas as a word, got %+v", f)
+	}
+	for _, bad := range []string{"sort:name", "frames>=many", "frames<=0"} {
+		if _, err := parseFilter(bad); err == nil {
+			t.Fatalf("expected %q to fail", bad)
+		}
+	}
+}
+
+func filterState(t *testing.T) (*appState, func(...inputEvent)) {
+	t.Helper()
+	state, out := navState(t, "", 100)
+	results := []model.Result{
+		{ID: "a", Title: "Cat wide", URL: "a.gif", Width: 480, Height: 270, Renditions: []model.Rendition{{URL: "a.gif", Bytes: 900}}},
+		{ID: "b", Title: "Cat tall", URL: "b.gif", Width: 270, Height: 480, Renditions: []model.Rendition{{URL: "b.gif", Bytes: 300}}},
+		{ID: "c", Title: "Dog", Tags: []string{"cat"}, URL: "c.gif", Width: 200, Height: 200},
+		{ID: "d", Title: "Cat big", URL: "d.gif", Width: 640, Height: 360, Renditions: []model.Rendition{{URL: "d.gif", Bytes: 600}}},
+		{ID: "e", Title: "Cow", URL: "e.gif", Width: 500, Height: 500},
+	}
+	state.results, state.allResults = results, results
+	return state, func(evs ...inputEvent) { press(state, out, evs...) }
+}
+
+func ids(results []model.Result) string {
+	var b strings.Builder
+	for _, r := range results {
+		b.WriteString(r.ID)
+	}
+	return b.String()
+}
+
+func TestFilterPromptNarrowsAndSorts(t *testing.T) {
+	state, keys := filterState(t)
+	keys(inputEvent{kind: keyDown}, inputEvent{kind: keyDown}, inputEvent{kind: keyDown})
+	keys(runes("=")...)
+	if state.mode != modeFilter {
+		t.Fatalf("expected the filter prompt")
+	}
+	keys(runes("cat wide")...)
+	keys(inputEvent{kind: keyEnter})
+	if ids(state.results) != "ad" || state.selected != 1 {
+		t.Fatalf("expected wide cats with d still selected, got %q at %d", ids(state.results), state.selected)
+	}
+	if state.status != "2 of 5 results" {
+		t.Fatalf("unexpected status %q", state.status)
+	}
+
+	keys(runes("=")...)
+	if state.filterInput != "cat wide" {
+		t.Fatalf("expected the prompt to start from the filter, got %q", state.filterInput)
+	}
+	keys(inputEvent{kind: keyEsc})
+	if state.mode != modeBrowse || ids(state.results) != "ad" {
+		t.Fatalf("expected Esc to keep the filter")
+	}
+
+	state.filterText = "cat sort:-size"
+	applyFilter(state)
+	if ids(state.results) != "adbc" {
+		t.Fatalf("expected cats by size, unknown last, got %q", ids(state.results))
+	}
+	state.filterText = "300x300 sort:dims"
+	applyFilter(state)
+	if ids(state.results) != "de" {
+		t.Fatalf("expected big results, smallest area first, got %q", ids(state.results))
+	}
+	state.filterText = "square"
+	applyFilter(state)
+	if ids(state.results) != "ce" {
+		t.Fatalf("expected square results, got %q", ids(state.results))
+	}
+
+	keys(runes("=")...)
+	keys(inputEvent{kind: keyBackspace}, inputEvent{kind: keyBackspace}, inputEvent{kind: keyBackspace},
+		inputEvent{kind: keyBackspace}, inputEvent{kind: keyBackspace}, inputEvent{kind: keyBackspace})
+	keys(runes("sort:name")...)
+	keys(inputEvent{kind: keyEnter})
+	if state.mode != modeFilter || !strings.HasPrefix(state.status, "Filter: sort by") {
+		t.Fatalf("expected a bad filter to keep the prompt open, got %q", state.status)
+	}
+	state.filterInput = ""
+	keys(inputEvent{kind: keyEnter})
+	if ids(state.results) != "abcde" || state.status != "5 results" {
+		t.Fatalf("expected an empty filter to show everything, got %q %q", ids(state.results), state.status)
+	}
+}
+
+func TestFilterOnFramesLoadsPreviews(t *testing.T) {
+	state, _ := filterState(t)
+	state.inline = termcaps.InlineIterm
+	for i := range state.allResults {
+		state.allResults[i].PreviewURL = fmt.Sprintf("https://example.test/%s.gif", state.allResults[i].ID)
+	}
+	prev := fetchThumbFn
+	t.Cleanup(func() { fetchThumbFn = prev })
+	fetchThumbFn = func(url string) ([]byte, error) {
+		if strings.HasSuffix(url, "/c.gif") {
+			return nil, errors.New("gone")
+		}
+		return testutil.MakeTestGIF(), nil
+	}
+
+	state.filterText = "frames>=2"
+	applyFilter(state)
+	if len(state.results) != 0 || !strings.Contains(state.status, "reading frames") {
+		t.Fatalf("expected nothing known yet, got %q %q", ids(state.results), state.status)
+	}
+	for range state.allResults {
+		select {
+		case res := <-state.thumbCh:
+			handleThumbResult(state, res)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for previews")
+		}
+	}
+	if ids(state.results) != "abde" || state.status != "4 of 5 results" {
+		t.Fatalf("expected the decoded results, got %q %q", ids(state.results), state.status)
+	}
+	if n, d, ok := frameStats(state, state.results[0]); !ok || n != 2 || d != 120*time.Millisecond {
+		t.Fatalf("unexpected frame stats %d %v %v", n, d, ok)
+	}
+}
+
+func TestFilterCountsFramesPastTheDecodeLimit(t *testing.T) {
+	state, _ := filterState(t)
+	state.inline = termcaps.InlineKitty
+	for i := range state.allResults {
+		state.allResults[i].PreviewURL = fmt.Sprintf("https://example.test/%s.gif", state.allResults[i].ID)
+	}
+	prev := fetchThumbFn
+	t.Cleanup(func() { fetchThumbFn = prev })
+	fetchThumbFn = func(url string) ([]byte, error) {
+		if strings.HasSuffix(url, "/b.gif") {
+			return testutil.MakeLongGIF(70), nil
+		}
+		return testutil.MakeTestGIF(), nil
+	}
+
+	state.filterText = "frames>=61"
+	applyFilter(state)
+	for range state.allResults {
+		select {
+		case res := <-state.thumbCh:
+			handleThumbResult(state, res)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for previews")
+		}
+	}
+	if ids(state.results) != "b" {
+		t.Fatalf("expected only the 70-frame GIF, got %q", ids(state.results))
+	}
+	if n, d, ok := frameStats(state, state.results[0]); !ok || n != 70 || d != 3500*time.Millisecond {
+		t.Fatalf("unexpected frame stats %d %v %v", n, d, ok)
+	}
+	if thumb := state.thumbs["id:b"]; thumb == nil || thumb.anim == nil || len(thumb.anim.Frames) != 60 {
+		t.Fatalf("expected the thumbnail itself still capped")
+	}
+}
@@ -0,0 +1,81 @@
+package tui
+
+import (
+	"bufio"
+	"strings"
+
+	"github.com/steipete/gifgrep/internal/kitty"
+	"github.com/steipete/gifgrep/internal/termcaps"
+)
+
+// toggleHelp opens or closes the ? overlay. It covers the preview or the
+// grid, so images are removed while it is open and sent again afterwards.
+func toggleHelp(state *appState, out *bufio.Writer) {
+	state.showHelp = !state.showHelp
+	if state.view == viewGrid {
+		clearGrid(state, out)
+	}
+	if state.showHelp && state.inline == termcaps.InlineKitty && state.activeImageID != 0 {
+		kitty.DeleteImage(out, state.activeImageID)
+		state.activeImageID = 0
+	}
+	state.previewNeedsSend = true
+	state.previewDirty = true
+	state.lastShowRight = false
+	state.itermLast.cols, state.itermLast.rows = 0, 0
+	state.renderDirty = true
+}
+
+// drawHelp fills the content area with every action and its keys, taken
+// from the active keymap, in columns when one doesn't fit.
+func drawHelp(out *bufio.Writer, state *appState, layout layout) {
+	th := state.theme()
+	lines := state.keymap().helpLines()
+	keyWidth, labelWidth := 0, 0
+	for _, l := range lines {
+		keyWidth = maxInt(keyWidth, runeLen(l[0]))
+		labelWidth = maxInt(labelWidth, runeLen(l[1]))
+	}
+	cell := func(l [2]string) string {
+		key := l[0] + strings.Repeat(" ", keyWidth-runeLen(l[0]))
+		label := l[1] + strings.Repeat(" ", labelWidth-runeLen(l[1]))
+		return styleIf(state.useColor, key, "\x1b[1m", th.accent) + "  " + styleIf(state.useColor, label, th.dim)
+	}
+	// Use more columns when the list is too tall, as many as the width
+	// allows (but at least two, truncating the second).
+	cellWidth := keyWidth + 2 + labelWidth
+	maxColumns := maxInt(2, (layout.cols+4)/(cellWidth+4))
+	columns := 1
+	for columns < maxColumns && (len(lines)+columns-1)/columns+4 > layout.contentHeight {
+		columns++
+	}
+	perCol := (len(lines) + columns - 1) / columns
+	width := columns*cellWidth + (columns-1)*4
+	var body []string
+	for i := 0; i < perCol; i++ {
+		row := cell(lines[i])
+		for j := i + perCol; j < len(lines); j += perCol {
+			row += "    " + cell(lines[j])
+		}
+		body = append(body, row)
+	}
+	// The blank line above the footer goes first when space is short.
+	footer := styleIf(state.useColor, "Any key closes this help.", th.dim)
+	rows := append(body, footer)
+	if len(rows) < layout.contentHeight {
+		rows = append(body, "", footer)
+	}
+	if len(rows)+2 <= layout.contentHeight {
+		rows = append([]string{styleIf(state.useColor, "Keys", "\x1b[1m"), ""}, rows...)
+	}
+
+	pad := strings.Repeat(" ", maxInt(0, (layout.cols-width)/2))
+	top := layout.contentTop + maxInt(0, (layout.contentHeight-len(rows))/2)
+	for row := layout.contentTop; row <= layout.contentBottom; row++ {
+		text := ""
+		if i := row - top; i >= 0 && i < len(rows) {
+			text = pad + rows[i]
+		}
+		writeLineAt(out, row, 1, text, layout.cols)
+	}
+}
@@ -0,0 +1,427 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// action names what a browse-mode key does; config's [keys] table maps
+// action names to keys.
+type action string
+
+const (
+	actUp           action = "up"
+	actDown         action = "down"
+	actLeft         action = "left"
+	actRight        action = "right"
+	actTop          action = "top"
+	actBottom       action = "bottom"
+	actPageUp       action = "page-up"
+	actPageDown     action = "page-down"
+	actHalfPageUp   action = "half-page-up"
+	actHalfPageDown action = "half-page-down"
+	actFind         action = "find"
+	actNextMatch    action = "next-match"
+	actPrevMatch    action = "prev-match"
+	actFilter       action = "filter"
+	actGrid         action = "grid"
+	actMark         action = "mark"
+	actMarkRange    action = "mark-range"
+	actUnmarkAll    action = "unmark-all"
+	actSearch       action = "search"
+	actDownload     action = "download"
+	actCopy         action = "copy"
+	actCopyURL      action = "copy-url"
+	actCopyMarkdown action = "copy-markdown"
+	actCopyHTML     action = "copy-html"
+	actCopyData     action = "copy-data"
+	actFavorite     action = "favorite"
+	actExport       action = "export-markdown"
+	actPause        action = "pause"
+	actFramePrev    action = "frame-prev"
+	actFrameNext    action = "frame-next"
+	actSlower       action = "slower"
+	actFaster       action = "faster"
+	actSaveFrame    action = "save-frame"
+	actCaption      action = "caption"
+	actReveal       action = "reveal"
+	actHelp         action = "help"
+	actQuit         action = "quit"
+)
+
+// actionInfo describes an action for the ? overlay; hint, when set, is
+// its label in the bottom bar.
+type actionInfo struct {
+	name  action
+	label string
+	hint  string
+}
+
+// actions is the overlay order.
+var actions = []actionInfo{
+	{actUp, "Previous result", ""},
+	{actDown, "Next result", ""},
+	{actLeft, "Previous tile (grid)", ""},
+	{actRight, "Next tile (grid)", ""},
+	{actTop, "First result (with a count: result N)", ""},
+	{actBottom, "Last result (with a count: result N)", ""},
+	{actPageUp, "Page up", ""},
+	{actPageDown, "Page down", ""},
+	{actHalfPageUp, "Half page up", ""},
+	{actHalfPageDown, "Half page down", ""},
+	{actFind, "Find in results", "Find"},
+	{actNextMatch, "Next match", ""},
+	{actPrevMatch, "Previous match", ""},
+	{actFilter, "Filter and sort results", ""},
+	{actGrid, "Switch list / thumbnail grid", "Grid"},
+	{actMark, "Mark or unmark, then next", ""},
+	{actMarkRange, "Mark from the last mark to here", ""},
+	{actUnmarkAll, "Clear marks", ""},
+	{actSearch, "Edit search", "Edit"},
+	{actDownload, "Download (marked: all, in the background)", "Download"},
+	{actCopy, "Copy GIF", "Copy"},
+	{actCopyURL, "Copy URL (marked: all)", ""},
+	{actCopyMarkdown, "Copy Markdown image (marked: all)", ""},
+	{actCopyHTML, "Copy HTML <img> (marked: all)", ""},
+	{actCopyData, "Copy as a data: URI", ""},
+	{actFavorite, "Add to favorites (marked: all)", ""},
+	{actExport, "Export Markdown to the download dir", ""},
+	{actPause, "Pause or resume the preview", ""},
+	{actFramePrev, "Previous frame (pauses)", ""},
+	{actFrameNext, "Next frame (pauses)", ""},
+	{actSlower, "Play slower (down to ¼×)", ""},
+	{actFaster, "Play faster (up to 4×)", ""},
+	{actSaveFrame, "Save the frame on screen as PNG", ""},
+	{actCaption, "Caption and save", "Caption"},
+	{actReveal, "Reveal in file manager", "Reveal"},
+	{actHelp, "Show or hide this help", "Help"},
+	{actQuit, "Quit", "Quit"},
+}
+
+// presets bind each action to keys, first key shown in hints. Keys are a
+// printable character or a name: up, down, left, right, pgup, pgdn, home, end, enter,
+// esc, tab, space, ctrl+X. Unbound digits type a count (5j, 5⏎).
+var presets = map[string]map[action][]string{
+	"default": {
+		actUp:           {"up", "k"},
+		actDown:         {"down", "j"},
+		actLeft:         {"left"},
+		actRight:        {"right"},
+		actTop:          {"home", "g"},
+		actBottom:       {"end", "G"},
+		actPageUp:       {"pgup"},
+		actPageDown:     {"pgdn"},
+		actHalfPageUp:   {"ctrl+u"},
+		actHalfPageDown: {"ctrl+d"},
+		actFind:         {"ctrl+f"},
+		actNextMatch:    {"n"},
+		actPrevMatch:    {"N"},
+		actFilter:       {"="},
+		actGrid:         {"tab"},
+		actMark:         {"space"},
+		actMarkRange:    {"V"},
+		actUnmarkAll:    {"u"},
+		actSearch:       {"/", "enter", "esc"},
+		actDownload:     {"d"},
+		actCopy:         {"c"},
+		actCopyURL:      {"y"},
+		actCopyMarkdown: {"Y"},
+		actCopyHTML:     {"C"},
+		actCopyData:     {"U"},
+		actFavorite:     {"*"},
+		actExport:       {"E"},
+		actPause:        {"p"},
+		actFramePrev:    {","},
+		actFrameNext:    {"."},
+		actSlower:       {"["},
+		actFaster:       {"]"},
+		actSaveFrame:    {"S"},
+		actCaption:      {"t"},
+		actReveal:       {"f"},
+		actHelp:         {"?"},
+		actQuit:         {"q"},
+	},
+	"vim": {
+		actUp:           {"k", "up"},
+		actDown:         {"j", "down"},
+		actLeft:         {"h", "left"},
+		actRight:        {"l", "right"},
+		actTop:          {"g", "home"},
+		actBottom:       {"G", "end"},
+		actPageUp:       {"pgup", "ctrl+b"},
+		actPageDown:     {"pgdn", "ctrl+f"},
+		actHalfPageUp:   {"ctrl+u"},
+		actHalfPageDown: {"ctrl+d"},
+		actFind:         {"F"},
+		actNextMatch:    {"n"},
+		actPrevMatch:    {"N"},
+		actFilter:       {"="},
+		actGrid:         {"tab"},
+		actMark:         {"space"},
+		actMarkRange:    {"V"},
+		actUnmarkAll:    {"u"},
+		actSearch:       {"/", "enter"},
+		actDownload:     {"d"},
+		actCopy:         {"c"},
+		actCopyURL:      {"y"},
+		actCopyMarkdown: {"Y"},
+		actCopyHTML:     {"C"},
+		actCopyData:     {"U"},
+		actFavorite:     {"*"},
+		actExport:       {"E"},
+		actPause:        {"p"},
+		actFramePrev:    {","},
+		actFrameNext:    {"."},
+		actSlower:       {"["},
+		actFaster:       {"]"},
+		actSaveFrame:    {"S"},
+		actCaption:      {"t"},
+		actReveal:       {"f"},
+		actHelp:         {"?"},
+		actQuit:         {"q"},
+	},
+	"emacs": {
+		actUp:           {"ctrl+p", "up"},
+		actDown:         {"ctrl+n", "down"},
+		actLeft:         {"ctrl+b", "left"},
+		actRight:        {"ctrl+f", "right"},
+		actTop:          {"home"},
+		actBottom:       {"end"},
+		actPageUp:       {"pgup"},
+		actPageDown:     {"ctrl+v", "pgdn"},
+		actHalfPageUp:   {"ctrl+u"},
+		actHalfPageDown: {"ctrl+d"},
+		actFind:         {"ctrl+r"},
+		actNextMatch:    {"n"},
+		actPrevMatch:    {"N"},
+		actFilter:       {"="},
+		actGrid:         {"tab"},
+		actMark:         {"space"},
+		actMarkRange:    {"V"},
+		actUnmarkAll:    {"u"},
+		actSearch:       {"ctrl+s", "/", "enter"},
+		actDownload:     {"d"},
+		actCopy:         {"c"},
+		actCopyURL:      {"ctrl+w", "y"},
+		actCopyMarkdown: {"Y"},
+		actCopyHTML:     {"C"},
+		actCopyData:     {"U"},
+		actFavorite:     {"*"},
+		actExport:       {"E"},
+		actPause:        {"p"},
+		actFramePrev:    {","},
+		actFrameNext:    {"."},
+		actSlower:       {"["},
+		actFaster:       {"]"},
+		actSaveFrame:    {"S"},
+		actCaption:      {"t"},
+		actReveal:       {"f"},
+		actHelp:         {"?"},
+		actQuit:         {"ctrl+g", "q"},
+	},
+}
+
+// keymap maps key names to actions, and actions back to their keys.
+type keymap struct {
+	byKey    map[string]action
+	byAction map[action][]string
+}
+
+// buildKeymap starts from preset ("" is default) and applies overrides
+// (action → space-separated keys). A rebound action loses its preset keys.
+func buildKeymap(preset string, overrides map[string]string) (keymap, error) {
+	if preset == "" {
+		preset = "default"
+	}
+	base, ok := presets[preset]
+	if !ok {
+		return keymap{}, fmt.Errorf("keys: unknown keymap %q (have default, emacs, vim)", preset)
+	}
+	bindings := map[action][]string{}
+	for a, keys := range base {
+		bindings[a] = keys
+	}
+	for name, spec := range overrides {
+		a := action(strings.TrimSpace(name))
+		if _, ok := base[a]; !ok {
+			return keymap{}, fmt.Errorf("keys: unknown action %q (have %s)", name, strings.Join(actionNames(), ", "))
+		}
+		var keys []string
+		for _, field := range strings.Fields(spec) {
+			key, err := parseKeyName(field)
+			if err != nil {
+				return keymap{}, fmt.Errorf("keys: %s: %w", name, err)
+			}
+			keys = append(keys, key)
+		}
+		if len(keys) == 0 {
+			return keymap{}, fmt.Errorf("keys: %s needs a key", name)
+		}
+		bindings[a] = keys
+	}
+	m := keymap{byKey: map[string]action{}, byAction: bindings}
+	for _, info := range actions {
+		for _, key := range bindings[info.name] {
+			if prev, dup := m.byKey[key]; dup {
+				return keymap{}, fmt.Errorf("keys: %s is bound to both %s and %s", key, prev, info.name)
+			}
+			m.byKey[key] = info.name
+		}
+	}
+	return m, nil
+}
+
+// reservedCtrl are ctrl letters the terminal sends as Ctrl-C, Backspace,
+// Tab or Enter.
+const reservedCtrl = "chijm"
+
+var namedKeys = map[string]bool{
+	"up": true, "down": true, "left": true, "right": true, "pgup": true, "pgdn": true, "home": true, "end": true,
+	"enter": true, "esc": true, "tab": true, "space": true,
+}
+
+// parseKeyName checks a key from config: one printable character, a named
+// key or ctrl+LETTER (also C-x, ctrl-x).
+func parseKeyName(s string) (string, error) {
+	if r, size := utf8.DecodeRuneInString(s); size == len(s) && r != utf8.RuneError && r > 0x20 && r != 0x7f {
+		return s, nil
+	}
+	lower := strings.ToLower(s)
+	if namedKeys[lower] {
+		return lower, nil
+	}
+	for _, prefix := range []string{"ctrl+", "ctrl-", "c-"} {
+		if rest, ok := strings.CutPrefix(lower, prefix); ok && len(rest) == 1 && rest[0] >= 'a' && rest[0] <= 'z' && !strings.Contains(reservedCtrl, rest) {
+			return "ctrl+" + rest, nil
+		}
+	}
+	return "", fmt.Errorf("unknown key %q (use a character, up, down, left, right, pgup, pgdn, home, end, enter, esc, tab, space or ctrl+a…z except c, h, i, j, m)", s)
+}
+
+func actionNames() []string {
+	names := make([]string, 0, len(actions))
+	for _, info := range actions {
+		names = append(names, string(info.name))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// keyName names ev the way keymaps do; "" for keys that can't be bound.
+func (ev inputEvent) keyName() string {
+	switch ev.kind {
+	case keyRune:
+		if ev.ch == ' ' {
+			return "space"
+		}
+		return string(ev.ch)
+	case keyCtrl:
+		return "ctrl+" + string(ev.ch)
+	case keyEnter:
+		return "enter"
+	case keyEsc:
+		return "esc"
+	case keyTab:
+		return "tab"
+	case keyUp:
+		return "up"
+	case keyDown:
+		return "down"
+	case keyLeft:
+		return "left"
+	case keyRight:
+		return "right"
+	case keyPgUp:
+		return "pgup"
+	case keyPgDn:
+		return "pgdn"
+	case keyHome:
+		return "home"
+	case keyEnd:
+		return "end"
+	default:
+		return ""
+	}
+}
+
+// displayKey is how hints and the overlay show a key.
+func displayKey(key string) string {
+	switch key {
+	case "up":
+		return "↑"
+	case "down":
+		return "↓"
+	case "left":
+		return "←"
+	case "right":
+		return "→"
+	case "enter":
+		return "⏎"
+	case "esc":
+		return "Esc"
+	case "tab":
+		return "Tab"
+	case "space":
+		return "Space"
+	case "pgup":
+		return "PgUp"
+	case "pgdn":
+		return "PgDn"
+	case "home":
+		return "Home"
+	case "end":
+		return "End"
+	}
+	if rest, ok := strings.CutPrefix(key, "ctrl+"); ok {
+		return "^" + strings.ToUpper(rest)
+	}
+	return key
+}
+
+// keymap is the session's keymap, or the default preset.
+func (s *appState) keymap() keymap {
+	if s.keys.byKey == nil {
+		s.keys, _ = buildKeymap("", nil)
+	}
+	return s.keys
+}
+
+func (s *appState) actionFor(ev inputEvent) (action, bool) {
+	a, ok := s.keymap().byKey[ev.keyName()]
+	return a, ok
+}
+
+func firstKey(k keymap, a action) string {
+	if keys := k.byAction[a]; len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
+
+// hints lists the bottom-bar entries: the first key of each hinted action,
+// with up/down merged into one Select entry.
+func (k keymap) hints() [][2]string {
+	first := func(a action) string { return displayKey(firstKey(k, a)) }
+	out := [][2]string{{first(actUp) + first(actDown), "Select"}}
+	for _, info := range actions {
+		if info.hint != "" && first(info.name) != "" {
+			out = append(out, [2]string{first(info.name), info.hint})
+		}
+	}
+	return out
+}
+
+// helpLines renders the ? overlay: every action with all of its keys.
+func (k keymap) helpLines() [][2]string {
+	out := make([][2]string, 0, len(actions))
+	for _, info := range actions {
+		keys := make([]string, len(k.byAction[info.name]))
+		for i, key := range k.byAction[info.name] {
+			keys[i] = displayKey(key)
+		}
+		out = append(out, [2]string{strings.Join(keys, " "), info.label})
+	}
+	return out
+}
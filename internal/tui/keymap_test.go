@@ -0,0 +1,166 @@
+package tui
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/steipete/gifgrep/internal/model"
+	"github.com/steipete/gifgrep/internal/termcaps"
+)
+
+func TestBuildKeymap(t *testing.T) {
+	keys, err := buildKeymap("", map[string]string{"download": "D", "quit": "x ctrl-q"})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if keys.byKey["D"] != actDownload || keys.byKey["x"] != actQuit || keys.byKey["ctrl+q"] != actQuit {
+		t.Fatalf("expected rebound keys, got %v", keys.byKey)
+	}
+	if _, ok := keys.byKey["d"]; ok {
+		t.Fatalf("expected default d to be released")
+	}
+
+	for _, bad := range []map[string]string{
+		{"dance": "x"},
+		{"download": "dd"},
+		{"download": ""},
+		{"download": "c"},
+		{"download": "ctrl+m"},
+	} {
+		if _, err := buildKeymap("", bad); err == nil {
+			t.Fatalf("expected error for %v", bad)
+		}
+	}
+	if _, err := buildKeymap("helix", nil); err == nil {
+		t.Fatalf("expected error for unknown preset")
+	}
+}
+
+func TestKeymapPresets(t *testing.T) {
+	for name, want := range map[string]map[string]action{
+		"default": {"up": actUp, "j": actDown, "G": actBottom, "/": actSearch, "esc": actSearch, "?": actHelp},
+		"vim":     {"j": actDown, "k": actUp, "down": actDown, "q": actQuit},
+		"emacs":   {"ctrl+n": actDown, "ctrl+p": actUp, "ctrl+s": actSearch, "ctrl+g": actQuit},
+	} {
+		keys, err := buildKeymap(name, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for key, a := range want {
+			if keys.byKey[key] != a {
+				t.Fatalf("%s: expected %s on %s, got %q", name, a, key, keys.byKey[key])
+			}
+		}
+		for _, info := range actions {
+			if len(keys.byAction[info.name]) == 0 {
+				t.Fatalf("%s: %s has no key", name, info.name)
+			}
+		}
+	}
+}
+
+func TestHintsFollowKeymap(t *testing.T) {
+	keys, err := buildKeymap("vim", map[string]string{"download": "D", "next-match": "ctrl+n", "prev-match": "ctrl+p"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	state := &appState{keys: keys}
+	var buf bytes.Buffer
+	out := bufio.NewWriter(&buf)
+	drawHints(out, state, layout{cols: 120, hintsRow: 1})
+	_ = out.Flush()
+	for _, want := range []string{"kj Select", "D Download", "? Help", "q Quit"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("expected %q in hints: %q", want, buf.String())
+		}
+	}
+	handleBrowseInput(state, inputEvent{kind: keyRune, ch: 'F'}, out)
+	if state.mode != modeFind || !strings.HasSuffix(state.status, "^N/^P cycle") {
+		t.Fatalf("expected the find status to follow the keymap, got %q", state.status)
+	}
+}
+
+func TestReboundQuitKey(t *testing.T) {
+	keys, err := buildKeymap("", map[string]string{"quit": "x"})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	state := &appState{
+		mode:    modeBrowse,
+		results: []model.Result{{Title: "A"}},
+		cache:   map[string]*gifCacheEntry{},
+		keys:    keys,
+	}
+	out := bufio.NewWriter(&bytes.Buffer{})
+	if !handleInput(state, inputEvent{kind: keyRune, ch: 'x'}, out, nil) {
+		t.Fatalf("expected quit on rebound key")
+	}
+	if handleInput(state, inputEvent{kind: keyRune, ch: 'q'}, out, nil) {
+		t.Fatalf("expected q to no longer quit")
+	}
+}
+
+func TestUnboundKeyStaysInBrowse(t *testing.T) {
+	keys, _ := buildKeymap("vim", nil)
+	state := &appState{
+		mode:    modeBrowse,
+		results: []model.Result{{Title: "A"}, {Title: "B"}},
+		cache:   map[string]*gifCacheEntry{},
+		keys:    keys,
+	}
+	out := bufio.NewWriter(&bytes.Buffer{})
+	handleInput(state, inputEvent{kind: keyRune, ch: 'x'}, out, nil)
+	if state.mode != modeBrowse || state.query != "" {
+		t.Fatalf("expected unbound key to stay in browse, got mode %v query %q", state.mode, state.query)
+	}
+	if !strings.Contains(state.status, "x is not bound") {
+		t.Fatalf("expected status hint, got %q", state.status)
+	}
+	handleInput(state, inputEvent{kind: keyRune, ch: 'j'}, out, nil)
+	if state.selected != 1 {
+		t.Fatalf("expected j to move down, got %d", state.selected)
+	}
+}
+
+func TestHelpOverlay(t *testing.T) {
+	keys, _ := buildKeymap("emacs", nil)
+	state := &appState{
+		mode:          modeBrowse,
+		results:       []model.Result{{Title: "A"}},
+		cache:         map[string]*gifCacheEntry{},
+		keys:          keys,
+		inline:        termcaps.InlineKitty,
+		activeImageID: 7,
+		currentAnim:   &gifAnimation{ID: 7},
+	}
+	var buf bytes.Buffer
+	out := bufio.NewWriter(&buf)
+	handleInput(state, inputEvent{kind: keyRune, ch: '?'}, out, nil)
+	if !state.showHelp || state.activeImageID != 0 {
+		t.Fatalf("expected help open and image removed, got %v %d", state.showHelp, state.activeImageID)
+	}
+	render(state, out, 24, 80)
+	_ = out.Flush()
+	for _, want := range []string{"^N ↓", "Next result", "^G q", "Any key closes"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("expected %q in overlay", want)
+		}
+	}
+	handleInput(state, inputEvent{kind: keyCtrl, ch: 'n'}, out, nil)
+	if state.showHelp || state.selected != 0 || !state.previewNeedsSend {
+		t.Fatalf("expected any key to close help and resend the preview")
+	}
+}
+
+func TestThemes(t *testing.T) {
+	state := &appState{useColor: true, opts: model.Options{Theme: "mono"}}
+	if got := formatStatusLine(true, state.theme(), "3 results"); !strings.Contains(got, "\x1b[2m results") {
+		t.Fatalf("expected mono dim, got %q", got)
+	}
+	state.opts.Theme = "nope"
+	if state.theme() != themes["default"] {
+		t.Fatalf("expected default theme fallback")
+	}
+}
@@ -0,0 +1,104 @@
+package tui
+
+import (
+	"bufio"
+	"bytes"
+	"image/png"
+
+	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/internal/kitty"
+	"github.com/steipete/gifgrep/internal/termcaps"
+)
+
+// maxAPC bounds a reply we collect; Kitty's are a few dozen bytes.
+const maxAPC = 4096
+
+// readAPC reads an APC body after ESC _ up to ESC \ (not included).
+func readAPC(reader *bufio.Reader) string {
+	var buf []byte
+	for len(buf) < maxAPC {
+		b, err := reader.ReadByte()
+		if err != nil {
+			break
+		}
+		if b == 0x1b {
+			next, err := reader.ReadByte()
+			if err != nil || next == '\\' {
+				break
+			}
+			buf = append(buf, b, next)
+			continue
+		}
+		buf = append(buf, b)
+	}
+	return string(buf)
+}
+
+// handleKittyReply shows a failed upload or placement in the status line
+// and retries the preview more cheaply: direct transmission instead of
+// files, then software playback, then frames at half size.
+func handleKittyReply(state *appState, text string) {
+	reply, ok := kitty.ParseReply(text)
+	if !ok || reply.OK {
+		return
+	}
+	state.status = "Kitty: " + reply.Code
+	if reply.Message != "" {
+		state.status += " " + reply.Message
+	}
+	state.renderDirty = true
+
+	anim := state.currentAnim
+	if anim == nil || reply.ID != anim.ID {
+		return
+	}
+	switch {
+	case state.sender.Transport != termcaps.KittyDirect && !anim.Direct:
+		anim.Direct = true
+	case !playsInSoftware(state, anim) && len(anim.Frames) > 1:
+		anim.Software = true
+	case !anim.Downscaled:
+		frames, err := downscaleFrames(anim.Frames)
+		if err != nil {
+			return
+		}
+		anim.Frames = frames
+		anim.Width = maxInt(1, anim.Width/2)
+		anim.Height = maxInt(1, anim.Height/2)
+		anim.Downscaled = true
+	default:
+		return
+	}
+	state.status += " · retrying"
+	state.previewNeedsSend = true
+}
+
+// senderFor is the sender for anim's frames: direct transmission once the
+// terminal failed to read them from a file, the detected transport
+// otherwise.
+func senderFor(state *appState, anim *gifAnimation) *kitty.Sender {
+	if anim != nil && anim.Direct {
+		return &kitty.Sender{Replies: state.sender.Replies}
+	}
+	return &state.sender
+}
+
+// downscaleFrames halves every frame.
+func downscaleFrames(frames []gifdecode.Frame) ([]gifdecode.Frame, error) {
+	out := make([]gifdecode.Frame, len(frames))
+	for i, frame := range frames {
+		img, err := png.Decode(bytes.NewReader(frame.PNG))
+		if err != nil {
+			return nil, err
+		}
+		b := img.Bounds()
+		scaled := gifdecode.Scale(img, maxInt(1, b.Dx()/2), maxInt(1, b.Dy()/2))
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, scaled); err != nil {
+			return nil, err
+		}
+		out[i] = frame
+		out[i].PNG = buf.Bytes()
+	}
+	return out, nil
+}
@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/internal/termcaps"
+)
+
+func TestReadInputGraphicsReply(t *testing.T) {
+	r := bytes.NewReader([]byte("\x1b_Gi=5;EINVAL:bad frame\x1b\\x"))
+	ch := make(chan inputEvent, 4)
+	stop := make(chan struct{})
+	readInput(r, ch, stop)
+	close(ch)
+
+	ev := <-ch
+	if ev.kind != keyGraphicsReply || ev.text != "Gi=5;EINVAL:bad frame" {
+		t.Fatalf("unexpected reply event: %+v", ev)
+	}
+	if ev := <-ch; ev.kind != keyRune || ev.ch != 'x' {
+		t.Fatalf("expected input after the reply, got %+v", ev)
+	}
+}
+
+func TestHandleKittyReplyRetries(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 8, 4))); err != nil {
+		t.Fatalf("png encode failed: %v", err)
+	}
+	frame := gifdecode.Frame{PNG: buf.Bytes(), Delay: 50 * time.Millisecond}
+	state := &appState{
+		inline:      termcaps.InlineKitty,
+		currentAnim: &gifAnimation{ID: 5, Frames: []gifdecode.Frame{frame, frame}, Width: 8, Height: 4},
+	}
+
+	state.sender.Transport = termcaps.KittyFile
+	handleKittyReply(state, "Gi=5;EBADF:failed to read file")
+	if !state.currentAnim.Direct || state.sender.Transport != termcaps.KittyFile || !state.previewNeedsSend {
+		t.Fatalf("expected direct transmission retry for this preview only")
+	}
+	if s := senderFor(state, state.currentAnim); s.Transport != termcaps.KittyDirect {
+		t.Fatalf("expected this preview sent directly, got %v", s.Transport)
+	}
+	if !strings.HasPrefix(state.status, "Kitty: EBADF failed to read file") {
+		t.Fatalf("unexpected status %q", state.status)
+	}
+
+	state.previewNeedsSend = false
+	handleKittyReply(state, "Gi=5;ENOSPC:out of quota")
+	if !state.currentAnim.Software || state.useSoftwareAnim || !state.previewNeedsSend {
+		t.Fatalf("expected software animation retry for this preview only")
+	}
+
+	state.previewNeedsSend = false
+	handleKittyReply(state, "Gi=5;ENOSPC:out of quota")
+	if !state.currentAnim.Downscaled || state.currentAnim.Width != 4 || !state.previewNeedsSend {
+		t.Fatalf("expected downscaled retry: %+v", state.currentAnim)
+	}
+	img, err := png.Decode(bytes.NewReader(state.currentAnim.Frames[0].PNG))
+	if err != nil || img.Bounds().Dx() != 4 || img.Bounds().Dy() != 2 {
+		t.Fatalf("expected 4x2 frame, got %v (%v)", img.Bounds(), err)
+	}
+
+	state.previewNeedsSend = false
+	handleKittyReply(state, "Gi=5;ENOSPC:out of quota")
+	if state.previewNeedsSend || strings.Contains(state.status, "retrying") {
+		t.Fatalf("expected no further retries, status %q", state.status)
+	}
+
+	// Replies for other images only show up in the status line.
+	handleKittyReply(state, "Gi=9;ENOENT:unknown image")
+	if state.previewNeedsSend || state.status != "Kitty: ENOENT unknown image" {
+		t.Fatalf("unexpected handling of foreign reply: %q", state.status)
+	}
+	handleKittyReply(state, "Gi=5;OK")
+	if state.status != "Kitty: ENOENT unknown image" {
+		t.Fatalf("expected OK replies to be ignored")
+	}
+}
@@ -73,6 +73,40 @@ func TestDrawPreviewSoftwareDirty(t *testing.T) {
 	}
 }
 
+func TestDrawPreviewPlaceholders(t *testing.T) {
+	state := &appState{
+		inline:            termcaps.InlineKitty,
+		kittyPlaceholders: true,
+		currentAnim: &gifAnimation{
+			ID: 3,
+			Frames: []gifdecode.Frame{
+				{PNG: []byte{1, 2, 3}, Delay: 10 * time.Millisecond},
+				{PNG: []byte{4, 5, 6}, Delay: 10 * time.Millisecond},
+			},
+		},
+		previewNeedsSend: true,
+	}
+	var buf bytes.Buffer
+	out := bufio.NewWriter(&buf)
+	drawPreview(state, out, 4, 2, 2, 2)
+	_ = out.Flush()
+	s := buf.String()
+	if !strings.Contains(s, "U=1") || !strings.Contains(s, "a=f") {
+		t.Fatalf("expected virtual animation: %q", s)
+	}
+	if strings.Count(s, "\U0010EEEE") != 8 {
+		t.Fatalf("expected 4x2 placeholder cells: %q", s)
+	}
+
+	// Placeholders are text: later renders redraw them without resending.
+	buf.Reset()
+	drawPreview(state, out, 4, 2, 2, 2)
+	_ = out.Flush()
+	if s := buf.String(); strings.Contains(s, "\x1b_G") || strings.Count(s, "\U0010EEEE") != 8 {
+		t.Fatalf("expected placeholder redraw only: %q", s)
+	}
+}
+
 func TestAdvanceManualAnimationGuards(t *testing.T) {
 	var buf bytes.Buffer
 	out := bufio.NewWriter(&buf)
@@ -0,0 +1,285 @@
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/steipete/gifgrep/internal/clipboard"
+	"github.com/steipete/gifgrep/internal/download"
+	"github.com/steipete/gifgrep/internal/favorites"
+	"github.com/steipete/gifgrep/internal/model"
+)
+
+var errNoURL = errors.New("no URL")
+
+var writeToDownloadsFn = download.WriteToDownloads
+
+// batchProgress reports one finished download of a background batch.
+type batchProgress struct {
+	item        model.Result
+	path        string
+	err         error
+	done, total int
+}
+
+func isMarked(state *appState, idx int) bool {
+	return idx >= 0 && idx < len(state.results) && state.marked[resultKey(state.results[idx])]
+}
+
+// markedResults are the marked results in list order.
+func markedResults(state *appState) []model.Result {
+	if len(state.marked) == 0 {
+		return nil
+	}
+	var out []model.Result
+	for _, r := range state.results {
+		if state.marked[resultKey(r)] {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// batchTargets are what batch actions act on: the marked results, or the
+// selection when nothing is marked.
+func batchTargets(state *appState) []model.Result {
+	if items := markedResults(state); len(items) > 0 {
+		return items
+	}
+	if state.selected < 0 || state.selected >= len(state.results) {
+		return nil
+	}
+	return []model.Result{state.results[state.selected]}
+}
+
+func clearMarks(state *appState) {
+	state.marked = nil
+	state.markAnchor = ""
+}
+
+// toggleMark marks or unmarks the selection and moves on, so a run of
+// space presses marks consecutive results.
+func toggleMark(state *appState) {
+	if state.selected < 0 || state.selected >= len(state.results) {
+		return
+	}
+	if state.marked == nil {
+		state.marked = map[string]bool{}
+	}
+	key := resultKey(state.results[state.selected])
+	if state.marked[key] {
+		delete(state.marked, key)
+	} else {
+		state.marked[key] = true
+	}
+	state.markAnchor = key
+	markStatus(state)
+	selectResult(state, state.selected+1)
+	state.renderDirty = true
+}
+
+// markRange marks every result between the last mark and the selection.
+// Without an earlier mark it marks the selection.
+func markRange(state *appState) {
+	if state.selected < 0 || state.selected >= len(state.results) {
+		return
+	}
+	if state.marked == nil {
+		state.marked = map[string]bool{}
+	}
+	// The anchor is a result, not a row, so it survives filtering and
+	// sorting; if the filter hid it, start from the selection.
+	from := state.selected
+	if len(state.marked) > 0 {
+		for i, r := range state.results {
+			if resultKey(r) == state.markAnchor {
+				from = i
+				break
+			}
+		}
+	}
+	for i := minInt(from, state.selected); i <= maxInt(from, state.selected); i++ {
+		state.marked[resultKey(state.results[i])] = true
+	}
+	state.markAnchor = resultKey(state.results[state.selected])
+	markStatus(state)
+	state.renderDirty = true
+}
+
+func markStatus(state *appState) {
+	n := len(state.marked)
+	if n == 0 {
+		state.status = "No marks"
+		return
+	}
+	k := state.keymap()
+	key := func(a action) string { return displayKey(firstKey(k, a)) }
+	state.status = fmt.Sprintf("%d marked · %s download · %s copy URLs · %s favorite · %s export · %s clear",
+		n, key(actDownload), key(actCopyURL), key(actFavorite), key(actExport), key(actUnmarkAll))
+}
+
+// copyMarkedAs copies every marked result as one text list.
+func copyMarkedAs(state *appState, out *bufio.Writer, format clipboard.Format, items []model.Result) {
+	if format == clipboard.FormatGIF || format == clipboard.FormatDataURI {
+		flashHeader(state, "Copy takes one GIF · "+displayKey(firstKey(state.keymap(), actCopyURL))+" copies the marked URLs")
+		state.renderDirty = true
+		return
+	}
+	srcs := make([]clipboard.Source, 0, len(items))
+	for _, item := range items {
+		if item.URL != "" {
+			srcs = append(srcs, clipboard.Source{URL: item.URL, Title: item.Title})
+		}
+	}
+	if len(srcs) == 0 {
+		flashHeader(state, "No URL")
+		state.renderDirty = true
+		return
+	}
+	p, err := clipboard.NewListPayload(format, srcs)
+	if err == nil {
+		err = copyPayloadFn(p, out)
+	}
+	if err != nil {
+		flashHeader(state, "Copy failed: "+err.Error())
+		state.renderDirty = true
+		return
+	}
+	flashHeader(state, fmt.Sprintf("%s (%d)", copyFlash[format], len(srcs)))
+	state.renderDirty = true
+}
+
+// downloadMarked downloads items one after another in the background.
+// Progress arrives on state.batchCh and shows in the header.
+func downloadMarked(state *appState, items []model.Result) {
+	if state.batchRunning {
+		flashHeader(state, "A download is already running")
+		state.renderDirty = true
+		return
+	}
+	if state.batchCh == nil {
+		state.batchCh = make(chan batchProgress, 16)
+	}
+	state.batchRunning = true
+	state.batchFailed = 0
+	state.batchErr = nil
+	batchFlash(state, fmt.Sprintf("Downloading 0/%d…", len(items)))
+	ch := state.batchCh
+	go func() {
+		for i, item := range items {
+			p := batchProgress{item: item, done: i + 1, total: len(items)}
+			if item.URL == "" {
+				p.err = errNoURL
+			} else {
+				p.path, p.err = downloadToDownloadsFn(item)
+			}
+			ch <- p
+		}
+	}()
+}
+
+func handleBatchProgress(state *appState, p batchProgress) {
+	if p.err != nil {
+		state.batchFailed++
+		state.batchErr = p.err
+	} else {
+		state.lastSavedPath = p.path
+		trackSavedPath(state, p.item, p.path)
+	}
+	state.renderDirty = true
+	if p.done < p.total {
+		batchFlash(state, fmt.Sprintf("Downloading %d/%d…", p.done, p.total))
+		return
+	}
+	state.batchRunning = false
+	saved := p.total - state.batchFailed
+	if state.batchFailed == 0 {
+		flashHeader(state, fmt.Sprintf("Saved %d", saved))
+		return
+	}
+	flashHeader(state, fmt.Sprintf("Saved %d, %d failed: %v", saved, state.batchFailed, state.batchErr))
+}
+
+// batchFlash shows msg in the header until the next flash replaces it.
+func batchFlash(state *appState, msg string) {
+	flashHeader(state, msg)
+	state.headerFlashAt = time.Time{}
+}
+
+func addFavorites(state *appState) {
+	items := batchTargets(state)
+	if len(items) == 0 {
+		flashHeader(state, "No selection")
+		state.renderDirty = true
+		return
+	}
+	n, err := favorites.Add(favorites.Path(), items...)
+	switch {
+	case err != nil:
+		flashHeader(state, "Favorites: "+err.Error())
+	case n == 0:
+		flashHeader(state, "Already in favorites")
+	default:
+		flashHeader(state, fmt.Sprintf("Added %d to favorites", n))
+	}
+	state.renderDirty = true
+}
+
+// exportMarkdown writes the batch as Markdown images, one paragraph each,
+// to the download directory.
+func exportMarkdown(state *appState) {
+	items := batchTargets(state)
+	if len(items) == 0 {
+		flashHeader(state, "No selection")
+		state.renderDirty = true
+		return
+	}
+	var b strings.Builder
+	title, name := "gifgrep", "gifgrep.md"
+	if q := strings.TrimSpace(state.query); q != "" {
+		title, name = q, "gifgrep-"+q+".md"
+	}
+	_, _ = fmt.Fprintf(&b, "# %s\n", title)
+	for _, item := range items {
+		if item.URL != "" {
+			_, _ = fmt.Fprintf(&b, "\n%s\n", clipboard.Markdown(item.Title, item.URL))
+		}
+	}
+	path, err := writeToDownloadsFn(name, []byte(b.String()))
+	if err != nil {
+		flashHeader(state, "Export failed: "+err.Error())
+		state.renderDirty = true
+		return
+	}
+	flashHeader(state, fmt.Sprintf("Exported %d to %s", len(items), path))
+	state.renderDirty = true
+}
+
+// resultLabel is a list row or tile title: a cursor, a mark and the title,
+// with find matches underlined.
+func resultLabel(state *appState, idx int) string {
+	item := state.results[idx]
+	label := item.Title
+	if label == "" {
+		label = item.ID
+	}
+	th := state.theme()
+	cursor, mark := " ", " "
+	if matchesFind(state, idx) {
+		label = styleIf(state.useColor, label, "\x1b[4m")
+		if !state.useColor {
+			cursor = "*"
+		}
+	}
+	if isMarked(state, idx) {
+		mark = styleIf(state.useColor, "+", "\x1b[1m", th.flash)
+	}
+	if idx == state.selected {
+		cursor = styleIf(state.useColor, ">", "\x1b[1m", th.accent)
+		label = styleIf(state.useColor, label, "\x1b[1m")
+	}
+	return cursor + mark + label
+}
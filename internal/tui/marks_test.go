@@ -0,0 +1,146 @@
+package tui
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steipete/gifgrep/internal/clipboard"
+	"github.com/steipete/gifgrep/internal/download"
+	"github.com/steipete/gifgrep/internal/favorites"
+	"github.com/steipete/gifgrep/internal/model"
+)
+
+func markState(t *testing.T) (*appState, func(...inputEvent)) {
+	t.Helper()
+	state, out := navState(t, "", 100)
+	for i := range state.results {
+		state.results[i].URL = "https://example.test/" + state.results[i].ID + ".gif"
+	}
+	return state, func(evs ...inputEvent) { press(state, out, evs...) }
+}
+
+func TestMarkToggleAndRange(t *testing.T) {
+	state, keys := markState(t)
+	keys(runes(" ")...)
+	if !isMarked(state, 0) || state.selected != 1 {
+		t.Fatalf("expected space to mark and move on, got selected %d", state.selected)
+	}
+	keys(runes("V")...)
+	if len(state.marked) != 2 {
+		t.Fatalf("expected V to mark from the last mark, got %d", len(state.marked))
+	}
+	keys(inputEvent{kind: keyDown}, inputEvent{kind: keyDown}, inputEvent{kind: keyDown})
+	keys(runes("V")...)
+	if len(state.marked) != 5 || !isMarked(state, 4) {
+		t.Fatalf("expected 0-4 marked, got %v", state.marked)
+	}
+	keys(runes(" ")...)
+	if isMarked(state, 4) || len(state.marked) != 4 || state.selected != 5 {
+		t.Fatalf("expected space to unmark 4, got %v", state.marked)
+	}
+	if !strings.HasPrefix(state.status, "4 marked") {
+		t.Fatalf("unexpected status %q", state.status)
+	}
+	if got := resultLabel(state, 1); got != " +gif 1" {
+		t.Fatalf("unexpected label %q", got)
+	}
+	if got := resultLabel(state, 5); got != "> gif 5" {
+		t.Fatalf("unexpected selected label %q", got)
+	}
+	keys(runes("u")...)
+	if len(state.marked) != 0 {
+		t.Fatalf("expected u to clear marks")
+	}
+}
+
+func TestMarkRangeAfterFilter(t *testing.T) {
+	state, keys := filterState(t)
+	keys(inputEvent{kind: keyDown}, inputEvent{kind: keyDown}, inputEvent{kind: keyDown})
+	keys(runes(" ")...)
+	state.filterText = "cat sort:-size"
+	applyFilter(state)
+	if ids(state.results) != "adbc" {
+		t.Fatalf("unexpected results %q", ids(state.results))
+	}
+	selectResult(state, 2)
+	keys(runes("V")...)
+	if len(state.marked) != 2 || !isMarked(state, 1) || !isMarked(state, 2) {
+		t.Fatalf("expected V to mark from d to b, got %v", state.marked)
+	}
+}
+
+func TestBatchCopyAndDownload(t *testing.T) {
+	state, keys := markState(t)
+	prevCopy, prevDownload := copyPayloadFn, downloadToDownloadsFn
+	t.Cleanup(func() { copyPayloadFn, downloadToDownloadsFn = prevCopy, prevDownload })
+	var copied clipboard.Payload
+	copyPayloadFn = func(p clipboard.Payload, _ io.Writer) error {
+		copied = p
+		return nil
+	}
+	downloadToDownloadsFn = func(r model.Result) (string, error) {
+		if r.ID == "3" {
+			return "", errors.New("boom")
+		}
+		return "/tmp/" + r.ID + ".gif", nil
+	}
+
+	keys(inputEvent{kind: keyDown}, inputEvent{kind: keyDown})
+	keys(runes("  ")...)
+	keys(runes("y")...)
+	if text, _ := copied.Text(); text != "https://example.test/2.gif\nhttps://example.test/3.gif" {
+		t.Fatalf("expected both URLs, got %q", text)
+	}
+	keys(runes("c")...)
+	if !strings.HasPrefix(state.headerFlash, "Copy takes one GIF") {
+		t.Fatalf("expected GIF copy to refuse several, got %q", state.headerFlash)
+	}
+
+	keys(runes("d")...)
+	if !state.batchRunning || state.headerFlash != "Downloading 0/2…" {
+		t.Fatalf("expected a background download, got %v %q", state.batchRunning, state.headerFlash)
+	}
+	handleBatchProgress(state, <-state.batchCh)
+	if state.headerFlash != "Downloading 1/2…" || !state.headerFlashAt.IsZero() {
+		t.Fatalf("expected progress to stay in the header, got %q", state.headerFlash)
+	}
+	handleBatchProgress(state, <-state.batchCh)
+	if state.batchRunning || state.headerFlash != "Saved 1, 1 failed: boom" {
+		t.Fatalf("unexpected result %v %q", state.batchRunning, state.headerFlash)
+	}
+	if p, ok := state.savedPaths["id:2"]; !ok || p != "/tmp/2.gif" {
+		t.Fatalf("expected the saved path tracked, got %v", state.savedPaths)
+	}
+}
+
+func TestFavoritesAndExport(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	download.SetDir(dir)
+	t.Cleanup(func() { download.SetDir("") })
+	state, keys := markState(t)
+	state.query = "cats"
+
+	keys(runes("*")...)
+	if got, _ := favorites.Load(favorites.Path()); len(got) != 1 || got[0].ID != "0" {
+		t.Fatalf("expected the selection in favorites, got %+v", got)
+	}
+	keys(runes("  *")...)
+	if got, _ := favorites.Load(favorites.Path()); len(got) != 2 || state.headerFlash != "Added 1 to favorites" {
+		t.Fatalf("expected only the new mark added, got %d %q", len(got), state.headerFlash)
+	}
+
+	keys(runes("E")...)
+	data, err := os.ReadFile(filepath.Join(dir, "gifgrep-cats.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# cats\n\n![gif 0](https://example.test/0.gif)\n\n![gif 1](https://example.test/1.gif)\n"
+	if string(data) != want {
+		t.Fatalf("unexpected export %q", data)
+	}
+}
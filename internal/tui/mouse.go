@@ -0,0 +1,119 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	mouseLeft      = 0
+	mouseWheelUp   = 64
+	mouseWheelDown = 65
+
+	// wheelRows is how far one wheel notch scrolls the list.
+	wheelRows = 3
+	// doubleClick is the longest gap between the clicks of a double-click.
+	doubleClick = 400 * time.Millisecond
+)
+
+// mouseEvent is an SGR (1006) mouse report. x and y are 1-based cells.
+type mouseEvent struct {
+	button  int
+	x, y    int
+	release bool
+}
+
+// enableMouse asks for button and wheel reports in SGR encoding, which
+// has no column limit.
+func enableMouse(out *bufio.Writer) {
+	_, _ = fmt.Fprint(out, "\x1b[?1000h\x1b[?1006h")
+}
+
+func disableMouse(out *bufio.Writer) {
+	_, _ = fmt.Fprint(out, "\x1b[?1006l\x1b[?1000l")
+}
+
+// parseSGRMouse decodes the parameters of CSI < b ; x ; y M (press) or m
+// (release). Modifier bits are dropped.
+func parseSGRMouse(params string, final byte) (mouseEvent, bool) {
+	if (final != 'M' && final != 'm') || !strings.HasPrefix(params, "<") {
+		return mouseEvent{}, false
+	}
+	parts := strings.Split(params[1:], ";")
+	if len(parts) != 3 {
+		return mouseEvent{}, false
+	}
+	var nums [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return mouseEvent{}, false
+		}
+		nums[i] = n
+	}
+	if nums[0]&32 != 0 {
+		// Motion; only reported in modes gifgrep doesn't enable.
+		return mouseEvent{}, false
+	}
+	return mouseEvent{button: nums[0] &^ (4 | 8 | 16), x: nums[1], y: nums[2], release: final == 'm'}, true
+}
+
+// handleMouse maps clicks on the list or a grid tile to selection (double-click
+// downloads), the wheel to scrolling and clicks on the preview to
+// play/pause.
+func handleMouse(state *appState, m mouseEvent, out *bufio.Writer) {
+	if state.mode != modeBrowse || m.release {
+		return
+	}
+	if state.showHelp {
+		toggleHelp(state, out)
+		return
+	}
+	switch m.button {
+	case mouseWheelUp:
+		scrollPage(state, -wheelRows)
+		return
+	case mouseWheelDown:
+		scrollPage(state, wheelRows)
+		return
+	case mouseLeft:
+	default:
+		return
+	}
+	l := buildLayout(state, state.lastRows, state.lastCols)
+	if !l.hasContent {
+		return
+	}
+	if l.previewRows > 0 && m.y >= l.previewRow && m.y < l.previewRow+l.previewRows &&
+		m.x >= l.previewCol && m.x < l.previewCol+l.previewCols {
+		togglePause(state, out)
+		return
+	}
+	idx, ok := listIndexAt(state, l, m.x, m.y)
+	if state.view == viewGrid {
+		idx, ok = gridIndexAt(state, l, m.x, m.y)
+	}
+	if !ok {
+		return
+	}
+	now := nowFn()
+	double := idx == state.lastClickIdx && !state.lastClickAt.IsZero() && now.Sub(state.lastClickAt) <= doubleClick
+	state.lastClickIdx, state.lastClickAt = idx, now
+	selectResult(state, idx)
+	if double {
+		state.lastClickAt = time.Time{}
+		downloadSelected(state, out, state.opts.Reveal)
+	}
+}
+
+// listIndexAt maps a cell to the list row under it.
+func listIndexAt(state *appState, l layout, x, y int) (int, bool) {
+	if y < l.contentTop || y >= l.contentTop+l.listHeight || x < l.listCol || x >= l.listCol+l.listWidth {
+		return 0, false
+	}
+	idx := state.scroll + y - l.contentTop
+	return idx, idx < len(state.results)
+}
@@ -0,0 +1,134 @@
+package tui
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/internal/model"
+	"github.com/steipete/gifgrep/internal/termcaps"
+)
+
+func TestParseSGRMouse(t *testing.T) {
+	r := bytes.NewReader([]byte("\x1b[<0;12;5M\x1b[<0;12;5m\x1b[<65;1;1M\x1b[<16;3;4M\x1b[<35;2;2M"))
+	ch := make(chan inputEvent, 8)
+	readInput(r, ch, make(chan struct{}))
+	close(ch)
+	want := []mouseEvent{
+		{button: mouseLeft, x: 12, y: 5},
+		{button: mouseLeft, x: 12, y: 5, release: true},
+		{button: mouseWheelDown, x: 1, y: 1},
+		{button: mouseLeft, x: 3, y: 4},
+	}
+	for i, w := range want {
+		ev := <-ch
+		if ev.kind != keyMouse || ev.mouse != w {
+			t.Fatalf("event %d: expected %+v, got %+v", i, w, ev)
+		}
+	}
+	if ev, ok := <-ch; ok {
+		t.Fatalf("expected motion report to be dropped, got %+v", ev)
+	}
+}
+
+func TestMouseSelectScrollAndDoubleClick(t *testing.T) {
+	state, out := navState(t, "", 100)
+	prevNow, prevDownload := nowFn, downloadToDownloadsFn
+	t.Cleanup(func() { nowFn, downloadToDownloadsFn = prevNow, prevDownload })
+	now := time.Unix(0, 0)
+	nowFn = func() time.Time { return now }
+	var downloaded []string
+	downloadToDownloadsFn = func(r model.Result) (string, error) {
+		downloaded = append(downloaded, r.ID)
+		return "", nil
+	}
+	for i := range state.results {
+		state.results[i].URL = "https://example.test/" + state.results[i].ID + ".gif"
+	}
+
+	click := func(x, y int) {
+		handleInput(state, inputEvent{kind: keyMouse, mouse: mouseEvent{button: mouseLeft, x: x, y: y}}, out, nil)
+		handleInput(state, inputEvent{kind: keyMouse, mouse: mouseEvent{button: mouseLeft, x: x, y: y, release: true}}, out, nil)
+	}
+	click(5, 6)
+	if state.selected != 4 {
+		t.Fatalf("expected row 6 to select result 4, got %d", state.selected)
+	}
+	now = now.Add(time.Second)
+	click(5, 6)
+	if len(downloaded) != 0 {
+		t.Fatalf("expected slow clicks not to download")
+	}
+	now = now.Add(100 * time.Millisecond)
+	click(5, 6)
+	if len(downloaded) != 1 || downloaded[0] != "4" {
+		t.Fatalf("expected double-click to download result 4, got %v", downloaded)
+	}
+
+	handleInput(state, inputEvent{kind: keyMouse, mouse: mouseEvent{button: mouseWheelDown, x: 1, y: 1}}, out, nil)
+	if state.scroll != wheelRows || state.selected != 4+wheelRows {
+		t.Fatalf("expected wheel to scroll, got scroll %d selected %d", state.scroll, state.selected)
+	}
+	click(5, 22)
+	if state.selected != 4+wheelRows {
+		t.Fatalf("expected clicks outside the list to be ignored, got %d", state.selected)
+	}
+}
+
+func TestClickPreviewTogglesPause(t *testing.T) {
+	frames := []gifdecode.Frame{{PNG: []byte{1}}, {PNG: []byte{2}}}
+	var buf bytes.Buffer
+	out := bufio.NewWriter(&buf)
+	state := &appState{
+		mode:          modeBrowse,
+		results:       []model.Result{{Title: "A"}},
+		cache:         map[string]*gifCacheEntry{},
+		inline:        termcaps.InlineKitty,
+		currentAnim:   &gifAnimation{ID: 9, Frames: frames, Width: 100, Height: 100},
+		activeImageID: 9,
+		lastRows:      30,
+		lastCols:      100,
+	}
+	l := buildLayout(state, state.lastRows, state.lastCols)
+	if l.previewRows == 0 {
+		t.Fatalf("expected a preview in the layout")
+	}
+	click := mouseEvent{button: mouseLeft, x: l.previewCol, y: l.previewRow}
+	handleInput(state, inputEvent{kind: keyMouse, mouse: click}, out, nil)
+	_ = out.Flush()
+	if !state.paused || !strings.Contains(buf.String(), "a=a,i=9,s=1") {
+		t.Fatalf("expected kitty stop, got paused=%v %q", state.paused, buf.String())
+	}
+	handleInput(state, inputEvent{kind: keyMouse, mouse: click}, out, nil)
+	_ = out.Flush()
+	if state.paused || !strings.Contains(buf.String(), "a=a,i=9,s=3") {
+		t.Fatalf("expected kitty resume, got paused=%v %q", state.paused, buf.String())
+	}
+
+	state.manualAnim = true
+	handleInput(state, inputEvent{kind: keyMouse, mouse: click}, out, nil)
+	state.manualNext = time.Now().Add(-time.Second)
+	state.lastPreview.cols, state.lastPreview.rows = 10, 10
+	state.previewRow, state.previewCol = 1, 1
+	advanceManualAnimation(state, out)
+	if state.manualFrame != 0 {
+		t.Fatalf("expected paused software playback to hold its frame")
+	}
+}
+
+func TestMouseReportingToggles(t *testing.T) {
+	var buf bytes.Buffer
+	out := bufio.NewWriter(&buf)
+	setupOutput(out, termcaps.InlineNone, true)()
+	if !strings.Contains(buf.String(), "\x1b[?1006h") || !strings.Contains(buf.String(), "\x1b[?1006l") {
+		t.Fatalf("expected SGR mouse on and off, got %q", buf.String())
+	}
+	buf.Reset()
+	setupOutput(out, termcaps.InlineNone, false)()
+	if strings.Contains(buf.String(), "1006") {
+		t.Fatalf("expected no mouse reporting, got %q", buf.String())
+	}
+}
@@ -0,0 +1,175 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// listRows is how many results the list shows at the current size.
+func listRows(state *appState) int {
+	l := buildLayout(state, state.lastRows, state.lastCols)
+	if !l.hasContent {
+		return 0
+	}
+	return l.listHeight
+}
+
+// selectResult moves the selection to idx (clamped) and scrolls it into
+// view.
+func selectResult(state *appState, idx int) {
+	if len(state.results) == 0 {
+		return
+	}
+	idx = maxInt(0, minInt(idx, len(state.results)-1))
+	if idx == state.selected {
+		return
+	}
+	state.selected = idx
+	ensureVisible(state)
+	loadSelectedImage(state)
+	state.renderDirty = true
+}
+
+// scrollPage moves the selection and the view by n rows together, so the
+// selection keeps its place on screen, like less and vim's Ctrl-D. Grid
+// rows hold several results.
+func scrollPage(state *appState, n int) {
+	if len(state.results) == 0 {
+		return
+	}
+	stride := gridStride(state)
+	n *= stride
+	rows := maxInt(1, listRows(state))
+	maxScroll := maxInt(0, (len(state.results)+stride-1)/stride-rows) * stride
+	state.scroll = maxInt(0, minInt(state.scroll+n, maxScroll))
+	selectResult(state, state.selected+n)
+	state.renderDirty = true
+}
+
+// takeCount returns the pending count (def when none) and clears it.
+func takeCount(state *appState, def int) int {
+	n := state.count
+	state.count = 0
+	if n <= 0 {
+		return def
+	}
+	return n
+}
+
+// handleCount collects digits typed before a motion. It reports whether
+// ev was consumed.
+func handleCount(state *appState, ev inputEvent) bool {
+	if ev.kind != keyRune || ev.ch < '0' || ev.ch > '9' || (ev.ch == '0' && state.count == 0) {
+		return false
+	}
+	if _, bound := state.actionFor(ev); bound {
+		return false
+	}
+	if state.count < 1_000_000 {
+		state.count = state.count*10 + int(ev.ch-'0')
+	}
+	state.status = fmt.Sprintf("%d… (⏎ or %s jumps to result %d)", state.count, displayKey(firstKey(state.keymap(), actTop)), state.count)
+	state.renderDirty = true
+	return true
+}
+
+// handleNavAction runs the motion actions; it reports whether a was one.
+func handleNavAction(state *appState, a action) bool {
+	rows := maxInt(1, listRows(state))
+	stride := gridStride(state)
+	switch a {
+	case actUp:
+		selectResult(state, state.selected-takeCount(state, 1)*stride)
+	case actDown:
+		selectResult(state, state.selected+takeCount(state, 1)*stride)
+	case actLeft, actRight:
+		n := takeCount(state, 1)
+		if state.view != viewGrid {
+			break
+		}
+		if a == actLeft {
+			n = -n
+		}
+		selectResult(state, state.selected+n)
+	case actTop:
+		selectResult(state, takeCount(state, 1)-1)
+	case actBottom:
+		selectResult(state, takeCount(state, len(state.results))-1)
+	case actPageUp:
+		scrollPage(state, -rows*takeCount(state, 1))
+	case actPageDown:
+		scrollPage(state, rows*takeCount(state, 1))
+	case actHalfPageUp:
+		scrollPage(state, -maxInt(1, rows/2)*takeCount(state, 1))
+	case actHalfPageDown:
+		scrollPage(state, maxInt(1, rows/2)*takeCount(state, 1))
+	case actNextMatch:
+		jumpToMatch(state, 1)
+	case actPrevMatch:
+		jumpToMatch(state, -1)
+	default:
+		return false
+	}
+	return true
+}
+
+func matchesFind(state *appState, idx int) bool {
+	needle := strings.ToLower(strings.TrimSpace(state.findText))
+	if needle == "" || idx < 0 || idx >= len(state.results) {
+		return false
+	}
+	return resultContains(state.results[idx], needle)
+}
+
+// jumpToMatch selects the next (dir 1) or previous (dir -1) result whose
+// title or tags contain the find text, wrapping around the list.
+func jumpToMatch(state *appState, dir int) {
+	state.count = 0
+	if strings.TrimSpace(state.findText) == "" {
+		state.status = "Nothing to find · " + displayKey(firstKey(state.keymap(), actFind)) + " to find in results"
+		state.renderDirty = true
+		return
+	}
+	n := len(state.results)
+	for step := 1; step <= n; step++ {
+		idx := ((state.selected+dir*step)%n + n) % n
+		if matchesFind(state, idx) {
+			selectResult(state, idx)
+			state.status = fmt.Sprintf("%q: result %d of %d", state.findText, idx+1, n)
+			state.renderDirty = true
+			return
+		}
+	}
+	state.status = fmt.Sprintf("%q: no match", state.findText)
+	state.renderDirty = true
+}
+
+func handleFindInput(state *appState, ev inputEvent, _ *bufio.Writer) bool {
+	switch ev.kind {
+	case keyRune:
+		state.findText += string(ev.ch)
+		state.renderDirty = true
+	case keyBackspace:
+		if runes := []rune(state.findText); len(runes) > 0 {
+			state.findText = string(runes[:len(runes)-1])
+			state.renderDirty = true
+		}
+	case keyEnter:
+		state.mode = modeBrowse
+		if matchesFind(state, state.selected) {
+			state.status = fmt.Sprintf("%q: result %d of %d", state.findText, state.selected+1, len(state.results))
+			state.renderDirty = true
+		} else {
+			jumpToMatch(state, 1)
+		}
+	case keyEsc:
+		state.mode = modeBrowse
+		state.findText = ""
+		state.status = ""
+		state.renderDirty = true
+	case keyCtrlC:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package tui
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/steipete/gifgrep/internal/model"
+)
+
+func navState(t *testing.T, preset string, n int) (*appState, *bufio.Writer) {
+	t.Helper()
+	keys, err := buildKeymap(preset, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := make([]model.Result, n)
+	for i := range results {
+		results[i] = model.Result{ID: fmt.Sprint(i), Title: fmt.Sprintf("gif %d", i)}
+	}
+	results[30].Title = "Dancing cat"
+	results[70].Tags = []string{"cat"}
+	state := &appState{
+		mode:     modeBrowse,
+		results:  results,
+		cache:    map[string]*gifCacheEntry{},
+		keys:     keys,
+		lastRows: 24,
+		lastCols: 80,
+	}
+	return state, bufio.NewWriter(&bytes.Buffer{})
+}
+
+func press(state *appState, out *bufio.Writer, keys ...inputEvent) {
+	for _, ev := range keys {
+		handleInput(state, ev, out, nil)
+	}
+}
+
+func runes(s string) []inputEvent {
+	var evs []inputEvent
+	for _, r := range s {
+		evs = append(evs, inputEvent{kind: keyRune, ch: r})
+	}
+	return evs
+}
+
+func TestVimMotions(t *testing.T) {
+	state, out := navState(t, "vim", 100)
+	rows := listRows(state)
+	if rows != 20 {
+		t.Fatalf("expected 20 list rows, got %d", rows)
+	}
+
+	press(state, out, runes("jjj")...)
+	if state.selected != 3 {
+		t.Fatalf("expected j to move down, got %d", state.selected)
+	}
+	press(state, out, runes("5j")...)
+	if state.selected != 8 || state.count != 0 {
+		t.Fatalf("expected 5j to move 5, got %d (count %d)", state.selected, state.count)
+	}
+	press(state, out, runes("G")...)
+	if state.selected != 99 || state.scroll != 80 {
+		t.Fatalf("expected G at the end, got %d scroll %d", state.selected, state.scroll)
+	}
+	press(state, out, runes("g")...)
+	if state.selected != 0 || state.scroll != 0 {
+		t.Fatalf("expected g at the top, got %d scroll %d", state.selected, state.scroll)
+	}
+	press(state, out, inputEvent{kind: keyCtrl, ch: 'd'})
+	if state.selected != 10 || state.scroll != 10 {
+		t.Fatalf("expected half page down, got %d scroll %d", state.selected, state.scroll)
+	}
+	press(state, out, inputEvent{kind: keyPgDn})
+	if state.selected != 30 || state.scroll != 30 {
+		t.Fatalf("expected page down, got %d scroll %d", state.selected, state.scroll)
+	}
+	press(state, out, inputEvent{kind: keyCtrl, ch: 'u'}, inputEvent{kind: keyPgUp})
+	if state.selected != 0 || state.scroll != 0 {
+		t.Fatalf("expected back at the top, got %d scroll %d", state.selected, state.scroll)
+	}
+	press(state, out, append(runes("42"), inputEvent{kind: keyEnter})...)
+	if state.selected != 41 || state.mode != modeBrowse {
+		t.Fatalf("expected 42⏎ to select result 42, got %d mode %v", state.selected, state.mode)
+	}
+	if state.selected < state.scroll || state.selected >= state.scroll+rows {
+		t.Fatalf("selection %d not visible from scroll %d", state.selected, state.scroll)
+	}
+	press(state, out, inputEvent{kind: keyEnter})
+	if state.mode != modeQuery {
+		t.Fatalf("expected plain enter to edit the search")
+	}
+}
+
+func TestDefaultPagingKeys(t *testing.T) {
+	state, out := navState(t, "", 100)
+	press(state, out, inputEvent{kind: keyEnd})
+	if state.selected != 99 {
+		t.Fatalf("expected End to select the last result, got %d", state.selected)
+	}
+	press(state, out, inputEvent{kind: keyHome})
+	if state.selected != 0 {
+		t.Fatalf("expected Home to select the first result, got %d", state.selected)
+	}
+	press(state, out, runes("7")...)
+	press(state, out, inputEvent{kind: keyEsc})
+	if state.count != 0 || state.mode != modeBrowse {
+		t.Fatalf("expected Esc to drop the count, got %d mode %v", state.count, state.mode)
+	}
+}
+
+func TestFindAndCycleMatches(t *testing.T) {
+	state, out := navState(t, "", 100)
+	press(state, out, runes("n")...)
+	if state.selected != 0 {
+		t.Fatalf("expected n without find text to stay")
+	}
+
+	press(state, out, inputEvent{kind: keyCtrl, ch: 'f'})
+	if state.mode != modeFind {
+		t.Fatalf("expected find mode")
+	}
+	if !strings.HasSuffix(state.status, "n/N cycle") {
+		t.Fatalf("unexpected find status %q", state.status)
+	}
+	press(state, out, runes("CAT q")...)
+	press(state, out, inputEvent{kind: keyBackspace}, inputEvent{kind: keyBackspace}, inputEvent{kind: keyEnter})
+	if state.mode != modeBrowse || state.findText != "CAT" || state.selected != 30 {
+		t.Fatalf("expected jump to first match, got mode %v find %q selected %d", state.mode, state.findText, state.selected)
+	}
+	press(state, out, runes("n")...)
+	if state.selected != 70 {
+		t.Fatalf("expected tag match, got %d", state.selected)
+	}
+	press(state, out, runes("n")...)
+	if state.selected != 30 {
+		t.Fatalf("expected wrap to first match, got %d", state.selected)
+	}
+	press(state, out, runes("N")...)
+	if state.selected != 70 {
+		t.Fatalf("expected N to go back around, got %d", state.selected)
+	}
+}
+
+func TestReadInputNavigationKeys(t *testing.T) {
+	r := bytes.NewReader([]byte("\x1b[5~\x1b[6~\x1b[H\x1b[4~\x1bOF\x04"))
+	ch := make(chan inputEvent, 8)
+	readInput(r, ch, make(chan struct{}))
+	close(ch)
+	want := []keyKind{keyPgUp, keyPgDn, keyHome, keyEnd, keyEnd, keyCtrl}
+	for i, kind := range want {
+		ev := <-ch
+		if ev.kind != kind {
+			t.Fatalf("event %d: expected kind %v, got %+v", i, kind, ev)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package tui
+
+import (
+	"bufio"
+	"time"
+
+	"github.com/steipete/gifgrep/internal/kitty"
+)
+
+// drawPreviewPlaceholders shows the preview as a Kitty virtual placement.
+// The image is sent once; its placeholder cells are ordinary text, so they
+// are redrawn on every render like the in-text previews.
+func drawPreviewPlaceholders(state *appState, out *bufio.Writer, cols, rows int, row, col int) {
+	anim := state.currentAnim
+	if state.previewNeedsSend {
+		if state.activeImageID != 0 && state.activeImageID != anim.ID {
+			kitty.DeleteImage(out, state.activeImageID)
+		}
+		state.activeImageID = anim.ID
+		if playsInSoftware(state, anim) {
+			state.manualAnim = true
+			if !state.paused {
+				state.manualFrame = 0
+			}
+			frame := anim.Frames[state.manualFrame]
+			state.manualNext = time.Now().Add(frameGap(state, frame.Delay))
+			senderFor(state, anim).SendVirtualFrame(out, anim.ID, frame, cols, rows)
+		} else {
+			senderFor(state, anim).SendVirtualAnimation(out, anim.ID, anim.Frames, cols, rows)
+			startNativePlayback(state, out)
+		}
+		state.previewNeedsSend = false
+	} else if state.lastPreview.cols != cols || state.lastPreview.rows != rows {
+		state.sender.PlaceVirtual(out, state.activeImageID, cols, rows)
+	}
+	saveCursor(out)
+	moveCursor(out, row, col)
+	kitty.WritePlaceholders(out, state.activeImageID, cols, rows)
+	restoreCursor(out)
+	state.previewDirty = false
+	state.lastPreview.cols = cols
+	state.lastPreview.rows = rows
+}
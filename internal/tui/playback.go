@@ -0,0 +1,295 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/internal/kitty"
+	"github.com/steipete/gifgrep/internal/termcaps"
+)
+
+const (
+	// maxSpeed bounds state.speed: previews play at 2^speed, ¼× to 4×.
+	maxSpeed = 2
+	// timelineWidth is the width of the status bar's progress bar.
+	timelineWidth = 10
+)
+
+// canControlPlayback reports whether the preview can be paused and
+// stepped. iTerm2 animates GIFs itself and can't be controlled.
+func canControlPlayback(state *appState) bool {
+	if state.currentAnim == nil || state.view == viewGrid {
+		return false
+	}
+	if state.inline == termcaps.InlineIterm {
+		state.status = "iTerm2 previews can't be paused"
+		state.renderDirty = true
+		return false
+	}
+	return len(state.currentAnim.Frames) > 1
+}
+
+// playsInSoftware reports whether anim's frames are sent one at a time
+// rather than uploaded as a Kitty animation.
+func playsInSoftware(state *appState, anim *gifAnimation) bool {
+	return (state.useSoftwareAnim || anim.Software) && len(anim.Frames) > 1
+}
+
+// playsNatively reports whether Kitty is animating the preview itself, so
+// playback changes go out as animation-control commands.
+func playsNatively(state *appState) bool {
+	return state.inline == termcaps.InlineKitty && !state.manualAnim && !state.previewNeedsSend &&
+		state.currentAnim != nil && state.activeImageID == state.currentAnim.ID
+}
+
+// frameGap is how long a frame with delay d shows at the current speed.
+func frameGap(state *appState, d time.Duration) time.Duration {
+	gap := time.Duration(float64(d) / math.Pow(2, float64(state.speed)))
+	return max(gap, 10*time.Millisecond)
+}
+
+// currentFrame is the frame on screen. Software playback tracks it
+// directly; a native Kitty animation is followed by the clock from where
+// it last started.
+func currentFrame(state *appState) int {
+	frames := state.currentAnim.Frames
+	if state.manualAnim || state.paused || state.playStart.IsZero() || len(frames) == 0 {
+		return state.manualFrame
+	}
+	var total time.Duration
+	for _, fr := range frames {
+		total += frameGap(state, fr.Delay)
+	}
+	elapsed := nowFn().Sub(state.playStart) % total
+	i := state.playStartFrame % len(frames)
+	for {
+		gap := frameGap(state, frames[i].Delay)
+		if elapsed < gap {
+			return i
+		}
+		elapsed -= gap
+		i = (i + 1) % len(frames)
+	}
+}
+
+// startNativePlayback follows a freshly sent Kitty animation, applying
+// the speed and holding the paused frame.
+func startNativePlayback(state *appState, out *bufio.Writer) {
+	id := state.currentAnim.ID
+	if state.speed != 0 {
+		applyNativeSpeed(state, out)
+	}
+	if state.paused {
+		kitty.StopAnimation(out, id)
+		kitty.ShowFrame(out, id, state.manualFrame+1)
+		return
+	}
+	state.manualFrame = 0
+	state.playStart, state.playStartFrame = nowFn(), 0
+}
+
+func applyNativeSpeed(state *appState, out *bufio.Writer) {
+	for i, fr := range state.currentAnim.Frames {
+		kitty.SetFrameGap(out, state.currentAnim.ID, i+1, frameGap(state, fr.Delay))
+	}
+}
+
+// togglePause stops or resumes the preview. Kitty animates natively and
+// takes an animation-control command, pinned to the frame the counter
+// shows; software playback just stops advancing frames.
+func togglePause(state *appState, out *bufio.Writer) {
+	if !canControlPlayback(state) {
+		return
+	}
+	if state.paused {
+		resumePlayback(state, out)
+		state.status = "Playing"
+	} else {
+		pausePlayback(state, out)
+		state.status = "Paused"
+	}
+	state.renderDirty = true
+}
+
+func pausePlayback(state *appState, out *bufio.Writer) {
+	if playsNatively(state) {
+		state.manualFrame = currentFrame(state)
+		kitty.StopAnimation(out, state.activeImageID)
+		kitty.ShowFrame(out, state.activeImageID, state.manualFrame+1)
+	}
+	state.paused = true
+	state.playStart = time.Time{}
+}
+
+func resumePlayback(state *appState, out *bufio.Writer) {
+	state.paused = false
+	if playsNatively(state) {
+		kitty.LoopAnimation(out, state.activeImageID)
+		state.playStart, state.playStartFrame = nowFn(), state.manualFrame
+	}
+	if state.manualAnim {
+		state.manualNext = nowFn()
+	}
+}
+
+// stepFrame pauses the preview and moves dir frames, wrapping around.
+func stepFrame(state *appState, out *bufio.Writer, dir int) {
+	if !canControlPlayback(state) {
+		return
+	}
+	if !state.paused {
+		pausePlayback(state, out)
+	}
+	n := len(state.currentAnim.Frames)
+	state.manualFrame = ((state.manualFrame+dir)%n + n) % n
+	switch {
+	case playsNatively(state):
+		kitty.ShowFrame(out, state.activeImageID, state.manualFrame+1)
+	case state.manualAnim && state.lastPreview.cols > 0 && state.previewRow > 0:
+		drawManualFrame(state, out)
+	default:
+		state.previewDirty = true
+	}
+	state.status = fmt.Sprintf("Frame %d of %d", state.manualFrame+1, n)
+	state.renderDirty = true
+}
+
+// changeSpeed doubles (delta 1) or halves (delta -1) the playback speed.
+func changeSpeed(state *appState, out *bufio.Writer, delta int) {
+	if !canControlPlayback(state) {
+		return
+	}
+	speed := maxInt(-maxSpeed, minInt(maxSpeed, state.speed+delta))
+	if speed != state.speed {
+		if playsNatively(state) && !state.paused {
+			state.playStartFrame, state.playStart = currentFrame(state), nowFn()
+		}
+		state.speed = speed
+		if playsNatively(state) {
+			applyNativeSpeed(state, out)
+		}
+	}
+	state.status = "Speed " + speedLabel(state.speed)
+	state.renderDirty = true
+}
+
+func speedLabel(speed int) string {
+	switch speed {
+	case -2:
+		return "¼×"
+	case -1:
+		return "½×"
+	}
+	return fmt.Sprintf("%d×", 1<<maxInt(speed, 0))
+}
+
+// drawManualFrame draws state.manualFrame of software playback in place.
+func drawManualFrame(state *appState, out *bufio.Writer) {
+	frame := state.currentAnim.Frames[state.manualFrame]
+	saveCursor(out)
+	if drawsInText(state.inline) {
+		drawTextFrame(state, out, state.lastPreview.cols, state.lastPreview.rows, state.previewRow, state.previewCol)
+	} else if state.kittyPlaceholders {
+		senderFor(state, state.currentAnim).SendVirtualFrame(out, state.activeImageID, frame, state.lastPreview.cols, state.lastPreview.rows)
+	} else {
+		moveCursor(out, state.previewRow, state.previewCol)
+		senderFor(state, state.currentAnim).SendFrame(out, state.activeImageID, frame, state.lastPreview.cols, state.lastPreview.rows)
+	}
+	restoreCursor(out)
+}
+
+// timelineText is the status bar's playback readout, e.g.
+// "⏸ 12/48 ━━━━────── ½×", or "" when the preview can't be controlled.
+func timelineText(state *appState) string {
+	if state.currentAnim == nil || len(state.currentAnim.Frames) <= 1 || state.inline == termcaps.InlineIterm ||
+		state.view == viewGrid || state.showHelp {
+		return ""
+	}
+	n := len(state.currentAnim.Frames)
+	f := currentFrame(state) + 1
+	icon := "▶"
+	if state.paused {
+		icon = "⏸"
+	}
+	filled := f * timelineWidth / n
+	bar := strings.Repeat("━", filled) + strings.Repeat("─", timelineWidth-filled)
+	digits := len(fmt.Sprint(n))
+	return fmt.Sprintf("%s %*d/%d %s %s", icon, digits, f, n, bar, speedLabel(state.speed))
+}
+
+// drawTimeline writes the playback readout at the right end of the status
+// row when it fits after the status text.
+func drawTimeline(out *bufio.Writer, state *appState, l layout, width int, status string) {
+	text := timelineText(state)
+	state.timeline = ""
+	if text == "" || visibleRuneLen(status)+runeLen(text)+2 > width {
+		return
+	}
+	moveCursor(out, l.statusRow, width-runeLen(text)+1)
+	_, _ = fmt.Fprint(out, styleIf(state.useColor, text, state.theme().dim))
+	state.timeline = text
+}
+
+// updateTimeline redraws the readout between renders as frames advance.
+func updateTimeline(state *appState, out *bufio.Writer) {
+	if state.timeline == "" || state.renderDirty {
+		return
+	}
+	text := timelineText(state)
+	if text == state.timeline {
+		return
+	}
+	if runeLen(text) != runeLen(state.timeline) {
+		state.renderDirty = true
+		return
+	}
+	l := buildLayout(state, state.lastRows, state.lastCols)
+	width := statusWidth(state, l)
+	saveCursor(out)
+	moveCursor(out, l.statusRow, width-runeLen(text)+1)
+	_, _ = fmt.Fprint(out, styleIf(state.useColor, text, state.theme().dim))
+	restoreCursor(out)
+	state.timeline = text
+	_ = out.Flush()
+}
+
+// saveFrame writes the frame on screen, decoded at full size, as a PNG to
+// the download directory.
+func saveFrame(state *appState) {
+	anim := state.currentAnim
+	if anim == nil || len(anim.RawGIF) == 0 || state.selected < 0 || state.selected >= len(state.results) {
+		flashHeader(state, "No preview")
+		state.renderDirty = true
+		return
+	}
+	i := 0
+	if len(anim.Frames) > 0 {
+		i = currentFrame(state)
+	}
+	decoded, err := gifdecode.Decode(anim.RawGIF, gifdecode.DefaultOptions())
+	if err == nil && i >= len(decoded.Frames) {
+		err = errNoPreview
+	}
+	if err != nil {
+		flashHeader(state, "Save failed: "+err.Error())
+		state.renderDirty = true
+		return
+	}
+	item := state.results[state.selected]
+	name := item.Title
+	if name == "" {
+		name = item.ID
+	}
+	path, err := writeToDownloadsFn(fmt.Sprintf("%s-frame%d.png", name, i+1), decoded.Frames[i].PNG)
+	if err != nil {
+		flashHeader(state, "Save failed: "+err.Error())
+		state.renderDirty = true
+		return
+	}
+	flashHeader(state, fmt.Sprintf("Saved frame %d to %s", i+1, path))
+	state.renderDirty = true
+}
@@ -0,0 +1,169 @@
+package tui
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/internal/model"
+	"github.com/steipete/gifgrep/internal/termcaps"
+	"github.com/steipete/gifgrep/internal/testutil"
+)
+
+func playbackState(t *testing.T, inline termcaps.InlineProtocol) (*appState, *bytes.Buffer, func(string)) {
+	t.Helper()
+	keys, err := buildKeymap("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := []gifdecode.Frame{
+		{PNG: []byte("a"), Delay: 100 * time.Millisecond},
+		{PNG: []byte("b"), Delay: 100 * time.Millisecond},
+		{PNG: []byte("c"), Delay: 100 * time.Millisecond},
+	}
+	var buf bytes.Buffer
+	out := bufio.NewWriter(&buf)
+	state := &appState{
+		mode:          modeBrowse,
+		results:       []model.Result{{ID: "1", Title: "A"}},
+		cache:         map[string]*gifCacheEntry{},
+		keys:          keys,
+		inline:        inline,
+		currentAnim:   &gifAnimation{ID: 9, Frames: frames, Width: 100, Height: 100},
+		activeImageID: 9,
+		lastRows:      30,
+		lastCols:      100,
+	}
+	return state, &buf, func(s string) {
+		for _, ev := range runes(s) {
+			handleInput(state, ev, out, nil)
+		}
+		_ = out.Flush()
+	}
+}
+
+func TestNativePlaybackControls(t *testing.T) {
+	prevNow := nowFn
+	t.Cleanup(func() { nowFn = prevNow })
+	now := time.Unix(100, 0)
+	nowFn = func() time.Time { return now }
+
+	state, buf, keys := playbackState(t, termcaps.InlineKitty)
+	state.playStart = now.Add(-450 * time.Millisecond)
+	if got := timelineText(state); got != "▶ 2/3 ━━━━━━──── 1×" {
+		t.Fatalf("unexpected timeline %q", got)
+	}
+
+	keys("p")
+	if !state.paused || state.manualFrame != 1 || !strings.Contains(buf.String(), "a=a,i=9,s=1,q=2\x1b\\\x1b_Ga=a,i=9,c=2,") {
+		t.Fatalf("expected a stop pinned to frame 2, got frame %d %q", state.manualFrame, buf.String())
+	}
+	buf.Reset()
+	keys(".")
+	if !strings.Contains(buf.String(), "a=a,i=9,c=3,") || state.status != "Frame 3 of 3" {
+		t.Fatalf("expected a step to frame 3, got %q %q", buf.String(), state.status)
+	}
+	buf.Reset()
+	keys(".")
+	if !strings.Contains(buf.String(), "c=1,") {
+		t.Fatalf("expected the step to wrap to frame 1, got %q", buf.String())
+	}
+	keys(",")
+	if state.manualFrame != 2 {
+		t.Fatalf("expected , to wrap back, got %d", state.manualFrame)
+	}
+
+	buf.Reset()
+	keys("]")
+	if state.speed != 1 || !strings.Contains(buf.String(), "a=a,i=9,r=3,z=50,") || state.status != "Speed 2×" {
+		t.Fatalf("expected halved frame gaps, got %q %q", buf.String(), state.status)
+	}
+	keys("]]]")
+	if state.speed != maxSpeed || state.status != "Speed 4×" {
+		t.Fatalf("expected the speed capped at 4×, got %d", state.speed)
+	}
+	keys("[[")
+	buf.Reset()
+	keys("p")
+	if state.paused || !strings.Contains(buf.String(), "a=a,i=9,s=3,") {
+		t.Fatalf("expected kitty resume, got %q", buf.String())
+	}
+	now = now.Add(60 * time.Millisecond)
+	if f := currentFrame(state); f != 2 {
+		t.Fatalf("expected the clock to hold frame 3 for 100ms at 1×, got %d", f)
+	}
+	now = now.Add(50 * time.Millisecond)
+	if f := currentFrame(state); f != 0 {
+		t.Fatalf("expected the clock to wrap to frame 1, got %d", f)
+	}
+}
+
+func TestSoftwarePlaybackStepAndTimeline(t *testing.T) {
+	prevEncode := encodeBlocksFrameFn
+	t.Cleanup(func() { encodeBlocksFrameFn = prevEncode })
+	encodeBlocksFrameFn = func(frame gifdecode.Frame, cols, rows int, _ termcaps.TextStyle) ([]byte, error) {
+		return []byte("<" + string(frame.PNG) + ">"), nil
+	}
+
+	state, buf, keys := playbackState(t, termcaps.InlineBlocks)
+	state.activeImageID = 0
+	state.previewNeedsSend = true
+	var out bytes.Buffer
+	w := bufio.NewWriter(&out)
+	render(state, w, state.lastRows, state.lastCols)
+	_ = w.Flush()
+	if !state.manualAnim || !strings.Contains(out.String(), "▶ 1/3 ━━━─────── 1×") {
+		t.Fatalf("expected software playback with a timeline, got %q", out.String())
+	}
+
+	keys(".")
+	if !state.paused || state.manualFrame != 1 || !strings.Contains(buf.String(), "<b>") {
+		t.Fatalf("expected . to pause and draw frame 2, got %q", buf.String())
+	}
+	state.manualNext = time.Now().Add(-time.Second)
+	advanceManualAnimation(state, w)
+	if state.manualFrame != 1 {
+		t.Fatalf("expected the stepped frame to hold")
+	}
+
+	state.renderDirty = false
+	out.Reset()
+	state.timeline = "▶ 1/3 ━━━─────── 1×"
+	updateTimeline(state, w)
+	if !strings.Contains(out.String(), "⏸ 2/3 ━━━━━━────") {
+		t.Fatalf("expected the readout redrawn in place, got %q", out.String())
+	}
+}
+
+func TestSaveFrameWritesPNG(t *testing.T) {
+	prev := writeToDownloadsFn
+	t.Cleanup(func() { writeToDownloadsFn = prev })
+	var name string
+	var data []byte
+	writeToDownloadsFn = func(n string, d []byte) (string, error) {
+		name, data = n, d
+		return "/tmp/" + n, nil
+	}
+	state, _, keys := playbackState(t, termcaps.InlineKitty)
+	state.currentAnim.RawGIF = testutil.MakeTestGIF()
+	state.currentAnim.Frames = state.currentAnim.Frames[:2]
+	keys("p.")
+	keys("S")
+	if name != "A-frame2.png" || !bytes.HasPrefix(data, []byte("\x89PNG")) {
+		t.Fatalf("expected frame 2 as PNG, got %q %d bytes", name, len(data))
+	}
+	if state.headerFlash != "Saved frame 2 to /tmp/A-frame2.png" {
+		t.Fatalf("unexpected flash %q", state.headerFlash)
+	}
+}
+
+func TestPlaybackKeysOnIterm(t *testing.T) {
+	state, _, keys := playbackState(t, termcaps.InlineIterm)
+	keys(".")
+	if state.paused || state.status != "iTerm2 previews can't be paused" {
+		t.Fatalf("expected iTerm2 to refuse, got %q", state.status)
+	}
+}
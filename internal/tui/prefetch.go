@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/steipete/gifgrep/internal/download"
 	"github.com/steipete/gifgrep/internal/model"
 )
 
@@ -64,7 +65,7 @@ func startPrefetch(state *appState, results []model.Result, notify chan<- prefet
 	}
 	gen := state.prefetchGen
 	for _, item := range results {
-		if item.URL == "" {
+		if item.URL == "" || download.IsVideo(item.URL) {
 			continue
 		}
 		key := resultKey(item)
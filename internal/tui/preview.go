@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/gifencode"
+	"github.com/steipete/gifgrep/internal/download"
 	"github.com/steipete/gifgrep/internal/termcaps"
 )
 
@@ -15,17 +17,54 @@ func gifSize(raw []byte) (w, h int) {
 	}
 	hdr := string(raw[:6])
 	if hdr != "GIF87a" && hdr != "GIF89a" {
-		return 0, 0
+		return gifdecode.Size(raw)
 	}
 	w = int(binary.LittleEndian.Uint16(raw[6:8]))
 	h = int(binary.LittleEndian.Uint16(raw[8:10]))
 	return w, h
 }
 
+// itermPreviewData returns bytes iTerm2 can show. It only animates GIFs, so
+// animated WebP and APNG are re-encoded once and cached on the entry.
+func itermPreviewData(entry *gifCacheEntry, opts gifdecode.Options) []byte {
+	switch gifdecode.Format(entry.RawGIF) {
+	case "webp", "apng":
+	default:
+		return entry.RawGIF
+	}
+	if entry.ItermData != nil {
+		return entry.ItermData
+	}
+	decoded, err := gifdecode.Decode(entry.RawGIF, opts)
+	if err != nil {
+		return nil
+	}
+	if len(decoded.Frames) == 1 {
+		entry.ItermData = decoded.Frames[0].PNG
+		return entry.ItermData
+	}
+	frames, err := gifencode.FromDecoded(decoded)
+	if err != nil {
+		return nil
+	}
+	data, err := gifencode.EncodeBytes(frames, gifencode.Options{LoopCount: decoded.LoopCount})
+	if err != nil {
+		return nil
+	}
+	entry.ItermData = data
+	return data
+}
+
 func loadSelectedImage(state *appState) {
 	if state.cache == nil {
 		state.cache = map[string]*gifCacheEntry{}
 	}
+	if state.view == viewGrid {
+		// The grid shows thumbnails instead of a preview.
+		state.currentAnim = nil
+		state.previewDirty = true
+		return
+	}
 	if state.selected < 0 || state.selected >= len(state.results) {
 		state.currentAnim = nil
 		state.previewDirty = true
@@ -34,6 +73,10 @@ func loadSelectedImage(state *appState) {
 	item := state.results[state.selected]
 	source := item.PreviewURL
 	localPath, localOK := savedPathForResult(state, item)
+	if localOK && download.IsVideo(localPath) {
+		// --format-pref mp4 downloads can't be previewed; keep the GIF preview.
+		localPath, localOK = "", false
+	}
 	if localOK {
 		source = localPath
 	} else if tempPath, ok := tempPathForResult(state, item); ok {
@@ -62,8 +105,12 @@ func loadSelectedImage(state *appState) {
 		}
 		w, h := gifSize(data)
 		entry = &gifCacheEntry{RawGIF: data, Width: w, Height: h}
-		if state.inline == termcaps.InlineKitty {
-			decoded, err := gifdecode.Decode(data, gifdecode.DefaultOptions())
+		state.cache[source] = entry
+	}
+	if entry != nil && (state.inline == termcaps.InlineKitty || drawsInText(state.inline)) {
+		decodeOpts := previewDecodeOptions(state)
+		if entry.needsRedecode(decodeOpts) {
+			decoded, err := gifdecode.Decode(entry.RawGIF, decodeOpts)
 			if err != nil {
 				state.status = "Image error: " + err.Error()
 				state.currentAnim = nil
@@ -72,19 +119,9 @@ func loadSelectedImage(state *appState) {
 			entry.Frames = decoded
 			entry.Width = decoded.Width
 			entry.Height = decoded.Height
+			entry.MaxWidth = decodeOpts.MaxWidth
+			entry.MaxHeight = decodeOpts.MaxHeight
 		}
-		state.cache[source] = entry
-	}
-	if entry != nil && entry.Frames == nil && state.inline == termcaps.InlineKitty {
-		decoded, err := gifdecode.Decode(entry.RawGIF, gifdecode.DefaultOptions())
-		if err != nil {
-			state.status = "Image error: " + err.Error()
-			state.currentAnim = nil
-			return
-		}
-		entry.Frames = decoded
-		entry.Width = decoded.Width
-		entry.Height = decoded.Height
 	}
 
 	var frames []gifdecode.Frame
@@ -100,6 +137,9 @@ func loadSelectedImage(state *appState) {
 	}
 	if entry != nil {
 		state.currentAnim.RawGIF = entry.RawGIF
+		if state.inline == termcaps.InlineIterm {
+			state.currentAnim.RawGIF = itermPreviewData(entry, previewDecodeOptions(state))
+		}
 		state.currentAnim.Width = entry.Width
 		state.currentAnim.Height = entry.Height
 	}
@@ -107,6 +147,30 @@ func loadSelectedImage(state *appState) {
 	state.manualAnim = false
 	state.manualFrame = 0
 	state.manualNext = time.Time{}
+	state.paused = false
+	state.playStart = time.Time{}
 	state.previewNeedsSend = true
 	state.previewDirty = true
 }
+
+// previewDecodeOptions bounds decoded frames to the pixel area the preview
+// can fill. Anything larger is wasted bytes on the pty.
+func previewDecodeOptions(state *appState) gifdecode.Options {
+	opts := gifdecode.DefaultOptions()
+	l := buildLayout(state, state.lastRows, state.lastCols)
+	_, cols, rows := previewArea(state.lastRows, state.lastCols, l.contentHeight)
+	opts.MaxWidth, opts.MaxHeight = termcaps.CellSizeOr(termcaps.DefaultCellSize).Box(cols, rows)
+	return opts
+}
+
+// needsRedecode reports whether the cached frames were downscaled into a
+// smaller box than opts now allows (e.g. after the window grew).
+func (e *gifCacheEntry) needsRedecode(opts gifdecode.Options) bool {
+	if e.Frames == nil {
+		return true
+	}
+	grew := func(prev, next int) bool {
+		return prev > 0 && (next == 0 || next > prev)
+	}
+	return grew(e.MaxWidth, opts.MaxWidth) || grew(e.MaxHeight, opts.MaxHeight)
+}
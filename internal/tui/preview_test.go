@@ -139,3 +139,26 @@ func TestLoadSelectedImageUsesTempFile(t *testing.T) {
 		t.Fatalf("expected animation from temp file")
 	}
 }
+
+func TestPreviewDecodeOptionsBoundToPreview(t *testing.T) {
+	state := &appState{lastCols: 80, lastRows: 24}
+	opts := previewDecodeOptions(state)
+	// 80 columns leave 51 next to the list; 24 rows leave 20 for content.
+	wantW, wantH := termcaps.DefaultCellSize.Box(51, 20)
+	if opts.MaxWidth != wantW || opts.MaxHeight != wantH {
+		t.Fatalf("expected %dx%d box, got %dx%d", wantW, wantH, opts.MaxWidth, opts.MaxHeight)
+	}
+
+	entry := &gifCacheEntry{Frames: &gifdecode.Frames{}, MaxWidth: opts.MaxWidth, MaxHeight: opts.MaxHeight}
+	if entry.needsRedecode(opts) {
+		t.Fatalf("expected cached frames to be reused for same box")
+	}
+	state.lastCols = 120
+	if !entry.needsRedecode(previewDecodeOptions(state)) {
+		t.Fatalf("expected redecode after window grew")
+	}
+	state.lastCols = 40
+	if entry.needsRedecode(previewDecodeOptions(state)) {
+		t.Fatalf("expected no redecode after window shrank")
+	}
+}
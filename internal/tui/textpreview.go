@@ -0,0 +1,92 @@
+package tui
+
+import (
+	"bufio"
+	"time"
+
+	"github.com/steipete/gifgrep/internal/blocks"
+	"github.com/steipete/gifgrep/internal/sixel"
+	"github.com/steipete/gifgrep/internal/termcaps"
+)
+
+var (
+	encodeSixelFrameFn  = sixel.EncodeFrame
+	encodeBlocksFrameFn = blocks.EncodeFrame
+)
+
+// drawsInText reports whether previews are drawn into the text grid (Sixel
+// and text blocks), where text drawn over them erases them.
+func drawsInText(inline termcaps.InlineProtocol) bool {
+	return inline == termcaps.InlineSixel || inline == termcaps.InlineBlocks
+}
+
+// drawPreviewInText draws the current frame on every render: in-text
+// previews may have been erased by redrawing the list or status. Animation is
+// software-driven like the Ghostty path.
+func drawPreviewInText(state *appState, out *bufio.Writer, cols, rows int, row, col int) {
+	if state.previewNeedsSend {
+		state.manualAnim = len(state.currentAnim.Frames) > 1
+		if !state.paused {
+			state.manualFrame = 0
+		}
+		state.manualNext = time.Now().Add(frameGap(state, state.currentAnim.Frames[state.manualFrame].Delay))
+		state.previewNeedsSend = false
+	}
+	saveCursor(out)
+	drawTextFrame(state, out, cols, rows, row, col)
+	restoreCursor(out)
+	state.previewDirty = false
+	state.lastPreview.cols = cols
+	state.lastPreview.rows = rows
+}
+
+// drawTextFrame writes state.manualFrame at row, col.
+func drawTextFrame(state *appState, out *bufio.Writer, cols, rows int, row, col int) {
+	writeTextFrame(state, out, state.currentAnim, state.manualFrame, cols, rows, row, col)
+}
+
+// writeTextFrame writes frame i of anim at row, col. Sixel frames need the
+// rect cleared first (transparent pixels would show the previous frame);
+// text frames overwrite every cell anyway.
+func writeTextFrame(state *appState, out *bufio.Writer, anim *gifAnimation, i, cols, rows int, row, col int) {
+	data := encodedTextFrame(state, anim, i, cols, rows)
+	if len(data) == 0 {
+		return
+	}
+	if state.inline == termcaps.InlineSixel {
+		clearItermRectFn(out, row, col, cols, rows)
+	}
+	moveCursor(out, row, col)
+	_, _ = out.Write(data)
+}
+
+// encodedTextFrame returns frame i of anim encoded for a cols×rows area,
+// encoding on first use and dropping the cache when the area or cell size
+// changes.
+func encodedTextFrame(state *appState, anim *gifAnimation, i, cols, rows int) []byte {
+	if anim == nil || i < 0 || i >= len(anim.Frames) {
+		return nil
+	}
+	cell := termcaps.CellSizeOr(termcaps.SixelCellSize)
+	if anim.EncodedCols != cols || anim.EncodedRows != rows || anim.EncodedCell != cell || len(anim.Encoded) != len(anim.Frames) {
+		anim.Encoded = make([][]byte, len(anim.Frames))
+		anim.EncodedCols = cols
+		anim.EncodedRows = rows
+		anim.EncodedCell = cell
+	}
+	if anim.Encoded[i] == nil {
+		var data []byte
+		var err error
+		if state.inline == termcaps.InlineSixel {
+			w, h := cell.Box(cols, rows)
+			data, err = encodeSixelFrameFn(anim.Frames[i], w, h)
+		} else {
+			data, err = encodeBlocksFrameFn(anim.Frames[i], cols, rows, state.textStyle)
+		}
+		if err != nil {
+			return nil
+		}
+		anim.Encoded[i] = data
+	}
+	return anim.Encoded[i]
+}
@@ -0,0 +1,108 @@
+package tui
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/internal/model"
+	"github.com/steipete/gifgrep/internal/termcaps"
+)
+
+func TestSixelPreviewAnimatesAndCaches(t *testing.T) {
+	encodes := 0
+	prev := encodeSixelFrameFn
+	encodeSixelFrameFn = func(frame gifdecode.Frame, w, h int) ([]byte, error) {
+		encodes++
+		if w != 10*termcaps.SixelCellSize.Width || h != 5*termcaps.SixelCellSize.Height {
+			t.Fatalf("unexpected pixel box %dx%d", w, h)
+		}
+		return []byte("\x1bPq#" + string(frame.PNG) + "\x1b\\"), nil
+	}
+	t.Cleanup(func() { encodeSixelFrameFn = prev })
+
+	state := &appState{
+		inline: termcaps.InlineSixel,
+		currentAnim: &gifAnimation{
+			ID: 1,
+			Frames: []gifdecode.Frame{
+				{PNG: []byte("a"), Delay: 10 * time.Millisecond},
+				{PNG: []byte("b"), Delay: 10 * time.Millisecond},
+			},
+		},
+		previewNeedsSend: true,
+		previewRow:       2,
+		previewCol:       1,
+	}
+	var buf bytes.Buffer
+	out := bufio.NewWriter(&buf)
+	drawPreview(state, out, 10, 5, 2, 1)
+	_ = out.Flush()
+	if !strings.Contains(buf.String(), "\x1bPq#a") {
+		t.Fatalf("expected first frame, got %q", buf.String())
+	}
+	if !state.manualAnim {
+		t.Fatalf("expected software animation")
+	}
+
+	buf.Reset()
+	state.manualNext = time.Now().Add(-time.Millisecond)
+	advanceManualAnimation(state, out)
+	_ = out.Flush()
+	if !strings.Contains(buf.String(), "\x1bPq#b") {
+		t.Fatalf("expected second frame, got %q", buf.String())
+	}
+
+	// Redrawing the same size reuses the encoded frames.
+	buf.Reset()
+	drawPreview(state, out, 10, 5, 2, 1)
+	state.manualFrame = 0
+	drawPreview(state, out, 10, 5, 2, 1)
+	if encodes != 2 {
+		t.Fatalf("expected 2 encodes, got %d", encodes)
+	}
+}
+
+func TestBlocksPreviewOverwritesWithoutClearing(t *testing.T) {
+	prev := encodeBlocksFrameFn
+	encodeBlocksFrameFn = func(_ gifdecode.Frame, cols, rows int, style termcaps.TextStyle) ([]byte, error) {
+		if style != termcaps.Text256 {
+			t.Fatalf("unexpected style %v", style)
+		}
+		return []byte("<BLOCKS>"), nil
+	}
+	t.Cleanup(func() { encodeBlocksFrameFn = prev })
+
+	state := &appState{
+		inline:    termcaps.InlineBlocks,
+		textStyle: termcaps.Text256,
+		currentAnim: &gifAnimation{
+			ID:     1,
+			Frames: []gifdecode.Frame{{PNG: []byte("a")}},
+		},
+		previewNeedsSend: true,
+	}
+	var buf bytes.Buffer
+	out := bufio.NewWriter(&buf)
+	drawPreview(state, out, 4, 2, 3, 1)
+	_ = out.Flush()
+	if buf.String() != "\x1b7\x1b[3;1H<BLOCKS>\x1b8" {
+		t.Fatalf("unexpected output: %q", buf.String())
+	}
+	if state.manualAnim {
+		t.Fatalf("single frame should not animate")
+	}
+}
+
+func TestDetectInlineProtocolFallsBackToBlocks(t *testing.T) {
+	t.Setenv("GIFGREP_INLINE", "none")
+	if got := detectInlineProtocol(); got != termcaps.InlineBlocks {
+		t.Fatalf("expected blocks, got %v", got)
+	}
+	if got := textStyleFor(model.Options{Color: "never"}); got != termcaps.TextBraille {
+		t.Fatalf("expected braille for --no-color, got %v", got)
+	}
+}
@@ -0,0 +1,24 @@
+package tui
+
+// theme holds the SGR codes the TUI draws with.
+type theme struct {
+	accent string // selection, key hints, result count
+	dim    string // secondary text
+	flash  string // action feedback in the header, active prompt
+	pill   string // prompt label background
+}
+
+var themes = map[string]theme{
+	"default": {accent: "\x1b[36m", dim: "\x1b[90m", flash: "\x1b[33m", pill: "\x1b[48;5;236m"},
+	// light suits light backgrounds, where bright black and yellow fade.
+	"light": {accent: "\x1b[34m", dim: "\x1b[38;5;244m", flash: "\x1b[35m", pill: "\x1b[48;5;254m"},
+	// mono relies on weight and inversion only.
+	"mono": {accent: "\x1b[1m", dim: "\x1b[2m", flash: "\x1b[1m", pill: "\x1b[7m"},
+}
+
+func (s *appState) theme() theme {
+	if t, ok := themes[s.opts.Theme]; ok {
+		return t
+	}
+	return themes["default"]
+}
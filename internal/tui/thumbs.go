@@ -0,0 +1,365 @@
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/internal/iterm"
+	"github.com/steipete/gifgrep/internal/kitty"
+	"github.com/steipete/gifgrep/internal/model"
+	"github.com/steipete/gifgrep/internal/termcaps"
+)
+
+// A grid tile is thumbCols×thumbRows cells of image with its title on the
+// row below; tiles are one column apart.
+const (
+	thumbCols = 16
+	thumbRows = 6
+
+	// thumbWorkers bounds concurrent thumbnail fetches.
+	thumbWorkers = 4
+)
+
+var errNoPreview = errors.New("no preview")
+
+var fetchThumbFn = fetchGIF
+
+// thumb is a grid tile's image. row and col are where it was last drawn
+// (0 while it is off screen); frame and next drive software playback.
+type thumb struct {
+	anim       *gifAnimation
+	err        error
+	sent       bool
+	row, col   int
+	cols, rows int
+	frame      int
+	next       time.Time
+}
+
+type thumbResult struct {
+	gen    int
+	key    string
+	source string
+	data   []byte
+	frames *gifdecode.Frames
+	// frameCount and duration are read from the whole file; see frameStats.
+	frameCount int
+	duration   time.Duration
+	err        error
+}
+
+// toggleGrid switches between the list with its preview and the
+// thumbnail grid. The screen is cleared in between, since neither view's
+// images belong in the other.
+func toggleGrid(state *appState, out *bufio.Writer) {
+	clearGrid(state, out)
+	if state.view == viewGrid {
+		state.view = viewList
+	} else {
+		state.view = viewGrid
+		if state.inline == termcaps.InlineKitty && state.activeImageID != 0 {
+			kitty.DeleteImage(out, state.activeImageID)
+			state.activeImageID = 0
+		}
+	}
+	state.lastShowRight = false
+	state.itermLast.cols, state.itermLast.rows = 0, 0
+	ensureVisible(state)
+	loadSelectedImage(state)
+	state.renderDirty = true
+}
+
+// clearGrid removes every tile from the screen; the next render draws the
+// visible ones again.
+func clearGrid(state *appState, out *bufio.Writer) {
+	hideThumbs(state, out)
+	if state.inline == termcaps.InlineIterm {
+		clearItermScreenFn(out)
+	}
+	clearAll(out, state.lastRows, state.lastCols)
+}
+
+func hideThumbs(state *appState, out *bufio.Writer) {
+	for _, t := range state.thumbs {
+		hideThumb(state, out, t)
+	}
+	state.gridSlots = nil
+}
+
+func hideThumb(state *appState, out *bufio.Writer, t *thumb) {
+	if t.sent && t.anim != nil && state.inline == termcaps.InlineKitty {
+		kitty.DeleteImage(out, t.anim.ID)
+	}
+	t.sent = false
+	t.row, t.col = 0, 0
+}
+
+// resetThumbs drops the thumbnails of the previous search. Loads still in
+// flight are ignored when they finish.
+func resetThumbs(state *appState, out *bufio.Writer) {
+	hideThumbs(state, out)
+	state.thumbs = map[string]*thumb{}
+	state.thumbLoading = map[string]bool{}
+	state.thumbGen++
+}
+
+// gridStride is how far up/down moves the selection: one row of tiles.
+func gridStride(state *appState) int {
+	if state.view != viewGrid {
+		return 1
+	}
+	return maxInt(1, buildLayout(state, state.lastRows, state.lastCols).gridCols)
+}
+
+func thumbDecodeOptions(l layout) gifdecode.Options {
+	opts := gifdecode.DefaultOptions()
+	opts.MaxWidth, opts.MaxHeight = termcaps.CellSizeOr(termcaps.DefaultCellSize).Box(l.tileCols, l.tileRows)
+	return opts
+}
+
+// requestThumb loads item's thumbnail in the background unless it is
+// loaded or loading. Results arrive on state.thumbCh.
+func requestThumb(state *appState, item model.Result, opts gifdecode.Options) {
+	if state.thumbs == nil {
+		state.thumbs = map[string]*thumb{}
+	}
+	if state.thumbLoading == nil {
+		state.thumbLoading = map[string]bool{}
+	}
+	if state.thumbCh == nil {
+		state.thumbCh = make(chan thumbResult, 64)
+		state.thumbSem = make(chan struct{}, thumbWorkers)
+	}
+	key := resultKey(item)
+	if _, ok := state.thumbs[key]; ok || state.thumbLoading[key] {
+		return
+	}
+	source := item.PreviewURL
+	if source == "" {
+		state.thumbs[key] = &thumb{err: errNoPreview}
+		return
+	}
+	var data []byte
+	if entry, ok := state.cache[source]; ok {
+		data = entry.RawGIF
+	}
+	// iTerm2 decodes GIFs itself; the others need frames.
+	decode := state.inline != termcaps.InlineIterm
+	state.thumbLoading[key] = true
+	gen, ch, sem := state.thumbGen, state.thumbCh, state.thumbSem
+	go func() {
+		sem <- struct{}{}
+		defer func() { <-sem }()
+		res := thumbResult{gen: gen, key: key, source: source, data: data}
+		if res.data == nil {
+			res.data, res.err = fetchThumbFn(source)
+		}
+		if res.err == nil {
+			res.frameCount, res.duration = frameTiming(res.data)
+		}
+		if res.err == nil && decode {
+			res.frames, res.err = gifdecode.Decode(res.data, opts)
+		}
+		ch <- res
+	}()
+}
+
+func handleThumbResult(state *appState, res thumbResult) {
+	if res.gen != state.thumbGen {
+		return
+	}
+	delete(state.thumbLoading, res.key)
+	t := &thumb{err: res.err}
+	if res.err == nil {
+		if state.cache == nil {
+			state.cache = map[string]*gifCacheEntry{}
+		}
+		entry, ok := state.cache[res.source]
+		if !ok {
+			w, h := gifSize(res.data)
+			entry = &gifCacheEntry{RawGIF: res.data, Width: w, Height: h}
+			state.cache[res.source] = entry
+		}
+		if entry.FrameCount == 0 {
+			entry.FrameCount, entry.Duration = res.frameCount, res.duration
+		}
+		t.anim = &gifAnimation{ID: state.nextImageID, RawGIF: entry.RawGIF, Width: entry.Width, Height: entry.Height}
+		state.nextImageID++
+		if res.frames != nil {
+			t.anim.Frames = res.frames.Frames
+			t.anim.Width, t.anim.Height = res.frames.Width, res.frames.Height
+		}
+		if state.inline == termcaps.InlineIterm {
+			t.anim.RawGIF = itermPreviewData(entry, gifdecode.DefaultOptions())
+		}
+	}
+	state.thumbs[res.key] = t
+	state.renderDirty = true
+	refilterForFrames(state)
+}
+
+// drawGrid draws the visible tiles, starting loads for the ones not yet
+// fetched and removing the images of tiles scrolled away.
+func drawGrid(out *bufio.Writer, state *appState, l layout) {
+	slots := make([]string, l.gridCols*l.gridRows)
+	visible := map[string]bool{}
+	for i := range slots {
+		if idx := state.scroll + i; idx < len(state.results) {
+			slots[i] = resultKey(state.results[idx])
+			visible[slots[i]] = true
+		}
+	}
+	for key, t := range state.thumbs {
+		if t.row != 0 && !visible[key] {
+			hideThumb(state, out, t)
+		}
+	}
+	th := state.theme()
+	opts := thumbDecodeOptions(l)
+	for i, key := range slots {
+		row := l.contentTop + (i/l.gridCols)*(l.tileRows+1)
+		col := 1 + (i%l.gridCols)*(l.tileCols+1)
+		if i >= len(state.gridSlots) || state.gridSlots[i] != key {
+			clearItermRectFn(out, row, col, l.tileCols, l.tileRows)
+		}
+		idx := state.scroll + i
+		label := ""
+		if key != "" {
+			label = resultLabel(state, idx)
+		}
+		if labelRow := row + l.tileRows; labelRow <= l.contentBottom {
+			writeCellAt(out, labelRow, col, label, l.tileCols)
+		}
+		if key == "" {
+			continue
+		}
+		t := state.thumbs[key]
+		switch {
+		case t == nil:
+			requestThumb(state, state.results[idx], opts)
+			writeCellAt(out, row+l.tileRows/2, col, styleIf(state.useColor, "  loading…", th.dim), l.tileCols)
+		case t.anim == nil:
+			writeCellAt(out, row+l.tileRows/2, col, styleIf(state.useColor, "  no preview", th.dim), l.tileCols)
+		default:
+			drawThumb(state, out, t, row, col, l)
+		}
+	}
+	state.gridSlots = slots
+	for row := l.contentTop + l.gridRows*(l.tileRows+1); row <= l.contentBottom; row++ {
+		writeLineAt(out, row, 1, "", l.cols)
+	}
+}
+
+// drawThumb shows t in the tile at row, col. Kitty images are sent once
+// and moved with a placement; iTerm2 images are sent again when they move;
+// grid protocols and placeholders are text, redrawn on every render.
+func drawThumb(state *appState, out *bufio.Writer, t *thumb, row, col int, l layout) {
+	cols, rows := fitPreviewSize(l.tileCols, l.tileRows, t.anim)
+	moved := t.row != row || t.col != col || t.cols != cols || t.rows != rows
+	if moved {
+		clearItermRectFn(out, row, col, l.tileCols, l.tileRows)
+	}
+	software := state.useSoftwareAnim && len(t.anim.Frames) > 1
+	if t.frame >= len(t.anim.Frames) {
+		t.frame = 0
+	}
+	saveCursor(out)
+	moveCursor(out, row, col)
+	switch {
+	case state.inline == termcaps.InlineIterm:
+		if moved && len(t.anim.RawGIF) > 0 {
+			iterm.SendInlineFile(out, iterm.File{Name: "gifgrep.gif", Data: t.anim.RawGIF, WidthCells: cols, HeightCells: rows})
+		}
+	case len(t.anim.Frames) == 0:
+	case drawsInText(state.inline):
+		writeTextFrame(state, out, t.anim, t.frame, cols, rows, row, col)
+	case state.kittyPlaceholders:
+		if !t.sent {
+			if software {
+				state.sender.SendVirtualFrame(out, t.anim.ID, t.anim.Frames[t.frame], cols, rows)
+			} else {
+				state.sender.SendVirtualAnimation(out, t.anim.ID, t.anim.Frames, cols, rows)
+			}
+			t.sent = true
+		} else if t.cols != cols || t.rows != rows {
+			state.sender.PlaceVirtual(out, t.anim.ID, cols, rows)
+		}
+		kitty.WritePlaceholders(out, t.anim.ID, cols, rows)
+	case software:
+		if moved || !t.sent {
+			state.sender.SendFrame(out, t.anim.ID, t.anim.Frames[t.frame], cols, rows)
+			t.sent = true
+		}
+	default:
+		if !t.sent {
+			state.sender.SendAnimation(out, t.anim.ID, t.anim.Frames, cols, rows)
+			t.sent = true
+		} else if moved {
+			state.sender.PlaceImage(out, t.anim.ID, cols, rows)
+		}
+	}
+	restoreCursor(out)
+	if t.next.IsZero() && len(t.anim.Frames) > 0 {
+		t.next = nowFn().Add(t.anim.Frames[t.frame].Delay)
+	}
+	t.row, t.col, t.cols, t.rows = row, col, cols, rows
+}
+
+// advanceThumbs steps every on-screen tile whose next frame is due. It is
+// the grid's software scheduler; native Kitty and iTerm2 tiles play by
+// themselves.
+func advanceThumbs(state *appState, out *bufio.Writer) {
+	if state.view != viewGrid || state.showHelp || !state.useSoftwareAnim || state.inline == termcaps.InlineIterm {
+		return
+	}
+	now := nowFn()
+	drew := false
+	for _, t := range state.thumbs {
+		if t.anim == nil || t.row == 0 || len(t.anim.Frames) <= 1 || now.Before(t.next) {
+			continue
+		}
+		t.frame = (t.frame + 1) % len(t.anim.Frames)
+		frame := t.anim.Frames[t.frame]
+		t.next = now.Add(frame.Delay)
+		saveCursor(out)
+		switch {
+		case drawsInText(state.inline):
+			writeTextFrame(state, out, t.anim, t.frame, t.cols, t.rows, t.row, t.col)
+		case state.kittyPlaceholders:
+			state.sender.SendVirtualFrame(out, t.anim.ID, frame, t.cols, t.rows)
+		default:
+			moveCursor(out, t.row, t.col)
+			state.sender.SendFrame(out, t.anim.ID, frame, t.cols, t.rows)
+		}
+		restoreCursor(out)
+		drew = true
+	}
+	if drew {
+		_ = out.Flush()
+	}
+}
+
+// gridIndexAt maps a cell to the result shown in the tile under it.
+func gridIndexAt(state *appState, l layout, x, y int) (int, bool) {
+	if l.gridCols == 0 || y < l.contentTop || x < 1 {
+		return 0, false
+	}
+	c, r := (x-1)/(l.tileCols+1), (y-l.contentTop)/(l.tileRows+1)
+	if c >= l.gridCols || r >= l.gridRows {
+		return 0, false
+	}
+	idx := state.scroll + r*l.gridCols + c
+	return idx, idx < len(state.results)
+}
+
+// writeCellAt writes text padded to width without erasing the rest of the
+// line, which belongs to the neighbouring tiles.
+func writeCellAt(out *bufio.Writer, row, col int, text string, width int) {
+	text = truncateANSI(text, width)
+	moveCursor(out, row, col)
+	_, _ = fmt.Fprint(out, text+strings.Repeat(" ", maxInt(0, width-visibleRuneLen(text))))
+}
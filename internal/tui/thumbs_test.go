@@ -0,0 +1,153 @@
+package tui
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/internal/termcaps"
+	"github.com/steipete/gifgrep/internal/testutil"
+)
+
+func TestGridLayoutAndNavigation(t *testing.T) {
+	state, out := navState(t, "vim", 100)
+	press(state, out, inputEvent{kind: keyTab})
+	if state.view != viewGrid {
+		t.Fatalf("expected tab to switch to the grid")
+	}
+	l := buildLayout(state, state.lastRows, state.lastCols)
+	if l.gridCols != 4 || l.gridRows != 2 || l.tileCols != thumbCols || l.tileRows != thumbRows {
+		t.Fatalf("unexpected grid %dx%d of %dx%d tiles", l.gridCols, l.gridRows, l.tileCols, l.tileRows)
+	}
+
+	press(state, out, runes("jlll")...)
+	if state.selected != 7 {
+		t.Fatalf("expected j to move a row and l a tile, got %d", state.selected)
+	}
+	press(state, out, runes("h")...)
+	press(state, out, inputEvent{kind: keyPgDn})
+	if state.selected != 14 || state.scroll != 8 {
+		t.Fatalf("expected page down by two rows, got %d scroll %d", state.selected, state.scroll)
+	}
+	press(state, out, runes("G")...)
+	if state.selected != 99 || state.scroll != 92 {
+		t.Fatalf("expected G to scroll to the last row, got %d scroll %d", state.selected, state.scroll)
+	}
+	if idx, ok := gridIndexAt(state, l, 20, 2+thumbRows+1); !ok || idx != 97 {
+		t.Fatalf("expected second tile of the second row, got %d %v", idx, ok)
+	}
+
+	press(state, out, inputEvent{kind: keyTab})
+	if state.view != viewList || state.selected != 99 || state.scroll > 99 || state.scroll+listRows(state) <= 99 {
+		t.Fatalf("expected the list with 99 visible, got view %v scroll %d", state.view, state.scroll)
+	}
+	press(state, out, runes("l")...)
+	if state.selected != 99 {
+		t.Fatalf("expected l to do nothing in the list")
+	}
+}
+
+func TestGridLoadsVisibleTilesOnly(t *testing.T) {
+	state, out := navState(t, "", 100)
+	var buf bytes.Buffer
+	out = bufio.NewWriter(&buf)
+	state.inline = termcaps.InlineKitty
+	state.nextImageID = 1
+	for i := range state.results {
+		state.results[i].PreviewURL = fmt.Sprintf("https://example.test/%d.gif", i)
+	}
+	var mu sync.Mutex
+	var fetched []string
+	prev := fetchThumbFn
+	fetchThumbFn = func(url string) ([]byte, error) {
+		mu.Lock()
+		fetched = append(fetched, url)
+		mu.Unlock()
+		return testutil.MakeTestGIF(), nil
+	}
+	t.Cleanup(func() { fetchThumbFn = prev })
+
+	drain := func(n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			select {
+			case res := <-state.thumbCh:
+				handleThumbResult(state, res)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for thumbnail %d", i)
+			}
+		}
+	}
+
+	press(state, out, inputEvent{kind: keyTab})
+	render(state, out, state.lastRows, state.lastCols)
+	drain(8)
+	sort.Strings(fetched)
+	if len(fetched) != 8 || fetched[0] != "https://example.test/0.gif" || fetched[7] != "https://example.test/7.gif" {
+		t.Fatalf("expected the 8 visible tiles to load, got %v", fetched)
+	}
+
+	buf.Reset()
+	render(state, out, state.lastRows, state.lastCols)
+	_ = out.Flush()
+	first := state.thumbs["id:0"]
+	if first == nil || first.anim == nil || !first.sent || first.row != 2 || first.col != 1 {
+		t.Fatalf("expected result 0 sent at the first tile, got %+v", first)
+	}
+	if !strings.Contains(buf.String(), fmt.Sprintf(",i=%d,q=2,c=", first.anim.ID)) {
+		t.Fatalf("expected a kitty transmit for the first tile, got %q", buf.String())
+	}
+
+	buf.Reset()
+	press(state, out, inputEvent{kind: keyPgDn})
+	render(state, out, state.lastRows, state.lastCols)
+	_ = out.Flush()
+	if !strings.Contains(buf.String(), fmt.Sprintf("a=d,d=I,i=%d,", first.anim.ID)) || first.row != 0 {
+		t.Fatalf("expected the scrolled-off tile to be deleted, got %q", buf.String())
+	}
+	drain(8)
+	if len(fetched) != 16 {
+		t.Fatalf("expected only the next page to load, got %d fetches", len(fetched))
+	}
+}
+
+func TestGridSoftwareScheduler(t *testing.T) {
+	prevEncode, prevNow := encodeBlocksFrameFn, nowFn
+	t.Cleanup(func() { encodeBlocksFrameFn, nowFn = prevEncode, prevNow })
+	encodeBlocksFrameFn = func(frame gifdecode.Frame, cols, rows int, _ termcaps.TextStyle) ([]byte, error) {
+		return []byte("<" + string(frame.PNG) + ">"), nil
+	}
+	now := time.Unix(0, 0)
+	nowFn = func() time.Time { return now }
+
+	state, _ := navState(t, "", 100)
+	var buf bytes.Buffer
+	out := bufio.NewWriter(&buf)
+	state.inline = termcaps.InlineBlocks
+	state.useSoftwareAnim = true
+	state.view = viewGrid
+	frames := []gifdecode.Frame{{PNG: []byte("a"), Delay: 50 * time.Millisecond}, {PNG: []byte("b"), Delay: 50 * time.Millisecond}}
+	state.thumbs = map[string]*thumb{"id:0": {anim: &gifAnimation{ID: 1, Frames: frames, Width: 10, Height: 10}}}
+
+	render(state, out, state.lastRows, state.lastCols)
+	_ = out.Flush()
+	if !strings.Contains(buf.String(), "<a>") {
+		t.Fatalf("expected the first frame drawn, got %q", buf.String())
+	}
+	buf.Reset()
+	advanceThumbs(state, out)
+	if strings.Contains(buf.String(), "<b>") {
+		t.Fatalf("expected no frame before its delay")
+	}
+	now = now.Add(60 * time.Millisecond)
+	advanceThumbs(state, out)
+	if !strings.Contains(buf.String(), "<b>") || state.thumbs["id:0"].frame != 1 {
+		t.Fatalf("expected the scheduler to step the tile, got %q", buf.String())
+	}
+}
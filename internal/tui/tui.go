@@ -12,6 +12,7 @@ import (
 
 	"github.com/steipete/gifgrep/gifdecode"
 	"github.com/steipete/gifgrep/internal/assets"
+	"github.com/steipete/gifgrep/internal/clipboard"
 	"github.com/steipete/gifgrep/internal/iterm"
 	"github.com/steipete/gifgrep/internal/kitty"
 	"github.com/steipete/gifgrep/internal/model"
@@ -21,21 +22,34 @@ import (
 )
 
 type inputEvent struct {
-	kind keyKind
-	ch   rune
+	kind  keyKind
+	ch    rune
+	text  string // APC body for keyGraphicsReply, CSI parameters for keyCellSize
+	mouse mouseEvent
 }
 
 type keyKind int
 
 const (
 	keyRune keyKind = iota
+	keyCtrl         // ch is the lower-case letter
+	keyTab
 	keyEnter
 	keyBackspace
 	keyEsc
 	keyUp
 	keyDown
+	keyLeft
+	keyRight
+	keyPgUp
+	keyPgDn
+	keyHome
+	keyEnd
 	keyCtrlC
 	keyUnknown
+	keyGraphicsReply
+	keyCellSize
+	keyMouse
 )
 
 var ErrNotTerminal = errors.New("stdin is not a tty")
@@ -44,6 +58,11 @@ const giphyAttributionImageID uint32 = 0x67697068 // "giph"
 
 var nowFn = time.Now
 
+var (
+	detectCellSizeFn  = func() { _, _ = termcaps.DetectCellSize() }
+	refreshCellSizeFn = termcaps.RefreshCellSize
+)
+
 func Run(opts model.Options, query string) error {
 	env := defaultEnvFn()
 	return runWith(env, opts, query)
@@ -77,17 +96,33 @@ func initEnvDefaults(env Env) (Env, error) {
 	return env, nil
 }
 
-func detectInlineProtocol() (termcaps.InlineProtocol, error) {
+// detectInlineProtocol falls back to text blocks when the terminal has no
+// image protocol, so the TUI works over plain SSH, tmux or VS Code.
+func detectInlineProtocol() termcaps.InlineProtocol {
 	inline := termcaps.DetectInlineRobust(os.Getenv)
 	if inline == termcaps.InlineNone {
-		return inline, errUnsupportedInline(os.Getenv)
+		return termcaps.InlineBlocks
+	}
+	return inline
+}
+
+// textStyleFor picks the text-preview style; --no-color gets braille.
+func textStyleFor(opts model.Options) termcaps.TextStyle {
+	if opts.Color == "never" {
+		return termcaps.TextBraille
 	}
-	return inline, nil
+	return termcaps.DetectTextStyle(os.Getenv)
 }
 
-func setupOutput(out *bufio.Writer, inline termcaps.InlineProtocol) func() {
+func setupOutput(out *bufio.Writer, inline termcaps.InlineProtocol, mouse bool) func() {
 	hideCursor(out)
+	if mouse {
+		enableMouse(out)
+	}
 	return func() {
+		if mouse {
+			disableMouse(out)
+		}
 		showCursor(out)
 		if inline == termcaps.InlineKitty {
 			clearImages(out)
@@ -122,14 +157,17 @@ func newAppState(inline termcaps.InlineProtocol, opts model.Options) *appState {
 		renderDirty:     true,
 		nextImageID:     1,
 		inline:          inline,
-		useSoftwareAnim: inline == termcaps.InlineKitty && useSoftwareAnimation(),
-		useColor:        opts.Color != "never",
-		opts:            opts,
+		useSoftwareAnim: (inline == termcaps.InlineKitty && useSoftwareAnimation()) || drawsInText(inline),
+		// Inside tmux, images only follow the pane as placeholder text.
+		kittyPlaceholders: inline == termcaps.InlineKitty && termcaps.DetectPassthrough(os.Getenv) == termcaps.PassthroughTmux,
+		textStyle:         textStyleFor(opts),
+		useColor:          opts.Color != "never",
+		opts:              opts,
 	}
 }
 
 func runInitialSearch(state *appState, query string, opts model.Options, out *bufio.Writer, prefetchCh chan<- prefetchResult) {
-	if strings.TrimSpace(query) == "" {
+	if strings.TrimSpace(query) == "" && !search.AllowsEmptyQuery(opts.Source) {
 		return
 	}
 	state.query = query
@@ -146,6 +184,8 @@ func runInitialSearch(state *appState, query string, opts model.Options, out *bu
 	}
 
 	state.results = results
+	state.allResults = results
+	state.filterText = ""
 	state.selected = 0
 	state.scroll = 0
 	if len(results) == 0 {
@@ -153,6 +193,8 @@ func runInitialSearch(state *appState, query string, opts model.Options, out *bu
 		state.currentAnim = nil
 		state.previewDirty = true
 		resetPrefetch(state)
+		resetThumbs(state, out)
+		clearMarks(state)
 		state.renderDirty = true
 		return
 	}
@@ -160,6 +202,8 @@ func runInitialSearch(state *appState, query string, opts model.Options, out *bu
 	state.status = fmt.Sprintf("%d results", len(results))
 	loadSelectedImage(state)
 	resetPrefetch(state)
+	resetThumbs(state, out)
+	clearMarks(state)
 	startPrefetch(state, results, prefetchCh)
 	state.renderDirty = true
 }
@@ -178,11 +222,19 @@ func handlePrefetchResult(state *appState, res prefetchResult) {
 	state.renderDirty = true
 }
 
-func updateSizeIfNeeded(state *appState, env Env) {
+func updateSizeIfNeeded(state *appState, env Env, out *bufio.Writer) {
 	if cols, rows, err := env.GetSize(env.FD); err == nil {
 		if rows != state.lastRows || cols != state.lastCols {
 			state.lastRows = rows
 			state.lastCols = cols
+			// Font size changes resize the window too. Without pixel
+			// fields, the CSI 16 t reply arrives through readInput.
+			if !refreshCellSizeFn() {
+				_, _ = fmt.Fprint(out, "\x1b[16t")
+			}
+			if state.view == viewGrid {
+				clearGrid(state, out)
+			}
 			ensureVisible(state)
 			state.renderDirty = true
 			state.previewDirty = true
@@ -211,24 +263,15 @@ func handleEvents(state *appState, out *bufio.Writer, prefetchCh chan prefetchRe
 		}
 	case res := <-prefetchCh:
 		handlePrefetchResult(state, res)
+	case res := <-state.thumbCh:
+		handleThumbResult(state, res)
+	case p := <-state.batchCh:
+		handleBatchProgress(state, p)
 	case <-ticker.C:
 	}
 	return false
 }
 
-func errUnsupportedInline(getenv func(string) string) error {
-	if getenv == nil {
-		getenv = os.Getenv
-	}
-	termProgram := strings.TrimSpace(getenv("TERM_PROGRAM"))
-	term := strings.TrimSpace(getenv("TERM"))
-	return fmt.Errorf(
-		"gifgrep tui needs inline image support.\n\nSupported terminals:\n  - Kitty (Kitty graphics protocol)\n  - Ghostty (Kitty graphics protocol)\n  - iTerm2 (OSC 1337 inline images)\n\nDetected:\n  TERM_PROGRAM=%q\n  TERM=%q\n\nSee: docs/kitty.md and docs/iterm.md\n\nTip: You can force detection with GIFGREP_INLINE=kitty|iterm|none",
-		termProgram,
-		term,
-	)
-}
-
 func runWith(env Env, opts model.Options, query string) error {
 	var err error
 	env, err = initEnvDefaults(env)
@@ -236,11 +279,14 @@ func runWith(env Env, opts model.Options, query string) error {
 		return err
 	}
 
-	inline, err := detectInlineProtocol()
+	keys, err := buildKeymap(opts.Keymap, opts.Keys)
 	if err != nil {
 		return err
 	}
 
+	inline := detectInlineProtocol()
+	detectCellSizeFn()
+
 	oldState, err := env.MakeRaw(env.FD)
 	if err != nil {
 		return err
@@ -251,15 +297,21 @@ func runWith(env Env, opts model.Options, query string) error {
 		}()
 	}
 
-	out := bufio.NewWriter(env.Out)
-	defer setupOutput(out, inline)()
+	out := bufio.NewWriter(termcaps.NewGraphicsWriter(env.Out, inline))
+	defer setupOutput(out, inline, opts.Mouse)()
 
 	sigs := setupSignals(env)
 	inputCh, stopCh := setupInputReader(env.In)
 	prefetchCh := make(chan prefetchResult, 64)
 
 	state := newAppState(inline, opts)
+	state.keys = keys
 	defer cleanupTempDir(state)
+	if inline == termcaps.InlineKitty {
+		state.sender.Transport = termcaps.DetectKittyTransport(os.Getenv)
+		state.sender.Replies = opts.KittyReplies || kittyReplies()
+		defer state.sender.Close()
+	}
 	if cols, rows, err := env.GetSize(env.FD); err == nil {
 		state.lastRows = rows
 		state.lastCols = cols
@@ -274,10 +326,12 @@ func runWith(env Env, opts model.Options, query string) error {
 		if handleEvents(state, out, prefetchCh, inputCh, stopCh, sigs, ticker) {
 			return nil
 		}
-		updateSizeIfNeeded(state, env)
+		updateSizeIfNeeded(state, env, out)
 		renderIfNeeded(state, out)
 
 		advanceManualAnimation(state, out)
+		advanceThumbs(state, out)
+		updateTimeline(state, out)
 	}
 }
 
@@ -301,22 +355,38 @@ func readInput(r io.Reader, ch chan<- inputEvent, stop <-chan struct{}) {
 			ch <- inputEvent{kind: keyEnter}
 		case 0x7f, 0x08:
 			ch <- inputEvent{kind: keyBackspace}
+		case '\t':
+			ch <- inputEvent{kind: keyTab}
 		case 0x1b:
 			next, err := reader.ReadByte()
 			if err != nil {
 				ch <- inputEvent{kind: keyEsc}
 				continue
 			}
+			if next == '_' {
+				ch <- inputEvent{kind: keyGraphicsReply, text: readAPC(reader)}
+				continue
+			}
 			if next == '[' {
-				third, _ := reader.ReadByte()
-				switch third {
-				case 'A':
-					ch <- inputEvent{kind: keyUp}
-				case 'B':
-					ch <- inputEvent{kind: keyDown}
+				params, final := readCSI(reader)
+				switch {
+				case final == 't' && strings.HasPrefix(params, "6;"):
+					ch <- inputEvent{kind: keyCellSize, text: params}
+				case strings.HasPrefix(params, "<"):
+					if m, ok := parseSGRMouse(params, final); ok {
+						ch <- inputEvent{kind: keyMouse, mouse: m}
+					}
 				default:
-					ch <- inputEvent{kind: keyUnknown}
+					ch <- inputEvent{kind: csiKey(params, final)}
 				}
+			} else if next == 'O' {
+				// SS3: cursor keys in application mode.
+				final, err := reader.ReadByte()
+				if err != nil {
+					ch <- inputEvent{kind: keyEsc}
+					continue
+				}
+				ch <- inputEvent{kind: csiKey("", final)}
 			} else {
 				_ = reader.UnreadByte()
 				ch <- inputEvent{kind: keyEsc}
@@ -324,17 +394,84 @@ func readInput(r io.Reader, ch chan<- inputEvent, stop <-chan struct{}) {
 		default:
 			if b >= 0x20 && b < 0x7f {
 				ch <- inputEvent{kind: keyRune, ch: rune(b)}
+			} else if b >= 0x01 && b <= 0x1a {
+				ch <- inputEvent{kind: keyCtrl, ch: rune('a' + b - 1)}
 			}
 		}
 	}
 }
 
+// csiKey decodes unmodified cursor and editing keys.
+func csiKey(params string, final byte) keyKind {
+	switch {
+	case params == "" && final == 'A':
+		return keyUp
+	case params == "" && final == 'B':
+		return keyDown
+	case params == "" && final == 'C':
+		return keyRight
+	case params == "" && final == 'D':
+		return keyLeft
+	case params == "" && final == 'H':
+		return keyHome
+	case params == "" && final == 'F':
+		return keyEnd
+	case final == '~':
+		switch params {
+		case "1", "7":
+			return keyHome
+		case "4", "8":
+			return keyEnd
+		case "5":
+			return keyPgUp
+		case "6":
+			return keyPgDn
+		}
+	}
+	return keyUnknown
+}
+
+// readCSI reads a CSI sequence after ESC [ and returns its parameter bytes
+// and final byte (0 on EOF).
+func readCSI(reader *bufio.Reader) (string, byte) {
+	var params []byte
+	for len(params) < 64 {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return string(params), 0
+		}
+		if b >= 0x40 && b <= 0x7e {
+			return string(params), b
+		}
+		params = append(params, b)
+	}
+	return string(params), 0
+}
+
 func handleInput(state *appState, ev inputEvent, out *bufio.Writer, prefetchCh chan<- prefetchResult) bool {
 	if ev.kind == keyCtrlC {
 		return true
 	}
-	if ev.kind == keyRune && ev.ch == 'q' {
-		return true
+	if ev.kind == keyGraphicsReply {
+		handleKittyReply(state, ev.text)
+		return false
+	}
+	if ev.kind == keyMouse {
+		handleMouse(state, ev.mouse, out)
+		return false
+	}
+	if ev.kind == keyCellSize {
+		if cell, ok := termcaps.ParseCellSizeReply(ev.text); ok {
+			termcaps.SetCellSize(cell)
+			state.previewDirty = true
+			state.renderDirty = true
+		}
+		return false
+	}
+	if state.mode != modeCaption && state.mode != modeFind && state.mode != modeFilter {
+		if a, ok := state.actionFor(ev); ok && a == actQuit {
+			return true
+		}
 	}
 
 	switch state.mode {
@@ -342,6 +479,12 @@ func handleInput(state *appState, ev inputEvent, out *bufio.Writer, prefetchCh c
 		return handleQueryInput(state, ev, out, prefetchCh)
 	case modeBrowse:
 		return handleBrowseInput(state, ev, out)
+	case modeCaption:
+		return handleCaptionInput(state, ev, out)
+	case modeFind:
+		return handleFindInput(state, ev, out)
+	case modeFilter:
+		return handleFilterInput(state, ev, out)
 	}
 
 	return false
@@ -358,7 +501,7 @@ func handleQueryInput(state *appState, ev inputEvent, out *bufio.Writer, prefetc
 			state.renderDirty = true
 		}
 	case keyEnter:
-		if strings.TrimSpace(state.query) == "" {
+		if strings.TrimSpace(state.query) == "" && !search.AllowsEmptyQuery(state.opts.Source) {
 			state.status = "Empty query"
 			state.renderDirty = true
 			return false
@@ -372,6 +515,8 @@ func handleQueryInput(state *appState, ev inputEvent, out *bufio.Writer, prefetc
 			state.status = "Search error: " + err.Error()
 		} else {
 			state.results = results
+			state.allResults = results
+			state.filterText = ""
 			state.selected = 0
 			state.scroll = 0
 			if len(results) == 0 {
@@ -379,10 +524,14 @@ func handleQueryInput(state *appState, ev inputEvent, out *bufio.Writer, prefetc
 				state.currentAnim = nil
 				state.previewDirty = true
 				resetPrefetch(state)
+				resetThumbs(state, out)
+				clearMarks(state)
 			} else {
 				state.status = fmt.Sprintf("%d results", len(results))
 				loadSelectedImage(state)
 				resetPrefetch(state)
+				resetThumbs(state, out)
+				clearMarks(state)
 				startPrefetch(state, results, prefetchCh)
 			}
 		}
@@ -402,71 +551,122 @@ func handleQueryInput(state *appState, ev inputEvent, out *bufio.Writer, prefetc
 }
 
 func handleBrowseInput(state *appState, ev inputEvent, out *bufio.Writer) bool {
-	switch ev.kind {
-	case keyRune:
-		if ev.ch == '/' {
-			state.mode = modeQuery
-			state.status = "Type a search and press Enter"
-			state.renderDirty = true
-			return false
-		}
-		switch ev.ch {
-		case 'c':
-			copySelected(state, out)
-			return false
-		case 'd':
-			downloadSelected(state, out, state.opts.Reveal)
-			return false
-		case 'f':
-			return handleRevealSelected(state, out)
-		default:
-		}
-		if ev.ch >= 0x20 {
-			state.mode = modeQuery
-			state.status = "Type a search and press Enter"
-			state.query = string(ev.ch)
-			state.renderDirty = true
-			return false
-		}
-	case keyUp:
-		if state.selected > 0 {
-			state.selected--
-			ensureVisible(state)
-			loadSelectedImage(state)
-			state.renderDirty = true
+	if ev.kind == keyCtrlC {
+		return true
+	}
+	if state.showHelp {
+		toggleHelp(state, out)
+		return false
+	}
+	if handleCount(state, ev) {
+		return false
+	}
+	if state.count > 0 && (ev.kind == keyEnter || ev.kind == keyEsc) {
+		if ev.kind == keyEnter {
+			selectResult(state, state.count-1)
 		}
-	case keyDown:
-		if state.selected < len(state.results)-1 {
-			state.selected++
-			ensureVisible(state)
-			loadSelectedImage(state)
+		state.count = 0
+		state.status = ""
+		state.renderDirty = true
+		return false
+	}
+	a, ok := state.actionFor(ev)
+	if ok && handleNavAction(state, a) {
+		return false
+	}
+	state.count = 0
+	if !ok {
+		if ev.kind == keyRune {
+			state.status = fmt.Sprintf("%s is not bound · %s shows keys", ev.keyName(), displayKey(firstKey(state.keymap(), actHelp)))
 			state.renderDirty = true
 		}
-	case keyEnter:
+		return false
+	}
+	switch a {
+	case actSearch:
 		state.mode = modeQuery
 		state.status = "Type a search and press Enter"
 		state.renderDirty = true
-	case keyEsc:
-		state.mode = modeQuery
+	case actCopy:
+		copySelected(state, out)
+	case actCopyURL:
+		copySelectedAs(state, out, clipboard.FormatURL)
+	case actCopyMarkdown:
+		copySelectedAs(state, out, clipboard.FormatMarkdown)
+	case actCopyHTML:
+		copySelectedAs(state, out, clipboard.FormatHTML)
+	case actCopyData:
+		copySelectedAs(state, out, clipboard.FormatDataURI)
+	case actDownload:
+		if items := markedResults(state); len(items) > 0 {
+			downloadMarked(state, items)
+		} else {
+			downloadSelected(state, out, state.opts.Reveal)
+		}
+	case actReveal:
+		return handleRevealSelected(state, out)
+	case actPause:
+		togglePause(state, out)
+	case actFramePrev:
+		stepFrame(state, out, -1)
+	case actFrameNext:
+		stepFrame(state, out, 1)
+	case actSlower:
+		changeSpeed(state, out, -1)
+	case actFaster:
+		changeSpeed(state, out, 1)
+	case actSaveFrame:
+		saveFrame(state)
+	case actCaption:
+		state.mode = modeCaption
+		state.captionText = ""
+		state.status = "Caption: top | bottom, Enter saves to Downloads"
 		state.renderDirty = true
-	case keyCtrlC:
+	case actFind:
+		state.mode = modeFind
+		state.findText = ""
+		k := state.keymap()
+		state.status = fmt.Sprintf("Find in titles and tags, Enter jumps · %s/%s cycle",
+			displayKey(firstKey(k, actNextMatch)), displayKey(firstKey(k, actPrevMatch)))
+		state.renderDirty = true
+	case actFilter:
+		state.mode = modeFilter
+		state.filterInput = state.filterText
+		state.status = "Filter: words, wide|tall|square, 320x200, frames>=N, sort:size|dims|duration"
+		state.renderDirty = true
+	case actGrid:
+		toggleGrid(state, out)
+	case actMark:
+		toggleMark(state)
+	case actMarkRange:
+		markRange(state)
+	case actUnmarkAll:
+		clearMarks(state)
+		state.status = "Marks cleared"
+		state.renderDirty = true
+	case actFavorite:
+		addFavorites(state)
+	case actExport:
+		exportMarkdown(state)
+	case actHelp:
+		toggleHelp(state, out)
+	case actQuit:
 		return true
-	case keyBackspace, keyUnknown:
-		// ignore
 	}
 	return false
 }
 
+// ensureVisible scrolls the selection into view. In the grid, scroll is
+// the first result of a row of tiles.
 func ensureVisible(state *appState) {
-	listHeight := state.lastRows - 4
-	if listHeight < 0 {
-		listHeight = 0
-	}
+	listHeight := listRows(state)
+	stride := gridStride(state)
+	state.scroll -= state.scroll % stride
 	if state.selected < state.scroll {
-		state.scroll = state.selected
+		state.scroll = state.selected - state.selected%stride
 	}
-	if state.selected >= state.scroll+listHeight {
-		state.scroll = state.selected - listHeight + 1
+	if state.selected >= state.scroll+listHeight*stride {
+		state.scroll = (state.selected/stride - listHeight + 1) * stride
 	}
 	if state.scroll < 0 {
 		state.scroll = 0
@@ -509,7 +709,7 @@ func render(state *appState, out *bufio.Writer, rows, cols int) {
 	if strings.TrimSpace(state.headerFlash) != "" {
 		headerTagline = state.headerFlash
 	}
-	drawHeader(out, state.useColor, cols, headerTagline)
+	drawHeader(out, state.useColor, state.theme(), cols, headerTagline)
 
 	if !layout.hasContent {
 		clearAll(out, rows, cols)
@@ -520,18 +720,28 @@ func render(state *appState, out *bufio.Writer, rows, cols int) {
 		// When switching from bottom-preview to split-preview, clear the left area once
 		// so old list rows don't show through. For Kitty, it's cheap to clear every render;
 		// for iTerm images (inline in the text grid), clearing would erase the image.
-		if state.inline == termcaps.InlineKitty || !state.lastShowRight {
+		// In-text previews are in the text grid too, but a new image may be
+		// smaller than the last one, so clear its leftovers once.
+		if state.inline == termcaps.InlineKitty || !state.lastShowRight ||
+			(drawsInText(state.inline) && state.previewNeedsSend) {
 			clearPreviewAreaFn(out, layout)
 		}
 	}
 
 	state.lastShowRight = layout.showRight
 
-	drawList(out, state, layout)
-	if state.inline == termcaps.InlineIterm && layout.showRight {
-		clearItermGapColumn(out, layout)
+	switch {
+	case state.showHelp:
+		drawHelp(out, state, layout)
+	case state.view == viewGrid:
+		drawGrid(out, state, layout)
+	default:
+		drawList(out, state, layout)
+		if (state.inline == termcaps.InlineIterm || drawsInText(state.inline)) && layout.showRight {
+			clearItermGapColumn(out, layout)
+		}
+		drawPreviewIfNeeded(out, state, layout)
 	}
-	drawPreviewIfNeeded(out, state, layout)
 	drawStatus(out, state, layout)
 	drawSearch(out, state, layout)
 	drawHints(out, state, layout)
@@ -550,6 +760,10 @@ type layout struct {
 	clearWidth                     int
 	showRight                      bool
 	hasContent                     bool
+
+	// Grid view: gridCols×gridRows tiles of tileCols×tileRows cells.
+	gridCols, gridRows int
+	tileCols, tileRows int
 }
 
 func buildLayout(state *appState, rows, cols int) layout {
@@ -569,30 +783,23 @@ func buildLayout(state *appState, rows, cols int) layout {
 	layout.contentHeight = layout.contentBottom - layout.contentTop + 1
 	layout.hasContent = true
 
-	showRight := cols >= 80 && rows >= 14 && state.currentAnim != nil
-	minListWidth := 28
-	gapCols := 1
-	maxPreviewCols := cols
-	if showRight {
-		maxPreviewCols = cols - minListWidth - gapCols
-		if maxPreviewCols < 10 {
-			showRight = false
+	if state.view == viewGrid {
+		layout.tileCols, layout.tileRows = minInt(thumbCols, cols), thumbRows
+		if layout.contentHeight < thumbRows+1 {
+			layout.tileRows = maxInt(1, layout.contentHeight-1)
 		}
+		layout.gridCols = maxInt(1, (cols+1)/(layout.tileCols+1))
+		layout.gridRows = maxInt(1, layout.contentHeight/(layout.tileRows+1))
+		layout.listCol, layout.listWidth = 1, cols
+		layout.listHeight = layout.gridRows
+		return layout
 	}
-	layout.showRight = showRight
 
-	if showRight {
-		layout.previewCols, layout.previewRows = fitPreviewSize(maxPreviewCols, layout.contentHeight, state.currentAnim)
-	} else {
-		availRows := layout.contentHeight / 2
-		if availRows < 6 {
-			availRows = minInt(6, layout.contentHeight)
-		}
-		if availRows > layout.contentHeight-2 {
-			availRows = maxInt(0, layout.contentHeight-2)
-		}
-		layout.previewCols, layout.previewRows = fitPreviewSize(cols, availRows, state.currentAnim)
-	}
+	showRight, boxCols, boxRows := previewArea(rows, cols, layout.contentHeight)
+	showRight = showRight && state.currentAnim != nil
+	gapCols := 1
+	layout.showRight = showRight
+	layout.previewCols, layout.previewRows = fitPreviewSize(boxCols, boxRows, state.currentAnim)
 	if state.currentAnim == nil {
 		layout.previewCols = 0
 		layout.previewRows = 0
@@ -619,15 +826,32 @@ func buildLayout(state *appState, rows, cols int) layout {
 	return layout
 }
 
-func drawHeader(out *bufio.Writer, useColor bool, cols int, tagline string) {
-	header := styleIf(useColor, "gifgrep", "\x1b[1m", "\x1b[36m")
+// previewArea is the box a preview may fill: right of the list when the
+// window is wide enough, otherwise below it.
+func previewArea(rows, cols, contentHeight int) (right bool, boxCols, boxRows int) {
+	const minListWidth, gapCols = 28, 1
+	if cols >= 80 && rows >= 14 && cols-minListWidth-gapCols >= 10 {
+		return true, cols - minListWidth - gapCols, contentHeight
+	}
+	availRows := contentHeight / 2
+	if availRows < 6 {
+		availRows = minInt(6, contentHeight)
+	}
+	if availRows > contentHeight-2 {
+		availRows = maxInt(0, contentHeight-2)
+	}
+	return false, cols, availRows
+}
+
+func drawHeader(out *bufio.Writer, useColor bool, th theme, cols int, tagline string) {
+	header := styleIf(useColor, "gifgrep", "\x1b[1m", th.accent)
 	if strings.TrimSpace(tagline) == "" {
 		tagline = model.Tagline
 	}
-	codes := []string{"\x1b[90m"}
+	codes := []string{th.dim}
 	if useColor && strings.TrimSpace(tagline) != "" && tagline != model.Tagline {
 		// Likely an action flash; make it pop a bit.
-		codes = []string{"\x1b[33m"}
+		codes = []string{th.flash}
 	}
 	header += styleIf(useColor, " — "+tagline, codes...)
 	writeLineAt(out, 1, 1, header, cols)
@@ -651,17 +875,7 @@ func drawList(out *bufio.Writer, state *appState, layout layout) {
 	for i := 0; i < layout.listHeight; i++ {
 		idx := state.scroll + i
 		if idx >= 0 && idx < len(state.results) {
-			item := state.results[idx]
-			label := item.Title
-			if label == "" {
-				label = item.ID
-			}
-			prefix := "  "
-			if idx == state.selected {
-				prefix = styleIf(state.useColor, "> ", "\x1b[1m", "\x1b[36m")
-				label = styleIf(state.useColor, label, "\x1b[1m")
-			}
-			writeLineAt(out, layout.contentTop+i, layout.listCol, prefix+label, layout.listWidth)
+			writeLineAt(out, layout.contentTop+i, layout.listCol, resultLabel(state, idx), layout.listWidth)
 		} else {
 			writeLineAt(out, layout.contentTop+i, layout.listCol, "", layout.listWidth)
 		}
@@ -680,7 +894,7 @@ func drawPreviewIfNeeded(out *bufio.Writer, state *appState, layout layout) {
 		return
 	}
 
-	label := styleIf(state.useColor, "Preview", "\x1b[90m")
+	label := styleIf(state.useColor, "Preview", state.theme().dim)
 	writeLineAt(out, layout.contentTop+layout.listHeight, 1, label, layout.cols)
 	state.previewRow = layout.previewRow
 	state.previewCol = layout.previewCol
@@ -698,21 +912,19 @@ func drawStatus(out *bufio.Writer, state *appState, layout layout) {
 	}
 	source := search.ResolveSource(state.opts.Source)
 	showGiphyAttribution := source == "giphy"
-	showGiphyIcon := showGiphyAttribution && state.inline == termcaps.InlineKitty
+	showGiphyIcon := showGiphyAttribution && state.inline == termcaps.InlineKitty && !state.kittyPlaceholders
 	logoCols := 2
 	logoRows := 1
-	statusWidth := layout.cols
-	if showGiphyIcon {
-		statusWidth = maxInt(0, layout.cols-(logoCols+1))
-	}
-	line := formatStatusLine(state.useColor, status)
+	width := statusWidth(state, layout)
+	line := formatStatusLine(state.useColor, state.theme(), status)
 	if showGiphyAttribution {
-		line += styleIf(state.useColor, " · Powered by GIPHY", "\x1b[90m")
+		line += styleIf(state.useColor, " · Powered by GIPHY", state.theme().dim)
 	}
-	writeLineAt(out, layout.statusRow, 1, line, statusWidth)
+	writeLineAt(out, layout.statusRow, 1, line, width)
+	drawTimeline(out, state, layout, width, line)
 	if showGiphyIcon && layout.cols >= logoCols {
 		moveCursor(out, layout.statusRow, maxInt(1, layout.cols-logoCols+1))
-		kitty.SendFrame(out, giphyAttributionImageID, gifdecode.Frame{PNG: assets.GiphyIcon32PNG()}, logoCols, logoRows)
+		state.sender.SendFrame(out, giphyAttributionImageID, gifdecode.Frame{PNG: assets.GiphyIcon32PNG()}, logoCols, logoRows)
 		state.giphyAttributionShown = true
 	} else if state.giphyAttributionShown && state.inline == termcaps.InlineKitty {
 		kitty.DeleteImage(out, giphyAttributionImageID)
@@ -720,7 +932,15 @@ func drawStatus(out *bufio.Writer, state *appState, layout layout) {
 	}
 }
 
-func formatStatusLine(useColor bool, status string) string {
+// statusWidth is the status row left of the GIPHY icon.
+func statusWidth(state *appState, layout layout) int {
+	if search.ResolveSource(state.opts.Source) == "giphy" && state.inline == termcaps.InlineKitty && !state.kittyPlaceholders {
+		return maxInt(0, layout.cols-3)
+	}
+	return layout.cols
+}
+
+func formatStatusLine(useColor bool, th theme, status string) string {
 	if !useColor {
 		return status
 	}
@@ -731,24 +951,43 @@ func formatStatusLine(useColor bool, status string) string {
 	if i > 0 && strings.HasPrefix(status[i:], " results") {
 		num := status[:i]
 		rest := status[i:]
-		return styleIf(true, num, "\x1b[1m", "\x1b[36m") + styleIf(true, rest, "\x1b[90m")
+		return styleIf(true, num, "\x1b[1m", th.accent) + styleIf(true, rest, th.dim)
 	}
-	return styleIf(true, status, "\x1b[90m")
+	return styleIf(true, status, th.dim)
 }
 
 func drawSearch(out *bufio.Writer, state *appState, layout layout) {
-	pill := "[Search]"
+	label := "Search"
 	query := state.query
+	editing := state.mode == modeQuery
+	switch state.mode {
+	case modeCaption:
+		label = "Caption"
+		query = state.captionText
+		editing = true
+	case modeFind:
+		label = "Find"
+		query = state.findText
+		editing = true
+	case modeFilter:
+		label = "Filter"
+		query = state.filterInput
+		editing = true
+	}
+	pill := "[" + label + "]"
 	if state.useColor {
-		bg := "\x1b[48;5;236m"
-		if state.mode == modeQuery {
-			pill = styleIf(true, " Search ", bg, "\x1b[1m", "\x1b[33m")
-			query += styleIf(true, "▍", "\x1b[36m")
+		th := state.theme()
+		if editing {
+			pill = styleIf(true, " "+label+" ", th.pill, "\x1b[1m", th.flash)
+			query += styleIf(true, "▍", th.accent)
 		} else {
-			pill = styleIf(true, " Search ", bg, "\x1b[90m")
+			pill = styleIf(true, " "+label+" ", th.pill, th.dim)
 		}
 	}
 	searchLine := pill + " " + query
+	if state.mode != modeFilter && state.filterText != "" {
+		searchLine += styleIf(state.useColor, "  · filter: "+state.filterText, state.theme().dim)
+	}
 	writeLineAt(out, layout.searchRow, 1, searchLine, layout.cols)
 }
 
@@ -757,17 +996,14 @@ func drawHints(out *bufio.Writer, state *appState, layout layout) {
 		if !state.useColor {
 			return key + " " + label
 		}
-		return styleIf(true, key, "\x1b[1m", "\x1b[36m") + " " + styleIf(true, label, "\x1b[90m")
-	}
-	hints := strings.Join([]string{
-		formatHint("⏎", "Search"),
-		formatHint("/", "Edit"),
-		formatHint("↑↓", "Select"),
-		formatHint("d", "Download"),
-		formatHint("c", "Copy"),
-		formatHint("f", "Reveal"),
-		formatHint("q", "Quit"),
-	}, "  ")
+		th := state.theme()
+		return styleIf(true, key, "\x1b[1m", th.accent) + " " + styleIf(true, label, th.dim)
+	}
+	var parts []string
+	for _, h := range state.keymap().hints() {
+		parts = append(parts, formatHint(h[0], h[1]))
+	}
+	hints := strings.Join(parts, "  ")
 	// Hints live below the content area; center across the full terminal width,
 	// even when the content is split (preview left / list right).
 	pad := maxInt(0, (layout.cols-visibleRuneLen(hints))/2)
@@ -885,7 +1121,15 @@ func drawPreview(state *appState, out *bufio.Writer, cols, rows int, row, col in
 	if len(state.currentAnim.Frames) == 0 {
 		return
 	}
-	if state.useSoftwareAnim && len(state.currentAnim.Frames) > 1 {
+	if drawsInText(state.inline) {
+		drawPreviewInText(state, out, cols, rows, row, col)
+		return
+	}
+	if state.kittyPlaceholders {
+		drawPreviewPlaceholders(state, out, cols, rows, row, col)
+		return
+	}
+	if playsInSoftware(state, state.currentAnim) {
 		drawPreviewSoftware(state, out, cols, rows, row, col)
 		return
 	}
@@ -894,7 +1138,8 @@ func drawPreview(state *appState, out *bufio.Writer, cols, rows int, row, col in
 			kitty.DeleteImage(out, state.activeImageID)
 		}
 		state.activeImageID = state.currentAnim.ID
-		kitty.SendAnimation(out, state.currentAnim.ID, state.currentAnim.Frames, cols, rows)
+		senderFor(state, state.currentAnim).SendAnimation(out, state.currentAnim.ID, state.currentAnim.Frames, cols, rows)
+		startNativePlayback(state, out)
 		state.previewNeedsSend = false
 		state.previewDirty = false
 		state.lastPreview.cols = cols
@@ -902,7 +1147,7 @@ func drawPreview(state *appState, out *bufio.Writer, cols, rows int, row, col in
 		return
 	}
 	if state.previewDirty || state.lastPreview.cols != cols || state.lastPreview.rows != rows {
-		kitty.PlaceImage(out, state.activeImageID, cols, rows)
+		state.sender.PlaceImage(out, state.activeImageID, cols, rows)
 		state.previewDirty = false
 		state.lastPreview.cols = cols
 		state.lastPreview.rows = rows
@@ -930,13 +1175,15 @@ func drawPreviewSoftware(state *appState, out *bufio.Writer, cols, rows int, row
 	state.activeImageID = state.currentAnim.ID
 	if state.previewNeedsSend {
 		state.manualAnim = true
-		state.manualFrame = 0
+		if !state.paused {
+			state.manualFrame = 0
+		}
 		frame := state.currentAnim.Frames[state.manualFrame]
 		saveCursor(out)
 		moveCursor(out, row, col)
-		kitty.SendFrame(out, state.activeImageID, frame, cols, rows)
+		senderFor(state, state.currentAnim).SendFrame(out, state.activeImageID, frame, cols, rows)
 		restoreCursor(out)
-		state.manualNext = time.Now().Add(frame.Delay)
+		state.manualNext = time.Now().Add(frameGap(state, frame.Delay))
 		state.previewNeedsSend = false
 		state.previewDirty = false
 		state.lastPreview.cols = cols
@@ -947,7 +1194,7 @@ func drawPreviewSoftware(state *appState, out *bufio.Writer, cols, rows int, row
 		frame := state.currentAnim.Frames[state.manualFrame]
 		saveCursor(out)
 		moveCursor(out, row, col)
-		kitty.SendFrame(out, state.activeImageID, frame, cols, rows)
+		senderFor(state, state.currentAnim).SendFrame(out, state.activeImageID, frame, cols, rows)
 		restoreCursor(out)
 		state.previewDirty = false
 		state.lastPreview.cols = cols
@@ -956,7 +1203,7 @@ func drawPreviewSoftware(state *appState, out *bufio.Writer, cols, rows int, row
 }
 
 func advanceManualAnimation(state *appState, out *bufio.Writer) {
-	if !state.manualAnim || state.currentAnim == nil {
+	if !state.manualAnim || state.currentAnim == nil || state.showHelp || state.paused {
 		return
 	}
 	if len(state.currentAnim.Frames) <= 1 {
@@ -973,12 +1220,8 @@ func advanceManualAnimation(state *appState, out *bufio.Writer) {
 		return
 	}
 	state.manualFrame = (state.manualFrame + 1) % len(state.currentAnim.Frames)
-	frame := state.currentAnim.Frames[state.manualFrame]
-	saveCursor(out)
-	moveCursor(out, state.previewRow, state.previewCol)
-	kitty.SendFrame(out, state.activeImageID, frame, state.lastPreview.cols, state.lastPreview.rows)
-	restoreCursor(out)
-	state.manualNext = now.Add(frame.Delay)
+	drawManualFrame(state, out)
+	state.manualNext = now.Add(frameGap(state, state.currentAnim.Frames[state.manualFrame].Delay))
 	_ = out.Flush()
 }
 
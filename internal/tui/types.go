@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/steipete/gifgrep/gifdecode"
+	"github.com/steipete/gifgrep/internal/kitty"
 	"github.com/steipete/gifgrep/internal/model"
 	"github.com/steipete/gifgrep/internal/termcaps"
 )
@@ -13,6 +14,16 @@ type mode int
 const (
 	modeBrowse mode = iota
 	modeQuery
+	modeCaption
+	modeFind
+	modeFilter
+)
+
+type view int
+
+const (
+	viewList view = iota
+	viewGrid
 )
 
 type gifAnimation struct {
@@ -21,21 +32,48 @@ type gifAnimation struct {
 	Frames []gifdecode.Frame
 	Width  int
 	Height int
+
+	// Downscaled is set once frames were halved after the terminal
+	// rejected them.
+	Downscaled bool
+	// Direct is set once the terminal failed to read the frames from a
+	// file or shared memory; they are then sent inline.
+	Direct bool
+	// Software is set once the terminal rejected the uploaded animation;
+	// its frames are then sent one at a time.
+	Software bool
+
+	// Encoded holds frames encoded for an EncodedCols×EncodedRows in-text
+	// preview (Sixel or blocks); software playback re-sends every frame,
+	// so each is encoded once per size.
+	Encoded     [][]byte
+	EncodedCols int
+	EncodedRows int
+	EncodedCell termcaps.CellSize
 }
 
 type gifCacheEntry struct {
-	RawGIF []byte
-	Frames *gifdecode.Frames
-	Width  int
-	Height int
+	RawGIF    []byte
+	ItermData []byte // RawGIF re-encoded for iTerm2 when it can't animate the source
+	Frames    *gifdecode.Frames
+	// FrameCount and Duration cover the whole file, unlike Frames, which
+	// stops at the decode limit; FrameCount is 0 until read, -1 if unreadable.
+	FrameCount int
+	Duration   time.Duration
+	Width      int
+	Height     int
+	MaxWidth   int
+	MaxHeight  int
 }
 
 type appState struct {
 	query         string
+	captionText   string
 	tagline       string
 	headerFlash   string
 	headerFlashAt time.Time
-	results       []model.Result
+	results       []model.Result // allResults after the filter
+	allResults    []model.Result
 	selected      int
 	scroll        int
 	mode          mode
@@ -72,8 +110,37 @@ type appState struct {
 	manualFrame           int
 	manualNext            time.Time
 	useSoftwareAnim       bool
+	kittyPlaceholders     bool
+	sender                kitty.Sender
+	textStyle             termcaps.TextStyle
 	useColor              bool
 	opts                  model.Options
 	giphyAttributionShown bool
 	lastSavedPath         string
+	keys                  keymap
+	showHelp              bool
+	count                 int    // digits typed before a motion
+	findText              string // in-list find, cycled with n/N
+	filterText            string // applied filter, see resultFilter
+	filterInput           string // filter prompt being edited
+	paused                bool
+	speed                 int // playback runs at 2^speed
+	playStart             time.Time
+	playStartFrame        int    // native Kitty playback: frame shown at playStart
+	timeline              string // playback readout last drawn in the status bar
+	lastClickIdx          int
+	lastClickAt           time.Time
+	view                  view
+	thumbs                map[string]*thumb // by resultKey
+	thumbLoading          map[string]bool
+	thumbGen              int
+	thumbCh               chan thumbResult
+	thumbSem              chan struct{}
+	gridSlots             []string        // resultKey per tile at the last render
+	marked                map[string]bool // by resultKey
+	markAnchor            string          // resultKey of the last mark
+	batchCh               chan batchProgress
+	batchRunning          bool
+	batchFailed           int
+	batchErr              error
 }
@@ -7,6 +7,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/mattn/go-runewidth"
+	"github.com/steipete/gifgrep/internal/termcaps"
 )
 
 func truncateRunes(s string, width int) string {
@@ -109,13 +110,15 @@ func minInt(a, b int) int {
 	return b
 }
 
+// cellAspectRatio is the cell's width over its height; GIFGREP_CELL_ASPECT
+// overrides the detected cell size.
 func cellAspectRatio() float64 {
 	if raw := strings.TrimSpace(os.Getenv("GIFGREP_CELL_ASPECT")); raw != "" {
 		if v, err := strconv.ParseFloat(raw, 64); err == nil && v > 0.1 && v < 2 {
 			return v
 		}
 	}
-	return 0.5
+	return termcaps.CellSizeOr(termcaps.DefaultCellSize).Aspect()
 }
 
 func useSoftwareAnimation() bool {
@@ -131,6 +134,13 @@ func useSoftwareAnimation() bool {
 	return false
 }
 
+// kittyReplies reports whether GIFGREP_KITTY_REPLIES asks for Kitty's
+// error replies.
+func kittyReplies() bool {
+	raw := strings.ToLower(strings.TrimSpace(os.Getenv("GIFGREP_KITTY_REPLIES")))
+	return raw == "1" || raw == "true" || raw == "yes"
+}
+
 func styleIf(enabled bool, text string, codes ...string) string {
 	if !enabled || len(codes) == 0 {
 		return text